@@ -0,0 +1,35 @@
+package alice
+
+import "golang.org/x/net/context"
+
+type abortedKey struct{}
+
+// Abort returns a copy of ctx marked as aborted. A middleware that has
+// already handled a request, for instance by writing an error, but
+// still calls the next handler passes it on so later middleware and
+// the handler can skip expensive work:
+//
+//	if !allowed {
+//	    w.WriteHeader(http.StatusForbidden)
+//	    next.ServeHTTPContext(alice.Abort(ctx), w, r) // e.g. for auditing
+//	    return
+//	}
+//
+// and, further down the chain:
+//
+//	if alice.IsAborted(ctx) {
+//	    next.ServeHTTPContext(ctx, w, r)
+//	    return
+//	}
+func Abort(ctx context.Context) context.Context {
+	if IsAborted(ctx) {
+		return ctx
+	}
+	return context.WithValue(ctx, abortedKey{}, true)
+}
+
+// IsAborted reports whether ctx was marked by Abort.
+func IsAborted(ctx context.Context) bool {
+	aborted, _ := ctx.Value(abortedKey{}).(bool)
+	return aborted
+}
@@ -0,0 +1,34 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestAbort(t *testing.T) {
+	deny := func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			next.ServeHTTPContext(Abort(ctx), w, r)
+		})
+	}
+	var aborted bool
+	h := New(deny, passThrough).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		aborted = IsAborted(ctx)
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Code, http.StatusForbidden)
+	assert.True(t, aborted)
+	assert.False(t, IsAborted(context.Background()))
+}
+
+func TestAbortTwice(t *testing.T) {
+	ctx := Abort(context.Background())
+	assert.Equal(t, Abort(ctx), ctx)
+}
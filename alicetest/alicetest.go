@@ -0,0 +1,123 @@
+// Package alicetest provides utilities for testing middleware and chains.
+//
+//	func TestAuth(t *testing.T) {
+//	    ct := alicetest.New(t, alice.New(alice.RequestID(), auth.Require()))
+//	    res := ct.Get("/")
+//	    assert.Equal(t, res.Code, http.StatusUnauthorized)
+//	    assert.False(t, res.Reached)
+//	    assert.Equal(t, res.Order, []string{"alice.RequestID", "auth.Require"})
+//	}
+package alicetest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// ChainTester runs a chain against test requests.
+type ChainTester struct {
+	t     testing.TB
+	chain alice.Chain
+
+	// Context is the context the chain is served with.
+	// It defaults to context.Background().
+	Context context.Context
+	// Handler is the handler at the end of the chain. It defaults to OK.
+	Handler alice.ContextHandler
+}
+
+// Result is the outcome of a request run by a ChainTester.
+type Result struct {
+	*httptest.ResponseRecorder
+	// Order lists the middleware that was entered, in order,
+	// named after the functions that built their constructors.
+	Order []string
+	// Reached reports whether the request got to the Handler.
+	Reached bool
+	// Context and Request are those the Handler was called with,
+	// nil if it was not reached.
+	Context context.Context
+	Request *http.Request
+}
+
+// New returns a ChainTester for chain.
+func New(t testing.TB, chain alice.Chain) *ChainTester {
+	return &ChainTester{t: t, chain: chain}
+}
+
+// Do runs r through the chain.
+func (ct *ChainTester) Do(r *http.Request) *Result {
+	ct.t.Helper()
+	res := &Result{ResponseRecorder: httptest.NewRecorder()}
+	var mu sync.Mutex
+
+	constructors := ct.chain.Constructors()
+	for i, cons := range constructors {
+		name := Name(cons)
+		constructors[i] = func(next alice.ContextHandler) alice.ContextHandler {
+			h := cons(next)
+			if h == nil {
+				ct.t.Fatalf("alicetest: constructor %d (%s) returned a nil handler", i, name)
+			}
+			return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				res.Order = append(res.Order, name)
+				mu.Unlock()
+				h.ServeHTTPContext(ctx, w, r)
+			})
+		}
+	}
+
+	final := ct.Handler
+	if final == nil {
+		final = OK
+	}
+	ctx := ct.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	h := alice.New(constructors...).ThenFuncWithContext(ctx, func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		res.Reached, res.Context, res.Request = true, ctx, r
+		mu.Unlock()
+		final.ServeHTTPContext(ctx, w, r)
+	})
+	h.ServeHTTP(res.ResponseRecorder, r)
+	return res
+}
+
+// Get runs a GET request for target through the chain.
+func (ct *ChainTester) Get(target string) *Result {
+	ct.t.Helper()
+	return ct.Do(httptest.NewRequest("GET", target, nil))
+}
+
+// OK is a stub handler responding 200 OK with the body "ok".
+var OK = Status(http.StatusOK)
+
+// Status returns a stub handler responding with code and its status text.
+func Status(code int) alice.ContextHandler {
+	return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+		w.Write([]byte(strings.ToLower(http.StatusText(code))))
+	})
+}
+
+// Panic returns a stub handler panicking with v.
+func Panic(v any) alice.ContextHandler {
+	return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		panic(v)
+	})
+}
+
+// Name returns a short name for a constructor, such as "alice.RequestID"
+// for the constructor returned by alice.RequestID().
+func Name(c alice.Constructor) string {
+	return alice.ConstructorName(c)
+}
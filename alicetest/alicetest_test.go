@@ -0,0 +1,52 @@
+package alicetest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type userKey struct{}
+
+func withUser(next alice.ContextHandler) alice.ContextHandler {
+	return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTPContext(context.WithValue(ctx, userKey{}, "alice"), w, r)
+	})
+}
+
+func deny(next alice.ContextHandler) alice.ContextHandler {
+	return Status(http.StatusForbidden)
+}
+
+func TestChainTesterRecordsOrder(t *testing.T) {
+	ct := New(t, alice.New(alice.RequestID(), withUser))
+	res := ct.Get("/")
+
+	assert.Equal(t, res.Code, http.StatusOK)
+	assert.Equal(t, res.Body.String(), "ok")
+	assert.Equal(t, res.Order, []string{"alice.RequestID", "alicetest.withUser"})
+	assert.True(t, res.Reached)
+	assert.Equal(t, res.Context.Value(userKey{}), "alice")
+	assert.NotEqual(t, alice.RequestIDFrom(res.Context), "")
+}
+
+func TestChainTesterShortCircuit(t *testing.T) {
+	ct := New(t, alice.New(deny, withUser))
+	res := ct.Get("/")
+
+	assert.Equal(t, res.Code, http.StatusForbidden)
+	assert.Equal(t, res.Order, []string{"alicetest.deny"})
+	assert.False(t, res.Reached)
+	assert.Nil(t, res.Context)
+}
+
+func TestChainTesterHandler(t *testing.T) {
+	ct := New(t, alice.New())
+	ct.Handler = Status(http.StatusTeapot)
+	res := ct.Get("/")
+	assert.Equal(t, res.Code, http.StatusTeapot)
+	assert.Equal(t, res.Body.String(), "i'm a teapot")
+}
@@ -0,0 +1,160 @@
+package alicetest
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// Conformance checks that middleware built by cons keeps the capabilities
+// of the server's http.ResponseWriter, running a real server.
+// It fails t unless handlers behind the middleware can:
+//   - hijack the connection through an http.Hijacker type assertion,
+//     as WebSocket libraries do,
+//   - hijack it through http.ResponseController,
+//   - flush the response and set connection deadlines.
+//
+// Wrappers pass these checks by implementing Unwrap and Hijack:
+//
+//	func TestWebSocketCompatible(t *testing.T) {
+//	    alicetest.Conformance(t, mymiddleware.New())
+//	}
+func Conformance(t *testing.T, cons alice.Constructor) {
+	t.Helper()
+	t.Run("Hijacker", func(t *testing.T) {
+		checkHijack(t, cons, func(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+			h, ok := w.(http.Hijacker)
+			if !ok {
+				return nil, nil, errors.New("response writer is not an http.Hijacker")
+			}
+			return h.Hijack()
+		})
+	})
+	t.Run("ResponseController", func(t *testing.T) {
+		checkHijack(t, cons, func(w http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+			return http.NewResponseController(w).Hijack()
+		})
+	})
+	t.Run("Flush", func(t *testing.T) {
+		checkStream(t, cons)
+	})
+}
+
+// serve runs h behind cons on a test server, reporting an error
+// returned by h once the test ends.
+func serve(t *testing.T, cons alice.Constructor, h func(w http.ResponseWriter) error) *httptest.Server {
+	errs := make(chan error, 1)
+	handler := alice.New(cons).ThenWithContext(context.Background(), alice.ContextHandlerFunc(
+		func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			errs <- h(w)
+		}))
+	srv := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		srv.Close()
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Errorf("handler: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("handler did not finish")
+		}
+	})
+	return srv
+}
+
+// checkHijack upgrades a connection with hijack and echoes a line over it.
+func checkHijack(t *testing.T, cons alice.Constructor, hijack func(http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error)) {
+	t.Helper()
+	srv := serve(t, cons, func(w http.ResponseWriter) error {
+		conn, brw, err := hijack(w)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		if err := brw.Flush(); err != nil {
+			return err
+		}
+		line, err := brw.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		brw.WriteString(line)
+		return brw.Flush()
+	})
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(res.Body)
+		t.Fatalf("status %d, want 101: %s", res.StatusCode, body)
+	}
+	io.WriteString(conn, "ping\n")
+	line, err := br.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "ping\n" {
+		t.Errorf("echoed %q, want %q", line, "ping\n")
+	}
+}
+
+// checkStream flushes a partial response and sets deadlines,
+// reading the flushed part before the handler finishes.
+func checkStream(t *testing.T, cons alice.Constructor) {
+	t.Helper()
+	done := make(chan struct{})
+	srv := serve(t, cons, func(w http.ResponseWriter) error {
+		rc := http.NewResponseController(w)
+		if err := rc.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			return err
+		}
+		if err := rc.SetWriteDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			return err
+		}
+		io.WriteString(w, "first\n")
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			return errors.New("flushed data was not received")
+		}
+		return nil
+	})
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	close(done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "first\n" {
+		t.Errorf("read %q, want %q", line, "first\n")
+	}
+}
@@ -0,0 +1,27 @@
+package alicetest
+
+import (
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/SimiPro/alice/cookies"
+)
+
+func TestBuiltinWrappersConform(t *testing.T) {
+	codec := &cookies.Codec{Keys: [][]byte{[]byte("0123456789abcdef0123456789abcdef")}}
+	for name, cons := range map[string]alice.Constructor{
+		"Recover":      alice.Recover(alice.RecoverOptions{}),
+		"CollectStats": alice.CollectStats(),
+		"ServerTiming": alice.ServerTiming(),
+		"ServerPush":   alice.ServerPush(),
+		"Pagination":   alice.Pagination(alice.PageOptions{}),
+		"Compress":     alice.Compress(),
+		"Ranges":       alice.Ranges(),
+		"Logging":      alice.Logging(nil),
+		"Flash":        cookies.Flash(codec),
+	} {
+		t.Run(name, func(t *testing.T) {
+			Conformance(t, cons)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package alice
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// APIKeyInfo describes a valid API key.
+type APIKeyInfo struct {
+	// ID identifies the key without revealing it, e.g. in logs.
+	ID string
+	// Owner is the user or client the key belongs to.
+	Owner string
+	// Scopes are the scopes granted to the key, checked by RequireScopes.
+	Scopes []string
+	// Meta holds application-specific attributes, such as the plan.
+	Meta map[string]string
+}
+
+// ErrAPIKeyNotFound is returned by key stores for unknown or revoked keys.
+// APIKey responds 401.
+var ErrAPIKeyNotFound = errors.New("alice: API key not found")
+
+// KeyStore looks up API keys.
+type KeyStore interface {
+	// LookupAPIKey returns the key stored under key, which is the key
+	// as sent by the client or, with APIKeyOptions.Hashed, its HashAPIKey.
+	LookupAPIKey(ctx context.Context, key string) (APIKeyInfo, error)
+}
+
+// KeyStoreFunc is a function implementing KeyStore.
+type KeyStoreFunc func(ctx context.Context, key string) (APIKeyInfo, error)
+
+// LookupAPIKey calls f.
+func (f KeyStoreFunc) LookupAPIKey(ctx context.Context, key string) (APIKeyInfo, error) {
+	return f(ctx, key)
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 hash of key,
+// under which stores keep keys with APIKeyOptions.Hashed.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyOptions configure APIKey.
+type APIKeyOptions struct {
+	// Header is the request header carrying the key;
+	// it defaults to "X-API-Key".
+	Header string
+	// Query, if set, names a query parameter also accepted,
+	// for clients unable to set headers.
+	Query string
+	// Hashed makes APIKey look keys up by their HashAPIKey,
+	// so stores need not keep them in the clear.
+	Hashed bool
+	// Optional lets requests without a key through anonymously.
+	Optional bool
+}
+
+type apiKeyKey struct{}
+
+// APIKey returns a Constructor authenticating requests by API key.
+// The key is looked up in store and its APIKeyInfo stored in the
+// context (see APIKeyFrom), along with a Principal for RequireScopes
+// and the rate limiting key "apikey:" + ID (see RateLimitKey).
+// Requests with a missing or unknown key get 401 Unauthorized.
+//
+//	alice.New(alice.APIKey(keys, alice.APIKeyOptions{Hashed: true}), alice.RequireScopes("reports:read"))
+func APIKey(store KeyStore, opts APIKeyOptions) Constructor {
+	if opts.Header == "" {
+		opts.Header = "X-API-Key"
+	}
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(opts.Header)
+			if key == "" && opts.Query != "" {
+				key = r.URL.Query().Get(opts.Query)
+			}
+			if key == "" {
+				if opts.Optional {
+					next.ServeHTTPContext(ctx, w, r)
+					return
+				}
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusUnauthorized,
+					Code:   "missing_api_key",
+				})
+				return
+			}
+			if opts.Hashed {
+				key = HashAPIKey(key)
+			}
+			info, err := store.LookupAPIKey(ctx, key)
+			switch {
+			case errors.Is(err, ErrAPIKeyNotFound):
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusUnauthorized,
+					Code:   "invalid_api_key",
+					Err:    err,
+				})
+				return
+			case err != nil:
+				WriteError(ctx, w, err)
+				return
+			}
+			subject := info.Owner
+			if subject == "" {
+				subject = info.ID
+			}
+			ctx = context.WithValue(ctx, apiKeyKey{}, info)
+			ctx = WithPrincipal(ctx, Principal{Subject: subject, Scopes: info.Scopes})
+			ctx = WithRateLimitKey(ctx, "apikey:"+info.ID)
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+// APIKeyFrom returns the API key stored by APIKey.
+func APIKeyFrom(ctx context.Context) (APIKeyInfo, bool) {
+	info, ok := ctx.Value(apiKeyKey{}).(APIKeyInfo)
+	return info, ok
+}
+
+type rateLimitKey struct{}
+
+// WithRateLimitKey returns a copy of ctx in which requests are
+// rate limited under key, e.g. per API key or user rather than per IP.
+func WithRateLimitKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, rateLimitKey{}, key)
+}
+
+// RateLimitKey returns the key rate limiters should count r under:
+// the one set with WithRateLimitKey if any, "ip:" followed by the
+// client IP (see ClientIP and ProxyConfig.ClientIP) otherwise.
+func RateLimitKey(ctx context.Context, r *http.Request) string {
+	if key, ok := ctx.Value(rateLimitKey{}).(string); ok {
+		return key
+	}
+	return "ip:" + requestIP(ctx, r).String()
+}
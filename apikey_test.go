@@ -0,0 +1,81 @@
+package alice
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var testKeys = KeyStoreFunc(func(ctx context.Context, key string) (APIKeyInfo, error) {
+	switch key {
+	case HashAPIKey("secret"):
+		return APIKeyInfo{ID: "k1", Owner: "billing", Scopes: []string{"reports:read"}, Meta: map[string]string{"plan": "pro"}}, nil
+	case "broken":
+		return APIKeyInfo{}, errors.New("database down")
+	}
+	return APIKeyInfo{}, ErrAPIKeyNotFound
+})
+
+func TestAPIKey(t *testing.T) {
+	var info APIKeyInfo
+	var limitKey string
+	h := New(APIKey(testKeys, APIKeyOptions{Query: "api_key", Hashed: true}), RequireScopes("reports:read")).
+		ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			info, _ = APIKeyFrom(ctx)
+			limitKey = RateLimitKey(ctx, r)
+		})
+
+	r := httptest.NewRequest("GET", "/reports", nil)
+	r.Header.Set("X-API-Key", "secret")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, info.Meta["plan"], "pro")
+	assert.Equal(t, limitKey, "apikey:k1")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/reports?api_key=secret", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+}
+
+func TestAPIKeyRejects(t *testing.T) {
+	h := New(APIKey(testKeys, APIKeyOptions{})).ThenWithContext(context.Background(), testApp)
+	tests := []struct {
+		key    string
+		status int
+		code   string
+	}{
+		{"", http.StatusUnauthorized, "missing_api_key"},
+		{"wrong", http.StatusUnauthorized, "invalid_api_key"},
+		// Without Hashed, the hash itself is not a valid key.
+		{"secret", http.StatusUnauthorized, "invalid_api_key"},
+		{"broken", http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/reports?api_key=secret", nil)
+		r.Header.Set("X-API-Key", tt.key)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		assert.Equal(t, w.Code, tt.status, tt.key)
+		assert.Contains(t, w.Body.String(), tt.code)
+	}
+}
+
+func TestAPIKeyOptional(t *testing.T) {
+	var limitKey string
+	h := New(APIKey(testKeys, APIKeyOptions{Optional: true})).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		limitKey = RateLimitKey(ctx, r)
+	})
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, limitKey, "ip:192.0.2.1")
+}
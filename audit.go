@@ -0,0 +1,213 @@
+package alice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Outcomes of an audited request.
+const (
+	AuditSuccess = "success"
+	AuditDenied  = "denied"
+	AuditFailure = "failure"
+)
+
+// AuditEvent describes a completed mutating request.
+type AuditEvent struct {
+	Time time.Time `json:"time"`
+	// Actor identifies who made the request, see SetAuditActor.
+	Actor string `json:"actor,omitempty"`
+	// Action is the request method, unless set with SetAuditAction.
+	Action string `json:"action"`
+	// Resource is the request path, unless set with SetAuditResource.
+	Resource string `json:"resource"`
+	// Outcome is AuditSuccess, AuditDenied (401 and 403)
+	// or AuditFailure.
+	Outcome   string        `json:"outcome"`
+	Status    int           `json:"status"`
+	RequestID string        `json:"request_id,omitempty"`
+	ClientIP  string        `json:"client_ip,omitempty"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// An AuditSink receives audit events.
+type AuditSink interface {
+	Emit(ctx context.Context, ev AuditEvent) error
+}
+
+// AuditSinkFunc adapts a function to an AuditSink.
+type AuditSinkFunc func(ctx context.Context, ev AuditEvent) error
+
+// Emit calls f(ctx, ev).
+func (f AuditSinkFunc) Emit(ctx context.Context, ev AuditEvent) error {
+	return f(ctx, ev)
+}
+
+type auditKey struct{}
+
+// auditRecord collects the details set further down the chain.
+type auditRecord struct {
+	actor, action, resource string
+}
+
+// Audit returns a Constructor emitting an AuditEvent to sink
+// after each mutating (POST, PUT, PATCH or DELETE) request completes.
+// The actor is the one set with SetAuditActor further down the chain,
+// the subject of the principal (see PrincipalFrom) or of the client
+// certificate (see ClientCert) otherwise.
+// Sink errors are logged and do not affect the response.
+func Audit(sink AuditSink) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if !isUnsafe(r.Method) {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			start := time.Now()
+			rec := &auditRecord{action: r.Method, resource: r.URL.Path}
+			if p, ok := PrincipalFrom(ctx); ok {
+				rec.actor = p.Subject
+			} else if id, ok := ClientCertFrom(ctx); ok {
+				rec.actor = id.Subject
+			}
+			ctx = context.WithValue(ctx, auditKey{}, rec)
+			rw := NewResponseRecorder(w)
+			next.ServeHTTPContext(ctx, rw, r)
+
+			ev := AuditEvent{
+				Time:      start,
+				Actor:     rec.actor,
+				Action:    rec.action,
+				Resource:  rec.resource,
+				Status:    rw.Status(),
+				RequestID: requestIDOf(ctx, r),
+				Duration:  time.Since(start),
+			}
+			ev.Outcome = auditOutcome(ev.Status)
+			if ip := ClientIP(ctx); ip.IsValid() {
+				ev.ClientIP = ip.String()
+			}
+			if err := sink.Emit(ctx, ev); err != nil {
+				logger().Error("alice: audit sink failed", "error", err)
+			}
+		})
+	}
+}
+
+func auditOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return AuditDenied
+	case status >= 400:
+		return AuditFailure
+	default:
+		return AuditSuccess
+	}
+}
+
+// SetAuditActor sets the actor of the request audited in ctx,
+// typically from authentication middleware.
+// It does nothing if the request is not audited.
+func SetAuditActor(ctx context.Context, actor string) {
+	if rec, ok := ctx.Value(auditKey{}).(*auditRecord); ok {
+		rec.actor = actor
+	}
+}
+
+// SetAuditAction overrides the action of the request audited in ctx,
+// e.g. "user.delete".
+func SetAuditAction(ctx context.Context, action string) {
+	if rec, ok := ctx.Value(auditKey{}).(*auditRecord); ok {
+		rec.action = action
+	}
+}
+
+// SetAuditResource overrides the resource of the request audited in ctx,
+// e.g. "user:42".
+func SetAuditResource(ctx context.Context, resource string) {
+	if rec, ok := ctx.Value(auditKey{}).(*auditRecord); ok {
+		rec.resource = resource
+	}
+}
+
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterAuditSink returns an AuditSink writing events to w
+// as JSON lines, e.g. to os.Stdout or an opened file.
+func NewWriterAuditSink(w io.Writer) AuditSink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Emit(ctx context.Context, ev AuditEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+// HTTPAuditTimeout bounds each post of the sinks returned by
+// NewHTTPAuditSink, so a hung endpoint does not hold up requests.
+const HTTPAuditTimeout = 5 * time.Second
+
+// NewHTTPAuditSink returns an AuditSink posting each event
+// as JSON to url using client (http.DefaultClient if nil),
+// giving up after HTTPAuditTimeout.
+func NewHTTPAuditSink(url string, client *http.Client) AuditSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return AuditSinkFunc(func(ctx context.Context, ev AuditEvent) error {
+		b, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest("POST", url, bytes.NewReader(b))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		ctx, cancel := context.WithTimeout(Detach(ctx), HTTPAuditTimeout)
+		defer cancel()
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("alice: audit sink responded %s", resp.Status)
+		}
+		return nil
+	})
+}
+
+// A MessageProducer publishes messages to a topic-based broker
+// such as Kafka. Implement it on top of the client library in use.
+type MessageProducer interface {
+	Produce(ctx context.Context, key, value []byte) error
+}
+
+// NewProducerAuditSink returns an AuditSink publishing each event as JSON
+// through p, keyed by actor.
+func NewProducerAuditSink(p MessageProducer) AuditSink {
+	return AuditSinkFunc(func(ctx context.Context, ev AuditEvent) error {
+		b, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		return p.Produce(Detach(ctx), []byte(ev.Actor), b)
+	})
+}
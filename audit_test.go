@@ -0,0 +1,80 @@
+package alice
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestAuditEmitsMutatingRequests(t *testing.T) {
+	var events []AuditEvent
+	sink := AuditSinkFunc(func(ctx context.Context, ev AuditEvent) error {
+		events = append(events, ev)
+		return nil
+	})
+	h := New(Audit(sink)).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		SetAuditActor(ctx, "alice")
+		SetAuditResource(ctx, "user:42")
+		if r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+
+	r := httptest.NewRequest("GET", "/users/42", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, len(events), 0)
+
+	r = httptest.NewRequest("DELETE", "/users/42", nil)
+	r.Header.Set("Authorization", "Bearer x")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	r = httptest.NewRequest("DELETE", "/users/42", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, len(events), 2)
+	assert.Equal(t, events[0].Actor, "alice")
+	assert.Equal(t, events[0].Action, "DELETE")
+	assert.Equal(t, events[0].Resource, "user:42")
+	assert.Equal(t, events[0].Outcome, AuditSuccess)
+	assert.Equal(t, events[1].Outcome, AuditDenied)
+	assert.Equal(t, events[1].Status, http.StatusForbidden)
+}
+
+func TestWriterAuditSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterAuditSink(&buf)
+	sink.Emit(context.Background(), AuditEvent{Actor: "alice", Action: "POST", Outcome: AuditSuccess})
+
+	var ev AuditEvent
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &ev))
+	assert.Equal(t, ev.Actor, "alice")
+}
+
+func TestHTTPAuditSink(t *testing.T) {
+	var got AuditEvent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	err := NewHTTPAuditSink(srv.URL, nil).Emit(context.Background(), AuditEvent{Action: "PUT"})
+	assert.Nil(t, err)
+	assert.Equal(t, got.Action, "PUT")
+}
+
+func TestHTTPAuditSinkTimesOut(t *testing.T) {
+	var deadline time.Time
+	client := &http.Client{Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		deadline, _ = req.Context().Deadline()
+		return nil, req.Context().Err()
+	})}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	NewHTTPAuditSink("http://audit.example/", client).Emit(ctx, AuditEvent{Action: "PUT"})
+	assert.WithinDuration(t, deadline, time.Now().Add(HTTPAuditTimeout), time.Second)
+}
@@ -0,0 +1,199 @@
+// Package auth provides authentication for alice chains:
+// handlers for the OpenID Connect login flow (see OIDC),
+// sessions remembering the signed-in identity (see SessionStore)
+// and middleware storing it in the context (see Authenticate).
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// Identity is a signed-in user.
+type Identity struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email,omitempty"`
+	Name    string `json:"name,omitempty"`
+	// Roles are the roles of the user, checked by alice.RequireRoles.
+	Roles []string `json:"roles,omitempty"`
+	// IDToken is the raw ID token the identity was taken from.
+	IDToken string `json:"id_token,omitempty"`
+	// Expiry is when the session ends.
+	Expiry time.Time `json:"exp"`
+}
+
+// Principal returns the alice.Principal of id.
+func (id Identity) Principal() alice.Principal {
+	return alice.Principal{Subject: id.Subject, Roles: id.Roles}
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a copy of ctx carrying id,
+// also as the principal returned by alice.PrincipalFrom.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	ctx = context.WithValue(ctx, identityKey{}, id)
+	return alice.WithPrincipal(ctx, id.Principal())
+}
+
+// IdentityFrom returns the identity stored by Authenticate.
+func IdentityFrom(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// SessionStore keeps the identity of signed-in users across requests.
+type SessionStore interface {
+	// Load returns the identity of the unexpired session of r, if any.
+	Load(r *http.Request) (Identity, bool)
+	// Save starts a session for id.
+	Save(w http.ResponseWriter, r *http.Request, id Identity) error
+	// Clear ends the session of r.
+	Clear(w http.ResponseWriter, r *http.Request)
+}
+
+// MinCookieKeySize is the length of the shortest Key of a CookieStore.
+const MinCookieKeySize = 32
+
+// maxCookieSize is the size of the largest cookie browsers keep.
+const maxCookieSize = 4096
+
+// CookieStore is a SessionStore keeping the identity in a cookie
+// signed with HMAC-SHA256. It is readable by the user, but not
+// modifiable.
+type CookieStore struct {
+	// Key signs the cookie; it must be at least MinCookieKeySize
+	// random bytes, or no session is saved nor loaded.
+	Key []byte
+	// Name is the name of the cookie; it defaults to "alice_session".
+	Name string
+	// MaxAge is the lifetime of sessions; it defaults to 24 hours.
+	MaxAge time.Duration
+	// OmitIDToken leaves the ID token out of the cookie. Browsers drop
+	// cookies larger than 4 KB, which large ID tokens can make it, so
+	// Save fails for them unless it is set. Without the token, Logout
+	// cannot pass it to the provider as a hint.
+	OmitIDToken bool
+}
+
+func (s *CookieStore) name() string {
+	if s.Name == "" {
+		return "alice_session"
+	}
+	return s.Name
+}
+
+func (s *CookieStore) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Load implements SessionStore.
+func (s *CookieStore) Load(r *http.Request) (Identity, bool) {
+	c, err := r.Cookie(s.name())
+	if err != nil || len(s.Key) < MinCookieKeySize {
+		return Identity{}, false
+	}
+	payload, sig, _ := strings.Cut(c.Value, ".")
+	if !hmac.Equal([]byte(sig), []byte(s.sign(payload))) {
+		return Identity{}, false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Identity{}, false
+	}
+	var id Identity
+	if json.Unmarshal(b, &id) != nil || !time.Now().Before(id.Expiry) {
+		return Identity{}, false
+	}
+	return id, true
+}
+
+// Save implements SessionStore.
+// The session ends at id.Expiry, or after MaxAge if it is zero.
+func (s *CookieStore) Save(w http.ResponseWriter, r *http.Request, id Identity) error {
+	if len(s.Key) < MinCookieKeySize {
+		return errors.New("auth: CookieStore key is shorter than 32 bytes")
+	}
+	if s.OmitIDToken {
+		id.IDToken = ""
+	}
+	if id.Expiry.IsZero() {
+		maxAge := s.MaxAge
+		if maxAge <= 0 {
+			maxAge = 24 * time.Hour
+		}
+		id.Expiry = time.Now().Add(maxAge)
+	}
+	b, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	value := payload + "." + s.sign(payload)
+	if len(s.name())+len(value) > maxCookieSize {
+		return errors.New("auth: session cookie is larger than 4 KB")
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.name(),
+		Value:    value,
+		Path:     "/",
+		Expires:  id.Expiry,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// Clear implements SessionStore.
+func (s *CookieStore) Clear(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     s.name(),
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Authenticate returns a Constructor storing the identity of the
+// session of the request in the context (see IdentityFrom).
+// Requests without a session are passed on anonymously if loginURL
+// is empty. Otherwise GET and HEAD requests are redirected to loginURL
+// with a return_to parameter (see OIDC.Login), and others get
+// 401 Unauthorized.
+func Authenticate(store SessionStore, loginURL string) alice.Constructor {
+	return func(next alice.ContextHandler) alice.ContextHandler {
+		return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			id, ok := store.Load(r)
+			switch {
+			case ok:
+				ctx = WithIdentity(ctx, id)
+			case loginURL == "":
+			case r.Method == "GET" || r.Method == "HEAD":
+				http.Redirect(w, r, loginURL+"?return_to="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+				return
+			default:
+				alice.WriteError(ctx, w, &alice.HTTPError{
+					Status: http.StatusUnauthorized,
+					Code:   "unauthenticated",
+				})
+				return
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var testStore = &CookieStore{Key: []byte("0123456789abcdef0123456789abcdef")}
+
+// sessionCookie returns the cookie set by saving id in store.
+func sessionCookie(t *testing.T, store SessionStore, id Identity) *http.Cookie {
+	w := httptest.NewRecorder()
+	if err := store.Save(w, httptest.NewRequest("GET", "/", nil), id); err != nil {
+		t.Fatal(err)
+	}
+	return w.Result().Cookies()[0]
+}
+
+func TestCookieStore(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(sessionCookie(t, testStore, Identity{Subject: "ann", Roles: []string{"admin"}}))
+
+	id, ok := testStore.Load(r)
+	assert.True(t, ok)
+	assert.Equal(t, id.Subject, "ann")
+	assert.Equal(t, id.Roles, []string{"admin"})
+	assert.WithinDuration(t, id.Expiry, time.Now().Add(24*time.Hour), time.Minute)
+
+	w := httptest.NewRecorder()
+	testStore.Clear(w, r)
+	assert.Equal(t, w.Result().Cookies()[0].MaxAge, -1)
+}
+
+func TestCookieStoreRejects(t *testing.T) {
+	valid := sessionCookie(t, testStore, Identity{Subject: "ann"})
+	tampered := *valid
+	tampered.Value = "x" + tampered.Value
+	expired := sessionCookie(t, testStore, Identity{Subject: "ann", Expiry: time.Now().Add(-time.Second)})
+	otherKey := sessionCookie(t, &CookieStore{Key: []byte("another key, also 32 bytes long.")}, Identity{Subject: "ann"})
+
+	for _, c := range []*http.Cookie{&tampered, expired, otherKey} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.AddCookie(c)
+		_, ok := testStore.Load(r)
+		assert.False(t, ok)
+	}
+	assert.NotNil(t, (&CookieStore{}).Save(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), Identity{}))
+}
+
+func TestCookieStoreRequiresKey(t *testing.T) {
+	short := &CookieStore{Key: []byte("short")}
+	assert.NotNil(t, short.Save(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), Identity{Subject: "ann"}))
+
+	// A cookie forged with the empty key of a misconfigured store.
+	forger := &CookieStore{}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"ann","exp":"2999-01-01T00:00:00Z"}`))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "alice_session", Value: payload + "." + forger.sign(payload)})
+	_, ok := forger.Load(r)
+	assert.False(t, ok)
+}
+
+func TestCookieStoreIDToken(t *testing.T) {
+	id := Identity{Subject: "ann", IDToken: strings.Repeat("x", 4096)}
+	assert.NotNil(t, testStore.Save(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), id))
+
+	store := &CookieStore{Key: testStore.Key, OmitIDToken: true}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(sessionCookie(t, store, id))
+	loaded, ok := store.Load(r)
+	assert.True(t, ok)
+	assert.Equal(t, loaded.Subject, "ann")
+	assert.Empty(t, loaded.IDToken)
+}
+
+func TestAuthenticate(t *testing.T) {
+	var got Identity
+	app := alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		got, _ = IdentityFrom(ctx)
+	})
+	h := alice.New(Authenticate(testStore, "/login"), alice.RequireRoles("admin")).ThenWithContext(context.Background(), app)
+
+	r := httptest.NewRequest("GET", "/pets?page=2", nil)
+	r.AddCookie(sessionCookie(t, testStore, Identity{Subject: "ann", Roles: []string{"admin"}}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, got.Subject, "ann")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/pets?page=2", nil))
+	assert.Equal(t, w.Code, http.StatusFound)
+	assert.Equal(t, w.Header().Get("Location"), "/login?return_to=%2Fpets%3Fpage%3D2")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/pets", nil))
+	assert.Equal(t, w.Code, http.StatusUnauthorized)
+}
+
+func TestAuthenticateAnonymous(t *testing.T) {
+	var ok bool
+	h := alice.New(Authenticate(testStore, "")).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		_, ok = IdentityFrom(ctx)
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.False(t, ok)
+}
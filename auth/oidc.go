@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/SimiPro/alice"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// flowCookie holds the state of a login between Login and Callback.
+const flowCookie = "alice_oidc"
+
+// Config configures an OIDC relying party.
+type Config struct {
+	// Issuer is the URL of the OpenID provider, e.g.
+	// "https://accounts.google.com".
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the absolute URL the Callback handler is served at.
+	RedirectURL string
+	// Scopes are the scopes requested; they default to
+	// "openid", "profile" and "email".
+	Scopes []string
+	// RolesClaim names the ID token claim holding the roles
+	// of the user, e.g. "groups".
+	RolesClaim string
+	// Store keeps the signed-in identity.
+	Store SessionStore
+	// PostLogoutURL is where users end up after logging out;
+	// it defaults to "/".
+	PostLogoutURL string
+}
+
+// OIDC serves the OpenID Connect authorization code flow,
+// with PKCE, state and nonce checks:
+//
+//	o, err := auth.NewOIDC(ctx, auth.Config{
+//	    Issuer:      "https://accounts.example.com",
+//	    ClientID:    clientID,
+//	    RedirectURL: "https://app.example.com/callback",
+//	    Store:       &auth.CookieStore{Key: key},
+//	})
+//	mux.Handle("GET /login", base.ThenWithContext(ctx, o.Login()))
+//	mux.Handle("GET /callback", base.ThenWithContext(ctx, o.Callback()))
+//	mux.Handle("POST /logout", base.ThenWithContext(ctx, o.Logout()))
+//
+//	app := base.Append(auth.Authenticate(o.Store(), "/login"))
+type OIDC struct {
+	config     Config
+	oauth      oauth2.Config
+	verifier   *oidc.IDTokenVerifier
+	endSession string
+}
+
+// NewOIDC discovers the provider at config.Issuer and returns
+// the handlers of the login flow.
+func NewOIDC(ctx context.Context, config Config) (*OIDC, error) {
+	if config.Store == nil {
+		return nil, errors.New("auth: Config.Store is nil")
+	}
+	provider, err := oidc.NewProvider(ctx, config.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	var meta struct {
+		EndSession string `json:"end_session_endpoint"`
+	}
+	if err := provider.Claims(&meta); err != nil {
+		return nil, err
+	}
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+	if config.PostLogoutURL == "" {
+		config.PostLogoutURL = "/"
+	}
+	return &OIDC{
+		config: config,
+		oauth: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier:   provider.Verifier(&oidc.Config{ClientID: config.ClientID}),
+		endSession: meta.EndSession,
+	}, nil
+}
+
+// Store returns the session store of o.
+func (o *OIDC) Store() SessionStore {
+	return o.config.Store
+}
+
+// flow is the state of a login in progress.
+type flow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to"`
+}
+
+// Login returns a handler redirecting to the provider to sign in.
+// Once signed in, users are sent back to the local path given
+// in the return_to query parameter, or to "/".
+func (o *OIDC) Login() alice.ContextHandler {
+	return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		f := flow{
+			State:    randomString(),
+			Nonce:    randomString(),
+			Verifier: oauth2.GenerateVerifier(),
+			ReturnTo: localPath(r.URL.Query().Get("return_to")),
+		}
+		b, _ := json.Marshal(f)
+		http.SetCookie(w, &http.Cookie{
+			Name:     flowCookie,
+			Value:    base64.RawURLEncoding.EncodeToString(b),
+			Path:     "/",
+			MaxAge:   600,
+			Secure:   true,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		u := o.oauth.AuthCodeURL(f.State, oidc.Nonce(f.Nonce), oauth2.S256ChallengeOption(f.Verifier))
+		http.Redirect(w, r, u, http.StatusFound)
+	})
+}
+
+// Callback returns the handler the provider redirects back to.
+// It exchanges the authorization code, verifies the ID token,
+// saves the identity in the session store and redirects to the
+// page the login started from.
+func (o *OIDC) Callback() alice.ContextHandler {
+	return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		f, ok := readFlow(r)
+		http.SetCookie(w, &http.Cookie{Name: flowCookie, Path: "/", MaxAge: -1})
+		q := r.URL.Query()
+		if !ok || q.Get("state") != f.State {
+			alice.WriteError(ctx, w, &alice.HTTPError{
+				Status: http.StatusBadRequest,
+				Code:   "invalid_login",
+				Detail: "the login expired or was not started here",
+			})
+			return
+		}
+		if e := q.Get("error"); e != "" {
+			alice.WriteError(ctx, w, &alice.HTTPError{
+				Status: http.StatusUnauthorized,
+				Code:   "login_failed",
+				Detail: strings.TrimSpace(e + ": " + q.Get("error_description")),
+			})
+			return
+		}
+		id, err := o.exchange(ctx, q.Get("code"), f)
+		if err != nil {
+			alice.WriteError(ctx, w, &alice.HTTPError{
+				Status: http.StatusUnauthorized,
+				Code:   "login_failed",
+				Err:    err,
+			})
+			return
+		}
+		if err := o.config.Store.Save(w, r, id); err != nil {
+			alice.WriteError(ctx, w, err)
+			return
+		}
+		http.Redirect(w, r, f.ReturnTo, http.StatusFound)
+	})
+}
+
+func (o *OIDC) exchange(ctx context.Context, code string, f flow) (Identity, error) {
+	token, err := o.oauth.Exchange(ctx, code, oauth2.VerifierOption(f.Verifier))
+	if err != nil {
+		return Identity{}, err
+	}
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Identity{}, errors.New("auth: token response has no id_token")
+	}
+	idToken, err := o.verifier.Verify(ctx, raw)
+	if err != nil {
+		return Identity{}, err
+	}
+	if idToken.Nonce != f.Nonce {
+		return Identity{}, errors.New("auth: ID token nonce mismatch")
+	}
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, err
+	}
+	id := Identity{Subject: idToken.Subject, IDToken: raw}
+	id.Email, _ = claims["email"].(string)
+	id.Name, _ = claims["name"].(string)
+	if roles, ok := claims[o.config.RolesClaim].([]any); ok {
+		for _, role := range roles {
+			if s, ok := role.(string); ok {
+				id.Roles = append(id.Roles, s)
+			}
+		}
+	}
+	return id, nil
+}
+
+// Logout returns a handler ending the session and, if the provider
+// supports RP-initiated logout, its session at the provider too.
+// Users end up at Config.PostLogoutURL.
+// Serve it for POST only, so other sites cannot sign users out.
+func (o *OIDC) Logout() alice.ContextHandler {
+	return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		id, ok := o.config.Store.Load(r)
+		o.config.Store.Clear(w, r)
+		target := o.config.PostLogoutURL
+		if o.endSession != "" && ok {
+			q := url.Values{"client_id": {o.config.ClientID}}
+			if id.IDToken != "" {
+				q.Set("id_token_hint", id.IDToken)
+			}
+			if u, err := url.Parse(target); err == nil && u.IsAbs() {
+				q.Set("post_logout_redirect_uri", target)
+			}
+			target = o.endSession + "?" + q.Encode()
+		}
+		http.Redirect(w, r, target, http.StatusSeeOther)
+	})
+}
+
+func readFlow(r *http.Request) (flow, bool) {
+	var f flow
+	c, err := r.Cookie(flowCookie)
+	if err != nil {
+		return f, false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil || json.Unmarshal(b, &f) != nil || f.State == "" {
+		return f, false
+	}
+	f.ReturnTo = localPath(f.ReturnTo)
+	return f, true
+}
+
+// localPath returns p if it is a path on this site, "/" otherwise,
+// so logins cannot redirect elsewhere.
+func localPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, "/\\") {
+		return "/"
+	}
+	return p
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
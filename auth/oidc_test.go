@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// provider is a minimal OpenID provider issuing ID tokens for the code "good".
+type provider struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	nonce     string
+	challenge string
+}
+
+func newProvider(t *testing.T) *provider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &provider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                p.URL,
+			"authorization_endpoint":                p.URL + "/authorize",
+			"token_endpoint":                        p.URL + "/token",
+			"jwks_uri":                              p.URL + "/jwks",
+			"end_session_endpoint":                  p.URL + "/logout",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "good" || base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     p.idToken(t),
+		})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *provider) idToken(t *testing.T) string {
+	enc := func(v any) string {
+		b, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := enc(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"}) + "." + enc(map[string]any{
+		"iss":    p.URL,
+		"aud":    "client",
+		"sub":    "ann",
+		"email":  "ann@example.com",
+		"name":   "Ann",
+		"groups": []string{"admin"},
+		"nonce":  p.nonce,
+		"iat":    time.Now().Unix(),
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	})
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, sum[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func serve(h alice.ContextHandler, r *http.Request, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	alice.New().ThenWithContext(context.Background(), h).ServeHTTP(w, r)
+	return w
+}
+
+func cookie(w *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestOIDC(t *testing.T) {
+	p := newProvider(t)
+	o, err := NewOIDC(context.Background(), Config{
+		Issuer:        p.URL,
+		ClientID:      "client",
+		RedirectURL:   "https://app.example.com/callback",
+		RolesClaim:    "groups",
+		Store:         testStore,
+		PostLogoutURL: "https://app.example.com/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(o.Login(), httptest.NewRequest("GET", "/login?return_to=/pets", nil))
+	assert.Equal(t, w.Code, http.StatusFound)
+	u, _ := url.Parse(w.Header().Get("Location"))
+	q := u.Query()
+	assert.Equal(t, u.Path, "/authorize")
+	assert.Equal(t, q.Get("client_id"), "client")
+	assert.Equal(t, q.Get("code_challenge_method"), "S256")
+	p.nonce, p.challenge = q.Get("nonce"), q.Get("code_challenge")
+	login := cookie(w, flowCookie)
+
+	w = serve(o.Callback(), httptest.NewRequest("GET", "/callback?code=good&state="+q.Get("state"), nil), login)
+	assert.Equal(t, w.Code, http.StatusFound)
+	assert.Equal(t, w.Header().Get("Location"), "/pets")
+	session := cookie(w, "alice_session")
+
+	var id Identity
+	h := alice.New(Authenticate(o.Store(), "/login")).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		id, _ = IdentityFrom(ctx)
+	})
+	r := httptest.NewRequest("GET", "/pets", nil)
+	r.AddCookie(session)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, id.Subject, "ann")
+	assert.Equal(t, id.Email, "ann@example.com")
+	assert.Equal(t, id.Name, "Ann")
+	assert.Equal(t, id.Roles, []string{"admin"})
+
+	w = serve(o.Logout(), httptest.NewRequest("POST", "/logout", nil), session)
+	assert.Equal(t, w.Code, http.StatusSeeOther)
+	u, _ = url.Parse(w.Header().Get("Location"))
+	assert.Equal(t, u.Path, "/logout")
+	assert.Equal(t, u.Query().Get("id_token_hint"), id.IDToken)
+	assert.Equal(t, u.Query().Get("post_logout_redirect_uri"), "https://app.example.com/")
+	assert.Equal(t, cookie(w, "alice_session").MaxAge, -1)
+}
+
+func TestOIDCCallbackRejects(t *testing.T) {
+	p := newProvider(t)
+	o, err := NewOIDC(context.Background(), Config{Issuer: p.URL, ClientID: "client", Store: testStore})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := serve(o.Login(), httptest.NewRequest("GET", "/login?return_to=//evil.example.com", nil))
+	u, _ := url.Parse(w.Header().Get("Location"))
+	state := u.Query().Get("state")
+	p.nonce, p.challenge = u.Query().Get("nonce"), u.Query().Get("code_challenge")
+	login := cookie(w, flowCookie)
+
+	tests := []struct {
+		query  string
+		login  bool
+		status int
+	}{
+		{"code=good&state=" + state, false, http.StatusBadRequest},
+		{"code=good&state=forged", true, http.StatusBadRequest},
+		{"error=access_denied&state=" + state, true, http.StatusUnauthorized},
+		{"code=bad&state=" + state, true, http.StatusUnauthorized},
+		{"code=good&state=" + state, true, http.StatusFound},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/callback?"+tt.query, nil)
+		var cookies []*http.Cookie
+		if tt.login {
+			cookies = append(cookies, login)
+		}
+		w := serve(o.Callback(), r, cookies...)
+		assert.Equal(t, w.Code, tt.status, tt.query)
+		if w.Code == http.StatusFound {
+			assert.Equal(t, w.Header().Get("Location"), "/")
+		}
+	}
+
+	p.nonce = "replayed"
+	w = serve(o.Callback(), httptest.NewRequest("GET", "/callback?code=good&state="+state, nil), login)
+	assert.Equal(t, w.Code, http.StatusUnauthorized)
+}
+
+func TestNewOIDCRequiresStore(t *testing.T) {
+	_, err := NewOIDC(context.Background(), Config{Issuer: "https://accounts.example.com"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,110 @@
+package alice
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// Limits of the W3C baggage header.
+const (
+	maxBaggageMembers = 180
+	maxBaggageBytes   = 8192
+)
+
+type baggageKey struct{}
+
+// Baggage returns a Constructor parsing the W3C baggage header
+// of a request into the context. Entries are read with BaggageFrom
+// and BaggageValue, added with WithBaggage, and sent along with
+// outbound requests by TransportChain.Client (see InjectBaggage).
+// Member properties are dropped.
+func Baggage() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if b := parseBaggage(r.Header.Values("Baggage")); len(b) > 0 {
+				ctx = context.WithValue(ctx, baggageKey{}, b)
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+func parseBaggage(headers []string) map[string]string {
+	b := make(map[string]string)
+	for _, header := range headers {
+		for _, member := range strings.Split(header, ",") {
+			if len(b) == maxBaggageMembers {
+				return b
+			}
+			member, _, _ = strings.Cut(member, ";")
+			key, value, ok := strings.Cut(member, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				continue
+			}
+			value, err := url.PathUnescape(strings.TrimSpace(value))
+			if err != nil {
+				continue
+			}
+			b[key] = value
+		}
+	}
+	return b
+}
+
+// BaggageFrom returns a copy of the baggage entries in ctx.
+func BaggageFrom(ctx context.Context) map[string]string {
+	b, _ := ctx.Value(baggageKey{}).(map[string]string)
+	out := make(map[string]string, len(b))
+	for k, v := range b {
+		out[k] = v
+	}
+	return out
+}
+
+// BaggageValue returns the baggage entry key in ctx.
+func BaggageValue(ctx context.Context, key string) (string, bool) {
+	b, _ := ctx.Value(baggageKey{}).(map[string]string)
+	v, ok := b[key]
+	return v, ok
+}
+
+// WithBaggage returns a context whose baggage additionally
+// has the entry key set to value.
+func WithBaggage(ctx context.Context, key, value string) context.Context {
+	b := BaggageFrom(ctx)
+	b[key] = value
+	return context.WithValue(ctx, baggageKey{}, b)
+}
+
+// InjectBaggage is a Propagator setting the baggage header
+// from the entries in ctx. Entries that would exceed
+// the header size limit are left out.
+func InjectBaggage(ctx context.Context, h http.Header) {
+	b, _ := ctx.Value(baggageKey{}).(map[string]string)
+	if len(b) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		member := k + "=" + url.PathEscape(b[k])
+		if sb.Len()+len(member)+1 > maxBaggageBytes {
+			continue
+		}
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(member)
+	}
+	h.Set("Baggage", sb.String())
+}
@@ -0,0 +1,57 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestBaggageParsesHeader(t *testing.T) {
+	var b map[string]string
+	h := New(Baggage()).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		b = BaggageFrom(ctx)
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Add("Baggage", "userId=alice, serverNode=DF%2028;prop=1")
+	r.Header.Add("Baggage", "isProduction=false,invalid")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, b, map[string]string{
+		"userId":       "alice",
+		"serverNode":   "DF 28",
+		"isProduction": "false",
+	})
+}
+
+func TestWithBaggage(t *testing.T) {
+	ctx := WithBaggage(context.Background(), "tenant", "acme")
+	child := WithBaggage(ctx, "user", "alice smith")
+
+	_, ok := BaggageValue(ctx, "user")
+	assert.False(t, ok)
+	v, _ := BaggageValue(child, "user")
+	assert.Equal(t, v, "alice smith")
+
+	h := http.Header{}
+	InjectBaggage(child, h)
+	assert.Equal(t, h.Get("Baggage"), "tenant=acme,user=alice%20smith")
+}
+
+func TestClientInjectsBaggage(t *testing.T) {
+	var got string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Baggage")
+	}))
+	defer upstream.Close()
+
+	resp, err := Client(WithBaggage(context.Background(), "tenant", "acme")).Get(upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, got, "tenant=acme")
+}
@@ -0,0 +1,225 @@
+package alice
+
+import (
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ErrNoBackend is returned by Balancer.Pick when every backend is ejected.
+var ErrNoBackend = errors.New("alice: no healthy backend")
+
+// Backend is an upstream server of a Balancer.
+type Backend struct {
+	URL *url.URL
+
+	active atomic.Int64
+
+	mu           sync.Mutex
+	fails        int
+	ejectedUntil time.Time
+}
+
+// ID returns a stable identifier of the backend derived from its URL,
+// as stored in the cookie of Sticky.
+func (b *Backend) ID() string {
+	h := fnv.New64a()
+	h.Write([]byte(b.URL.String()))
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+// Active returns the number of requests the backend is serving.
+func (b *Backend) Active() int {
+	return int(b.active.Load())
+}
+
+// Healthy reports whether the backend is not ejected.
+func (b *Backend) Healthy() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !time.Now().Before(b.ejectedUntil)
+}
+
+// A Strategy picks the backend of a request among the healthy ones,
+// given in the order of the Balancer. It is never given none.
+type Strategy interface {
+	Pick(ctx context.Context, r *http.Request, backends []*Backend) *Backend
+}
+
+// StrategyFunc adapts a function to a Strategy.
+type StrategyFunc func(ctx context.Context, r *http.Request, backends []*Backend) *Backend
+
+// Pick calls f(ctx, r, backends).
+func (f StrategyFunc) Pick(ctx context.Context, r *http.Request, backends []*Backend) *Backend {
+	return f(ctx, r, backends)
+}
+
+// RoundRobin returns a Strategy picking the backends in turn.
+func RoundRobin() Strategy {
+	var next atomic.Uint64
+	return StrategyFunc(func(ctx context.Context, r *http.Request, backends []*Backend) *Backend {
+		return backends[(next.Add(1)-1)%uint64(len(backends))]
+	})
+}
+
+// LeastConnections returns a Strategy picking the backend serving
+// the fewest requests, the first one of them on ties.
+func LeastConnections() Strategy {
+	return StrategyFunc(func(ctx context.Context, r *http.Request, backends []*Backend) *Backend {
+		best := backends[0]
+		for _, b := range backends[1:] {
+			if b.Active() < best.Active() {
+				best = b
+			}
+		}
+		return best
+	})
+}
+
+// ConsistentHash returns a Strategy sending all requests with the same
+// key, a value from the context such as a user or tenant ID, to the same
+// backend. Ejecting a backend only moves the keys it had. Requests for
+// which key returns "" are spread round-robin.
+func ConsistentHash(key func(ctx context.Context) string) Strategy {
+	fallback := RoundRobin()
+	return StrategyFunc(func(ctx context.Context, r *http.Request, backends []*Backend) *Backend {
+		k := keyOf(ctx, key)
+		if k == "" {
+			return fallback.Pick(ctx, r, backends)
+		}
+		return rendezvous(k, backends)
+	})
+}
+
+// rendezvous returns the backend scoring highest for key,
+// so removing a backend only moves the keys it had.
+func rendezvous(key string, backends []*Backend) *Backend {
+	var best *Backend
+	var bestScore uint64
+	for _, b := range backends {
+		h := fnv.New64a()
+		h.Write([]byte(b.URL.String()))
+		h.Write([]byte{0})
+		h.Write([]byte(key))
+		if score := h.Sum64(); best == nil || score > bestScore {
+			best, bestScore = b, score
+		}
+	}
+	return best
+}
+
+// DefaultUnhealthy counts transport errors and 502, 503 and 504
+// responses as backend failures.
+func DefaultUnhealthy(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Balancer spreads the requests of a ReverseProxy over several backends
+// and ejects the ones failing, checking their health passively from the
+// requests they serve:
+//
+//	proxy := &alice.ReverseProxy{
+//	    Balancer: alice.NewBalancer(alice.LeastConnections(), api1, api2, api3),
+//	}
+type Balancer struct {
+	Backends []*Backend
+	// Strategy picks the backend of a request. It defaults to RoundRobin.
+	Strategy Strategy
+	// MaxFails consecutive failures eject a backend for EjectFor,
+	// after which it gets requests again. They default to 3 and 30s.
+	MaxFails int
+	EjectFor time.Duration
+	// Unhealthy decides whether an upstream response or error is
+	// a failure of the backend. It defaults to DefaultUnhealthy.
+	Unhealthy func(*http.Response, error) bool
+
+	once     sync.Once
+	strategy Strategy
+}
+
+// NewBalancer returns a Balancer over the backends at urls.
+func NewBalancer(strategy Strategy, urls ...*url.URL) *Balancer {
+	b := &Balancer{Strategy: strategy}
+	for _, u := range urls {
+		b.Backends = append(b.Backends, &Backend{URL: u})
+	}
+	return b
+}
+
+func (b *Balancer) init() {
+	b.strategy = b.Strategy
+	if b.strategy == nil {
+		b.strategy = RoundRobin()
+	}
+}
+
+// Pick returns the backend of a request, the one it is pinned to
+// by Sticky if healthy, or ErrNoBackend if every backend is ejected.
+func (b *Balancer) Pick(ctx context.Context, r *http.Request) (*Backend, error) {
+	b.once.Do(b.init)
+	healthy := make([]*Backend, 0, len(b.Backends))
+	for _, be := range b.Backends {
+		if be.Healthy() {
+			healthy = append(healthy, be)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil, ErrNoBackend
+	}
+	if opts, ok := stickyFrom(ctx); ok {
+		if be := opts.pinned(r, healthy); be != nil {
+			return be, nil
+		}
+	}
+	return b.strategy.Pick(ctx, r, healthy), nil
+}
+
+// start counts a request served by be, returning the function
+// reporting its outcome.
+func (b *Balancer) start(be *Backend) func(*http.Response, error) {
+	be.active.Add(1)
+	return func(resp *http.Response, err error) {
+		be.active.Add(-1)
+		if (resp == nil && err == nil) || errors.Is(err, context.Canceled) {
+			// No answer, or the client went away: the backend is not to blame.
+			return
+		}
+		unhealthy := b.Unhealthy
+		if unhealthy == nil {
+			unhealthy = DefaultUnhealthy
+		}
+		maxFails, ejectFor := b.MaxFails, b.EjectFor
+		if maxFails <= 0 {
+			maxFails = 3
+		}
+		if ejectFor <= 0 {
+			ejectFor = 30 * time.Second
+		}
+
+		be.mu.Lock()
+		defer be.mu.Unlock()
+		if !unhealthy(resp, err) {
+			be.fails = 0
+			return
+		}
+		be.fails++
+		if be.fails >= maxFails {
+			be.fails = 0
+			be.ejectedUntil = time.Now().Add(ejectFor)
+		}
+	}
+}
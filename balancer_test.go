@@ -0,0 +1,118 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type balanceKey struct{}
+
+func backends(names ...string) []*Backend {
+	var out []*Backend
+	for _, name := range names {
+		out = append(out, &Backend{URL: &url.URL{Scheme: "http", Host: name}})
+	}
+	return out
+}
+
+func picks(t *testing.T, b *Balancer, ctx context.Context, n int) []string {
+	var hosts []string
+	for i := 0; i < n; i++ {
+		be, err := b.Pick(ctx, httptest.NewRequest("GET", "/", nil))
+		if !assert.NoError(t, err) {
+			return hosts
+		}
+		hosts = append(hosts, be.URL.Host)
+	}
+	return hosts
+}
+
+func TestRoundRobin(t *testing.T) {
+	b := &Balancer{Backends: backends("a", "b", "c")}
+	assert.Equal(t, picks(t, b, context.Background(), 4), []string{"a", "b", "c", "a"})
+}
+
+func TestLeastConnections(t *testing.T) {
+	b := &Balancer{Backends: backends("a", "b", "c"), Strategy: LeastConnections()}
+	b.Backends[0].active.Add(2)
+	b.Backends[1].active.Add(1)
+	b.Backends[2].active.Add(3)
+	assert.Equal(t, picks(t, b, context.Background(), 1), []string{"b"})
+}
+
+func TestConsistentHash(t *testing.T) {
+	b := &Balancer{Backends: backends("a", "b", "c", "d"), Strategy: ConsistentHash(func(ctx context.Context) string {
+		user, _ := ctx.Value(balanceKey{}).(string)
+		return user
+	})}
+	owners := make(map[string]string)
+	for _, user := range []string{"ann", "bob", "cyd", "dan", "eve", "fay"} {
+		ctx := context.WithValue(context.Background(), balanceKey{}, user)
+		hosts := picks(t, b, ctx, 3)
+		assert.Equal(t, hosts, []string{hosts[0], hosts[0], hosts[0]})
+		owners[user] = hosts[0]
+	}
+
+	// Ejecting a backend only moves its own keys.
+	b.Backends[1].ejectedUntil = time.Now().Add(time.Hour)
+	for user, owner := range owners {
+		ctx := context.WithValue(context.Background(), balanceKey{}, user)
+		host := picks(t, b, ctx, 1)[0]
+		if owner != "b" {
+			assert.Equal(t, host, owner)
+		} else {
+			assert.NotEqual(t, host, "b")
+		}
+	}
+}
+
+func TestBalancerEjectsFailingBackends(t *testing.T) {
+	b := &Balancer{Backends: backends("a", "b"), MaxFails: 2, EjectFor: time.Hour}
+	fail := &http.Response{StatusCode: http.StatusBadGateway}
+	served := &http.Response{StatusCode: http.StatusInternalServerError}
+
+	b.start(b.Backends[0])(fail, nil)
+	b.start(b.Backends[0])(served, nil)
+	b.start(b.Backends[0])(fail, nil)
+	b.start(b.Backends[0])(nil, context.Canceled)
+	assert.True(t, b.Backends[0].Healthy())
+	b.start(b.Backends[0])(nil, context.DeadlineExceeded)
+	assert.False(t, b.Backends[0].Healthy())
+	assert.Equal(t, b.Backends[0].Active(), 0)
+	assert.Equal(t, picks(t, b, context.Background(), 2), []string{"b", "b"})
+
+	b.Backends[1].ejectedUntil = time.Now().Add(time.Hour)
+	_, err := b.Pick(context.Background(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, err, ErrNoBackend)
+}
+
+func TestReverseProxyBalancer(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("healthy"))
+	}))
+	defer healthy.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	u1, _ := url.Parse(failing.URL)
+	u2, _ := url.Parse(healthy.URL)
+	balancer := NewBalancer(nil, u1, u2)
+	balancer.MaxFails = 1
+	proxy := &ReverseProxy{Balancer: balancer}
+
+	var bodies []string
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		proxy.ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/", nil))
+		bodies = append(bodies, w.Body.String())
+	}
+	assert.Equal(t, bodies, []string{"", "healthy", "healthy"})
+	assert.False(t, balancer.Backends[0].Healthy())
+}
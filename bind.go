@@ -0,0 +1,246 @@
+package alice
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// MaxBindBodySize is the largest request body Bind decodes.
+const MaxBindBodySize = 1 << 20
+
+// A BindValidator is a bound value checking itself after decoding.
+type BindValidator interface {
+	Validate(ctx context.Context) error
+}
+
+// Bind decodes the request body into a T according to its Content-Type:
+// JSON (application/json, */*+json), XML (application/xml, text/xml,
+// */*+xml), forms (application/x-www-form-urlencoded, multipart/form-data)
+// or any format registered with RegisterCodec.
+// Form fields are matched by the form tag of struct fields, falling back
+// to their json tag and name, and may be strings, numbers, booleans
+// or slices of those.
+//
+// The result is then checked with ValidateStruct and, if T or *T
+// implements BindValidator, its Validate method. Failures are returned
+// as *HTTPError, so an ErrorHandlerFunc can return them as is:
+//
+//	func createUser(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+//	    user, err := alice.Bind[CreateUser](ctx, r)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    ...
+//	}
+func Bind[T any](ctx context.Context, r *http.Request) (T, error) {
+	var v T
+	if err := bindInto(r, &v); err != nil {
+		return v, err
+	}
+	return v, validateBound(ctx, &v)
+}
+
+// BindRequest works like Bind, gRPC-gateway style: the body is optional,
+// and the path wildcards of the http.ServeMux route and the query
+// parameters, in that order of precedence, fill the fields they name
+// like form fields, overriding the body:
+//
+//	type GetUser struct {
+//	    ID     int64    `json:"id" validate:"required"`
+//	    Fields []string `json:"fields"`
+//	}
+//
+//	// GET /users/{id}?fields=name&fields=email
+//	req, err := alice.BindRequest[GetUser](ctx, r)
+//
+// Only the fields of structs are filled; other types come from the body.
+func BindRequest[T any](ctx context.Context, r *http.Request) (T, error) {
+	var v T
+	if r.ContentLength != 0 {
+		if err := bindInto(r, &v); err != nil {
+			return v, err
+		}
+	}
+	if reflect.TypeOf(&v).Elem().Kind() != reflect.Struct {
+		return v, validateBound(ctx, &v)
+	}
+	query := r.URL.Query()
+	err := decodeFields(&v, func(name string) ([]string, bool) {
+		if pv := r.PathValue(name); pv != "" {
+			return []string{pv}, true
+		}
+		vs, ok := query[name]
+		return vs, ok
+	})
+	if err != nil {
+		return v, &HTTPError{Status: http.StatusBadRequest, Code: "invalid_parameter", Detail: err.Error(), Err: err}
+	}
+	return v, validateBound(ctx, &v)
+}
+
+// validateBound checks a bound value with ValidateStruct
+// and its BindValidator.
+func validateBound(ctx context.Context, v any) error {
+	if fields := ValidateStruct(v); fields != nil {
+		return &HTTPError{
+			Status: http.StatusBadRequest,
+			Code:   "invalid_body",
+			Detail: "the request body is invalid",
+			Fields: fields,
+		}
+	}
+	if bv, ok := v.(BindValidator); ok {
+		if err := bv.Validate(ctx); err != nil {
+			var herr *HTTPError
+			if errors.As(err, &herr) {
+				return err
+			}
+			return &HTTPError{Status: http.StatusBadRequest, Code: "invalid_body", Detail: err.Error(), Err: err}
+		}
+	}
+	return nil
+}
+
+func bindInto(r *http.Request, v any) error {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return &HTTPError{Status: http.StatusUnsupportedMediaType, Detail: "missing or invalid Content-Type"}
+	}
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(nil, r.Body, MaxBindBodySize)
+	}
+
+	switch {
+	case mt == "application/json" || strings.HasSuffix(mt, "+json"):
+		err = json.NewDecoder(r.Body).Decode(v)
+	case mt == "application/xml" || mt == "text/xml" || strings.HasSuffix(mt, "+xml"):
+		err = xml.NewDecoder(r.Body).Decode(v)
+	case mt == "application/x-www-form-urlencoded":
+		if err = r.ParseForm(); err == nil {
+			err = decodeForm(r.PostForm, v)
+		}
+	case mt == "multipart/form-data":
+		if err = r.ParseMultipartForm(MaxBindBodySize); err == nil {
+			err = decodeForm(r.MultipartForm.Value, v)
+		}
+	default:
+		c, ok := CodecFor(mt)
+		if !ok {
+			return &HTTPError{Status: http.StatusUnsupportedMediaType, Detail: "unsupported Content-Type " + mt}
+		}
+		var body []byte
+		if body, err = io.ReadAll(r.Body); err == nil {
+			err = c.Unmarshal(body, v)
+		}
+	}
+
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return &HTTPError{Status: http.StatusRequestEntityTooLarge, Err: err}
+	case err == io.EOF:
+		return &HTTPError{Status: http.StatusBadRequest, Code: "malformed_body", Detail: "the request body is empty", Err: err}
+	case err != nil:
+		return &HTTPError{Status: http.StatusBadRequest, Code: "malformed_body", Detail: err.Error(), Err: err}
+	}
+	return nil
+}
+
+// decodeForm sets the fields of the struct v points to from values.
+func decodeForm(values url.Values, v any) error {
+	return decodeFields(v, func(name string) ([]string, bool) {
+		vs, ok := values[name]
+		return vs, ok
+	})
+}
+
+// decodeFields sets the fields of the struct v points to
+// from the values lookup returns for their names.
+func decodeFields(v any, lookup func(name string) ([]string, bool)) error {
+	rv := reflect.ValueOf(v).Elem()
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot bind a form to %s", rv.Type())
+	}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("form"), ",")
+		if name == "" {
+			name = jsonName(sf)
+		}
+		if name == "-" {
+			continue
+		}
+		vs, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+			s := reflect.MakeSlice(fv.Type(), len(vs), len(vs))
+			for j, str := range vs {
+				if err := setFormValue(s.Index(j), str); err != nil {
+					return fmt.Errorf("%s: %v", name, err)
+				}
+			}
+			fv.Set(s)
+			continue
+		}
+		if err := setFormValue(fv, vs[0]); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func setFormValue(fv reflect.Value, s string) error {
+	if fv.Kind() == reflect.Pointer {
+		fv.Set(reflect.New(fv.Type().Elem()))
+		fv = fv.Elem()
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", fv.Type())
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package alice
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type signup struct {
+	Name  string   `json:"name" xml:"name" form:"name" validate:"required"`
+	Age   int      `json:"age" xml:"age"`
+	Tags  []string `json:"tags" xml:"tag" form:"tag"`
+	Terms bool     `json:"terms" xml:"terms"`
+}
+
+func (s *signup) Validate(ctx context.Context) error {
+	if !s.Terms {
+		return errors.New("the terms must be accepted")
+	}
+	return nil
+}
+
+func bindRequest(contentType, body string) (signup, error) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	return Bind[signup](context.Background(), r)
+}
+
+func TestBindDecodesByContentType(t *testing.T) {
+	want := signup{Name: "alice", Age: 30, Tags: []string{"a", "b"}, Terms: true}
+
+	s, err := bindRequest("application/json", `{"name":"alice","age":30,"tags":["a","b"],"terms":true}`)
+	assert.Nil(t, err)
+	assert.Equal(t, s, want)
+
+	s, err = bindRequest("application/xml; charset=utf-8", `<signup><name>alice</name><age>30</age><tag>a</tag><tag>b</tag><terms>true</terms></signup>`)
+	assert.Nil(t, err)
+	assert.Equal(t, s, want)
+
+	s, err = bindRequest("application/x-www-form-urlencoded", "name=alice&age=30&tag=a&tag=b&terms=true")
+	assert.Nil(t, err)
+	assert.Equal(t, s, want)
+}
+
+func TestBindErrors(t *testing.T) {
+	cases := []struct {
+		contentType, body string
+		status            int
+	}{
+		{"application/json", `{"name":`, http.StatusBadRequest},
+		{"application/json", `{"age":30,"terms":true}`, http.StatusBadRequest},
+		{"application/json", `{"name":"alice"}`, http.StatusBadRequest},
+		{"application/x-www-form-urlencoded", "name=alice&age=old", http.StatusBadRequest},
+		{"text/csv", "alice,30", http.StatusUnsupportedMediaType},
+		{"", "{}", http.StatusUnsupportedMediaType},
+		{"application/json", `{"name":"` + strings.Repeat("a", MaxBindBodySize) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, c := range cases {
+		_, err := bindRequest(c.contentType, c.body)
+		var herr *HTTPError
+		assert.True(t, errors.As(err, &herr))
+		assert.Equal(t, herr.Status, c.status, c.contentType+" "+c.body[:min(len(c.body), 20)])
+	}
+}
+
+func TestBindWithErrorHandlerFunc(t *testing.T) {
+	h := New().ThenWithContext(context.Background(), ErrorHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		_, err := Bind[signup](ctx, r)
+		return err
+	}))
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"terms":true}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+	assert.Contains(t, w.Body.String(), `"name":"is required"`)
+}
+
+func TestBindRequestMergesPathAndQuery(t *testing.T) {
+	mux := http.NewServeMux()
+	var got signup
+	var gotErr error
+	mux.HandleFunc("/signups/{name}", func(w http.ResponseWriter, r *http.Request) {
+		got, gotErr = BindRequest[signup](context.Background(), r)
+	})
+
+	r := httptest.NewRequest("PUT", "/signups/alice?age=30&tag=a&tag=b&name=bob", strings.NewReader(`{"age":20,"terms":true}`))
+	r.Header.Set("Content-Type", "application/json")
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Nil(t, gotErr)
+	assert.Equal(t, got, signup{Name: "alice", Age: 30, Tags: []string{"a", "b"}, Terms: true})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/signups/alice?terms=true", nil))
+	assert.Nil(t, gotErr)
+	assert.Equal(t, got, signup{Name: "alice", Terms: true})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/signups/alice?age=old", nil))
+	var herr *HTTPError
+	if assert.True(t, errors.As(gotErr, &herr)) {
+		assert.Equal(t, herr.Status, http.StatusBadRequest)
+		assert.Equal(t, herr.Code, "invalid_parameter")
+	}
+}
+
+func TestBindRequestNonStruct(t *testing.T) {
+	r := httptest.NewRequest("POST", "/tags?sort=name", strings.NewReader(`["a","b"]`))
+	r.Header.Set("Content-Type", "application/json")
+	tags, err := BindRequest[[]string](context.Background(), r)
+	assert.Nil(t, err)
+	assert.Equal(t, tags, []string{"a", "b"})
+
+	tags, err = BindRequest[[]string](context.Background(), httptest.NewRequest("GET", "/tags?sort=name", nil))
+	assert.Nil(t, err)
+	assert.Nil(t, tags)
+}
@@ -0,0 +1,174 @@
+package alice
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// BotClass classifies the client of a request.
+type BotClass int
+
+const (
+	// NotBot is a client that looks like a browser.
+	NotBot BotClass = iota
+	// GoodBot is a welcome crawler, such as a search engine.
+	// User agents are easily forged, so it is no proof of identity.
+	GoodBot
+	// UnknownBot is an automated client, such as curl or a script.
+	UnknownBot
+	// BadBot is a vulnerability scanner or abusive crawler.
+	BadBot
+)
+
+var botClassNames = [...]string{"human", "good", "unknown", "bad"}
+
+func (c BotClass) String() string {
+	if c < 0 || int(c) >= len(botClassNames) {
+		return "BotClass(" + strconv.Itoa(int(c)) + ")"
+	}
+	return botClassNames[c]
+}
+
+// BotInfo is the classification of a request by BotDetect.
+type BotInfo struct {
+	Class BotClass
+	// Name is the matched user agent token, e.g. "googlebot".
+	Name string
+	// Reasons lists the heuristics that flagged the request,
+	// e.g. "no Accept-Language".
+	Reasons []string
+}
+
+// User agent tokens, matched case-insensitively.
+var (
+	goodBots = []string{"googlebot", "bingbot", "duckduckbot", "applebot", "yandexbot", "baiduspider", "slurp"}
+	badBots  = []string{"sqlmap", "nikto", "nmap", "masscan", "zgrab", "nuclei", "wpscan", "mj12bot", "dotbot", "petalbot", "bytespider"}
+	anyBots  = []string{"bot", "crawler", "spider", "curl", "wget", "python-requests", "go-http-client", "java/", "okhttp", "headless"}
+)
+
+// BotOptions configure BotDetect.
+type BotOptions struct {
+	// Good and Bad list more user agent tokens of welcome and
+	// unwelcome crawlers, matched case-insensitively.
+	Good, Bad []string
+	// Block makes bad bots get 403 Forbidden.
+	Block bool
+	// Throttle, if positive, is the minimum interval between requests
+	// of a bad bot (see RateLimitKey); faster ones get 429 Too Many Requests.
+	Throttle time.Duration
+}
+
+type botKey struct{}
+
+// BotDetect returns a Constructor classifying every request by its user
+// agent and by heuristics on its headers, and storing the result in the
+// context (see BotFrom). Browser user agents sending none of the headers
+// browsers always send are taken for unknown bots.
+//
+//	alice.New(alice.RealIP(nil), alice.BotDetect(alice.BotOptions{Throttle: time.Second}))
+func BotDetect(opts BotOptions) Constructor {
+	good := append(lower(opts.Good), goodBots...)
+	bad := append(lower(opts.Bad), badBots...)
+	t := &botThrottle{last: make(map[string]time.Time)}
+
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			info := classifyBot(r, good, bad)
+			ctx = context.WithValue(ctx, botKey{}, info)
+			if info.Class == BadBot {
+				if opts.Block {
+					WriteError(ctx, w, &HTTPError{Status: http.StatusForbidden, Code: "bot_blocked"})
+					return
+				}
+				if opts.Throttle > 0 && !t.allow(RateLimitKey(ctx, r), opts.Throttle) {
+					w.Header().Set("Retry-After", strconv.Itoa(int(max(opts.Throttle/time.Second, 1))))
+					WriteError(ctx, w, &HTTPError{Status: http.StatusTooManyRequests, Code: "bot_throttled"})
+					return
+				}
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+func lower(list []string) []string {
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = strings.ToLower(s)
+	}
+	return out
+}
+
+func classifyBot(r *http.Request, good, bad []string) BotInfo {
+	ua := strings.ToLower(r.UserAgent())
+	if ua == "" {
+		return BotInfo{Class: UnknownBot, Reasons: []string{"no User-Agent"}}
+	}
+	if name, ok := matchToken(ua, bad); ok {
+		return BotInfo{Class: BadBot, Name: name}
+	}
+	if name, ok := matchToken(ua, good); ok {
+		return BotInfo{Class: GoodBot, Name: name}
+	}
+	if name, ok := matchToken(ua, anyBots); ok {
+		return BotInfo{Class: UnknownBot, Name: name}
+	}
+	var info BotInfo
+	if strings.HasPrefix(ua, "mozilla/") {
+		for _, h := range []string{"Accept", "Accept-Language", "Accept-Encoding"} {
+			if r.Header.Get(h) == "" {
+				info.Reasons = append(info.Reasons, "no "+h)
+			}
+		}
+	}
+	if len(info.Reasons) > 1 {
+		info.Class = UnknownBot
+	}
+	return info
+}
+
+func matchToken(ua string, tokens []string) (string, bool) {
+	for _, t := range tokens {
+		if strings.Contains(ua, t) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// BotFrom returns the classification made by BotDetect.
+func BotFrom(ctx context.Context) (BotInfo, bool) {
+	info, ok := ctx.Value(botKey{}).(BotInfo)
+	return info, ok
+}
+
+// botThrottle remembers when clients were last let through.
+type botThrottle struct {
+	mu    sync.Mutex
+	last  map[string]time.Time
+	swept time.Time
+}
+
+func (t *botThrottle) allow(key string, interval time.Duration) bool {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.swept) > interval {
+		for k, last := range t.last {
+			if now.Sub(last) > interval {
+				delete(t.last, k)
+			}
+		}
+		t.swept = now
+	}
+	if last, ok := t.last[key]; ok && now.Sub(last) < interval {
+		return false
+	}
+	t.last[key] = now
+	return true
+}
@@ -0,0 +1,81 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestBotDetect(t *testing.T) {
+	browser := http.Header{
+		"User-Agent":      {"Mozilla/5.0 (X11; Linux x86_64) Firefox/131.0"},
+		"Accept":          {"text/html"},
+		"Accept-Language": {"en"},
+		"Accept-Encoding": {"gzip"},
+	}
+	tests := []struct {
+		header http.Header
+		class  BotClass
+		name   string
+	}{
+		{browser, NotBot, ""},
+		{http.Header{"User-Agent": {"Mozilla/5.0 (compatible; Googlebot/2.1)"}}, GoodBot, "googlebot"},
+		{http.Header{"User-Agent": {"sqlmap/1.8"}}, BadBot, "sqlmap"},
+		{http.Header{"User-Agent": {"curl/8.5.0"}}, UnknownBot, "curl"},
+		{http.Header{"User-Agent": {"ScrapeCo/1.0"}}, BadBot, "scrapeco"},
+		{http.Header{"User-Agent": {"Mozilla/5.0 (X11; Linux x86_64) Firefox/131.0"}}, UnknownBot, ""},
+		{http.Header{}, UnknownBot, ""},
+	}
+	var info BotInfo
+	h := New(BotDetect(BotOptions{Bad: []string{"ScrapeCo"}})).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		info, _ = BotFrom(ctx)
+	})
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header = tt.header
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		assert.Equal(t, w.Code, http.StatusOK)
+		assert.Equal(t, info.Class, tt.class, tt.header.Get("User-Agent"))
+		assert.Equal(t, info.Name, tt.name)
+	}
+	assert.Equal(t, info.Reasons, []string{"no User-Agent"})
+}
+
+func TestBotDetectBlock(t *testing.T) {
+	h := New(BotDetect(BotOptions{Block: true})).ThenWithContext(context.Background(), testApp)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", "Nikto/2.5")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Code, http.StatusForbidden)
+}
+
+func TestBotDetectThrottle(t *testing.T) {
+	h := New(BotDetect(BotOptions{Throttle: time.Hour})).ThenWithContext(context.Background(), testApp)
+	serve := func(addr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = addr
+		r.Header.Set("User-Agent", "MJ12bot/v1.4.8")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, serve("192.0.2.1:1000").Code, http.StatusOK)
+	w := serve("192.0.2.1:1001")
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+	assert.Equal(t, w.Header().Get("Retry-After"), "3600")
+	assert.Equal(t, serve("192.0.2.2:1000").Code, http.StatusOK)
+}
+
+func TestBotClassString(t *testing.T) {
+	assert.Equal(t, BadBot.String(), "bad")
+	assert.Equal(t, BotClass(9).String(), "BotClass(9)")
+}
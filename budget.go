@@ -0,0 +1,92 @@
+package alice
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// BudgetHeader carries the time left to serve a request,
+// in milliseconds.
+const BudgetHeader = "X-Request-Timeout"
+
+// Budget returns a Constructor applying the deadline a caller sent
+// along to the context, so work is abandoned once the caller has given
+// up. The time left is read from the X-Request-Timeout header, in
+// milliseconds or as a Go duration such as "1.5s", or from the gRPC
+// style Grpc-Timeout header, e.g. "250m". An earlier deadline of the
+// context is kept. Malformed headers, and budgets that are not positive
+// or too large for a time.Duration, are ignored.
+//
+// Client and TransportChain.Client send the remaining budget on with
+// outbound requests (see InjectBudget), for end-to-end deadlines.
+func Budget() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			d, ok := parseBudget(r.Header)
+			if !ok {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+// InjectBudget is a Propagator sending the time left until the context
+// deadline in the X-Request-Timeout header.
+func InjectBudget(ctx context.Context, h http.Header) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	left := max(time.Until(deadline).Milliseconds(), 0)
+	h.Set(BudgetHeader, strconv.FormatInt(left, 10))
+}
+
+// parseBudget reads the time left from the request headers.
+func parseBudget(h http.Header) (time.Duration, bool) {
+	if v := h.Get(BudgetHeader); v != "" {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return budget(ms, time.Millisecond)
+		}
+		if d, err := time.ParseDuration(v); err == nil {
+			return d, d > 0
+		}
+	}
+	if v := h.Get("Grpc-Timeout"); len(v) >= 2 {
+		n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		unit, ok := grpcTimeoutUnits[v[len(v)-1]]
+		if !ok {
+			return 0, false
+		}
+		return budget(n, unit)
+	}
+	return 0, false
+}
+
+// budget returns n units, unless n is not positive or the duration
+// would overflow.
+func budget(n int64, unit time.Duration) (time.Duration, bool) {
+	if n <= 0 || n > math.MaxInt64/int64(unit) {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
@@ -0,0 +1,90 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestBudgetAppliesDeadline(t *testing.T) {
+	for _, c := range []struct{ header, value string }{
+		{BudgetHeader, "500"},
+		{BudgetHeader, "0.5s"},
+		{"Grpc-Timeout", "500m"},
+	} {
+		var left time.Duration
+		h := New(Budget()).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			left = time.Until(deadline)
+		})
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(c.header, c.value)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		assert.True(t, left > 400*time.Millisecond && left <= 500*time.Millisecond, c.value)
+	}
+}
+
+func TestBudgetKeepsEarlierDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var left time.Duration
+	h := New(Budget()).ThenFuncWithContext(ctx, func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		deadline, _ := ctx.Deadline()
+		left = time.Until(deadline)
+	})
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(BudgetHeader, "60000")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.True(t, left <= 100*time.Millisecond)
+}
+
+func TestBudgetIgnoresUnusableValues(t *testing.T) {
+	for _, c := range []struct{ header, value string }{
+		{BudgetHeader, "0"},
+		{BudgetHeader, "-5"},
+		{BudgetHeader, "9223372036854775807"},
+		{BudgetHeader, "-1s"},
+		{"Grpc-Timeout", "0S"},
+		{"Grpc-Timeout", "99999999999H"},
+	} {
+		var hasDeadline bool
+		h := New(Budget()).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = ctx.Deadline()
+		})
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(c.header, c.value)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, w.Code, http.StatusOK, c.value)
+		assert.False(t, hasDeadline, c.value)
+	}
+}
+
+func TestBudgetPropagates(t *testing.T) {
+	var got string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(BudgetHeader)
+	}))
+	defer upstream.Close()
+
+	h := New(Budget()).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		resp, err := Client(ctx).Get(upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	})
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(BudgetHeader, "2000")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	ms, err := strconv.Atoi(got)
+	assert.Nil(t, err)
+	assert.True(t, ms > 1000 && ms <= 2000, got)
+}
@@ -0,0 +1,75 @@
+package alice
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// Builder accumulates constructors for a Chain, for setup code adding
+// middleware conditionally. The zero value is ready to use.
+//
+//	b := alice.NewBuilder(alice.RequestID(), alice.Logging(nil))
+//	b.UseIf(cfg.Dev, alice.Chaos(chaosOpts)).
+//	    UseIf(!cfg.Dev, alice.RequireHTTPS(httpsOpts)).
+//	    UseWhen(isAPI, alice.RequireContentType("application/json"))
+//	chain := b.Build()
+//
+// Like New and Append, its methods panic if a constructor is nil.
+type Builder struct {
+	constructors []Constructor
+}
+
+// NewBuilder returns a Builder starting with constructors.
+func NewBuilder(constructors ...Constructor) *Builder {
+	return new(Builder).Use(constructors...)
+}
+
+// Use adds constructors to the end of the chain being built.
+func (b *Builder) Use(constructors ...Constructor) *Builder {
+	requireConstructors("Use", constructors)
+	b.constructors = append(b.constructors, constructors...)
+	return b
+}
+
+// UseIf adds constructors if cond is true, such as for
+// development-only middleware.
+func (b *Builder) UseIf(cond bool, constructors ...Constructor) *Builder {
+	requireConstructors("UseIf", constructors)
+	if cond {
+		b.constructors = append(b.constructors, constructors...)
+	}
+	return b
+}
+
+// UseWhen adds constructors that only handle the requests pred
+// returns true for; other requests bypass them.
+func (b *Builder) UseWhen(pred func(ctx context.Context, r *http.Request) bool, constructors ...Constructor) *Builder {
+	requireConstructors("UseWhen", constructors)
+	for _, cons := range constructors {
+		b.constructors = append(b.constructors, when(pred, cons))
+	}
+	return b
+}
+
+// Build returns a Chain of the constructors added so far.
+// The Builder can be used further without affecting it.
+func (b *Builder) Build() Chain {
+	return New(b.constructors...)
+}
+
+// when returns a Constructor passing the requests pred returns true for
+// through cons, and the others straight to the next handler.
+// It is named after cons.
+func when(pred func(context.Context, *http.Request) bool, cons Constructor) Constructor {
+	return nameConstructor(func(next ContextHandler) ContextHandler {
+		h := cons(next)
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if pred(ctx, r) {
+				h.ServeHTTPContext(ctx, w, r)
+			} else {
+				next.ServeHTTPContext(ctx, w, r)
+			}
+		})
+	}, constructorName(cons))
+}
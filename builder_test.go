@@ -0,0 +1,46 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestBuilder(t *testing.T) {
+	isAPI := func(ctx context.Context, r *http.Request) bool {
+		return strings.HasPrefix(r.URL.Path, "/api")
+	}
+	b := NewBuilder(tagMiddleware("t1\n")).
+		UseIf(true, tagMiddleware("dev\n")).
+		UseIf(false, tagMiddleware("prod\n")).
+		UseWhen(isAPI, tagMiddleware("api\n")).
+		Use(tagMiddleware("t2\n"))
+	h := b.Build().ThenWithContext(context.Background(), testApp)
+
+	for path, want := range map[string]string{
+		"/":      "t1\ndev\nt2\napp\n",
+		"/api/x": "t1\ndev\napi\nt2\napp\n",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, w.Body.String(), want)
+	}
+}
+
+func TestBuilderBuildIsImmutable(t *testing.T) {
+	var b Builder
+	chain := b.Use(tagMiddleware("t1\n")).Build()
+	b.Use(tagMiddleware("t2\n"))
+	assert.Equal(t, len(chain.constructors), 1)
+	assert.Equal(t, len(b.Build().constructors), 2)
+}
+
+func TestBuilderRejectsNilConstructors(t *testing.T) {
+	assert.PanicsWithValue(t, "alice: UseIf: constructor 0 is nil", func() {
+		new(Builder).UseIf(false, nil)
+	})
+}
@@ -0,0 +1,76 @@
+package alice
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// A CachePreset is a Cache-Control header value.
+type CachePreset string
+
+// Common cache presets.
+const (
+	// NoStore forbids caching, for sensitive or per-user responses.
+	NoStore CachePreset = "no-store"
+	// NoCache allows caching but requires revalidation on every use.
+	NoCache CachePreset = "no-cache"
+	// Immutable caches for a year without revalidation,
+	// for fingerprinted static assets.
+	Immutable CachePreset = "public, max-age=31536000, immutable"
+)
+
+// PublicMaxAge lets any cache keep responses for d.
+func PublicMaxAge(d time.Duration) CachePreset {
+	return CachePreset("public, max-age=" + strconv.Itoa(int(d/time.Second)))
+}
+
+// PrivateMaxAge lets only the browser keep responses for d.
+func PrivateMaxAge(d time.Duration) CachePreset {
+	return CachePreset("private, max-age=" + strconv.Itoa(int(d/time.Second)))
+}
+
+// A CacheOverride applies a different preset to some paths, see OnPath.
+type CacheOverride struct {
+	path   string
+	preset CachePreset
+}
+
+// OnPath overrides the preset for path, or for all paths under it
+// if it ends in a slash.
+func OnPath(path string, preset CachePreset) CacheOverride {
+	return CacheOverride{path, preset}
+}
+
+// CacheControl returns a Constructor setting the Cache-Control header
+// to preset, or to the preset of the most specific matching override:
+//
+//	alice.CacheControl(alice.NoStore,
+//	    alice.OnPath("/static/", alice.Immutable),
+//	    alice.OnPath("/feed.xml", alice.PublicMaxAge(5*time.Minute)),
+//	)
+//
+// The header is set before the handler runs, so handlers can still
+// replace it.
+func CacheControl(preset CachePreset, overrides ...CacheOverride) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			value, matched := preset, ""
+			for _, o := range overrides {
+				if len(o.path) <= len(matched) {
+					continue
+				}
+				if r.URL.Path == o.path || strings.HasSuffix(o.path, "/") && strings.HasPrefix(r.URL.Path, o.path) {
+					value, matched = o.preset, o.path
+				}
+			}
+			if value != "" {
+				w.Header().Set("Cache-Control", string(value))
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
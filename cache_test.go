@@ -0,0 +1,37 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestCacheControl(t *testing.T) {
+	h := New(CacheControl(NoStore,
+		OnPath("/static/", Immutable),
+		OnPath("/static/live/", NoCache),
+		OnPath("/feed.xml", PublicMaxAge(5*time.Minute)),
+	)).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/me" {
+			w.Header().Set("Cache-Control", string(PrivateMaxAge(time.Minute)))
+		}
+	}))
+
+	cases := map[string]string{
+		"/":                   "no-store",
+		"/static/app.js":      "public, max-age=31536000, immutable",
+		"/static/live/status": "no-cache",
+		"/feed.xml":           "public, max-age=300",
+		"/feed.xml/x":         "no-store",
+		"/me":                 "private, max-age=60",
+	}
+	for path, want := range cases {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, w.Header().Get("Cache-Control"), want, path)
+	}
+}
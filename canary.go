@@ -0,0 +1,88 @@
+package alice
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultCanaryCookie is the cookie Canary uses for sticky assignment.
+const DefaultCanaryCookie = "alice_canary"
+
+// CanaryOptions configure sticky canary assignment.
+type CanaryOptions struct {
+	// Key, if set, returns a value from the context, such as a user
+	// or tenant ID, whose hash decides the assignment.
+	// Requests for which it returns "" fall back to the cookie.
+	Key func(ctx context.Context) string
+	// Cookie names the cookie remembering the assignment of clients.
+	// It defaults to DefaultCanaryCookie.
+	Cookie string
+}
+
+type canaryKey struct{}
+
+// Canary returns a Constructor sending percent (0-100) of the traffic
+// to canary instead of the rest of the chain. Clients keep their
+// assignment through a cookie.
+func Canary(percent float64, canary ContextHandler) Constructor {
+	return CanaryWith(percent, canary, CanaryOptions{})
+}
+
+// CanaryWith works like Canary, with configurable stickiness.
+func CanaryWith(percent float64, canary ContextHandler, opts CanaryOptions) Constructor {
+	cookie := opts.Cookie
+	if cookie == "" {
+		cookie = DefaultCanaryCookie
+	}
+	// Assignments are made in basis points of the traffic.
+	threshold := uint32(percent * 100)
+
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			var chosen bool
+			if key := keyOf(ctx, opts.Key); key != "" {
+				h := fnv.New32a()
+				h.Write([]byte(key))
+				chosen = h.Sum32()%10000 < threshold
+			} else if c, err := r.Cookie(cookie); err == nil && (c.Value == "1" || c.Value == "0") {
+				chosen = c.Value == "1"
+			} else {
+				chosen = uint32(rand.Intn(10000)) < threshold
+				value := "0"
+				if chosen {
+					value = "1"
+				}
+				http.SetCookie(w, &http.Cookie{
+					Name:     cookie,
+					Value:    value,
+					Path:     "/",
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+
+			ctx = context.WithValue(ctx, canaryKey{}, chosen)
+			if chosen {
+				canary.ServeHTTPContext(ctx, w, r)
+			} else {
+				next.ServeHTTPContext(ctx, w, r)
+			}
+		})
+	}
+}
+
+func keyOf(ctx context.Context, key func(context.Context) string) string {
+	if key == nil {
+		return ""
+	}
+	return key(ctx)
+}
+
+// IsCanary reports whether the request was sent to the canary.
+func IsCanary(ctx context.Context) bool {
+	chosen, _ := ctx.Value(canaryKey{}).(bool)
+	return chosen
+}
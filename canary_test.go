@@ -0,0 +1,52 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func serveCanary(c Constructor, prepare func(*http.Request)) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	prepare(r)
+	c(writeTag("stable")).ServeHTTPContext(context.Background(), w, r)
+	return w
+}
+
+func TestCanaryExtremes(t *testing.T) {
+	none := func(*http.Request) {}
+	assert.Equal(t, serveCanary(Canary(0, writeTag("canary")), none).Body.String(), "stable")
+	assert.Equal(t, serveCanary(Canary(100, writeTag("canary")), none).Body.String(), "canary")
+}
+
+func TestCanaryIsStickyByCookie(t *testing.T) {
+	w := serveCanary(Canary(100, writeTag("canary")), func(*http.Request) {})
+	cookies := w.Result().Cookies()
+	assert.Equal(t, len(cookies), 1)
+	assert.Equal(t, cookies[0].Name, DefaultCanaryCookie)
+	assert.Equal(t, cookies[0].Value, "1")
+
+	// A client assigned to stable stays there even at 100%.
+	w = serveCanary(Canary(100, writeTag("canary")), func(r *http.Request) {
+		r.AddCookie(&http.Cookie{Name: DefaultCanaryCookie, Value: "0"})
+	})
+	assert.Equal(t, w.Body.String(), "stable")
+}
+
+func TestCanaryIsStickyByKey(t *testing.T) {
+	key := func(ctx context.Context) string {
+		return "user-42"
+	}
+	c := CanaryWith(50, writeTag("canary"), CanaryOptions{Key: key})
+
+	first := serveCanary(c, func(*http.Request) {}).Body.String()
+	for i := 0; i < 10; i++ {
+		w := serveCanary(c, func(*http.Request) {})
+		assert.Equal(t, w.Body.String(), first)
+		assert.Equal(t, len(w.Result().Cookies()), 0)
+	}
+}
@@ -0,0 +1,93 @@
+package alice
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// TrailingSlash is a policy for trailing slashes in paths.
+type TrailingSlash int
+
+const (
+	// KeepTrailingSlash leaves trailing slashes as they are.
+	KeepTrailingSlash TrailingSlash = iota
+	// StripTrailingSlash removes trailing slashes.
+	StripTrailingSlash
+	// AddTrailingSlash makes paths end in a slash.
+	AddTrailingSlash
+)
+
+// CanonicalPathOptions configure CanonicalPath.
+type CanonicalPathOptions struct {
+	TrailingSlash TrailingSlash
+	// Rewrite serves the canonical path directly
+	// instead of redirecting the client to it.
+	Rewrite bool
+}
+
+// CanonicalPath returns a Constructor bringing request paths into
+// canonical form before the router sees them: duplicate slashes are
+// collapsed, "." and ".." segments removed and trailing slashes
+// handled according to opts. Clients are redirected to the canonical
+// path, with 301 Moved Permanently for GET and HEAD and 308 Permanent
+// Redirect otherwise, unless opts.Rewrite is set.
+// Percent-encoded characters, such as %2F, are preserved, and
+// asterisk-form requests, as in "OPTIONS *", are left alone.
+func CanonicalPath(opts CanonicalPathOptions) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			escaped := r.URL.EscapedPath()
+			canonical := canonicalPath(escaped, opts.TrailingSlash)
+			if canonical == escaped || escaped == "*" {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			if !opts.Rewrite {
+				u := url.URL{Path: canonical, RawQuery: r.URL.RawQuery}
+				if p, err := url.PathUnescape(canonical); err == nil {
+					u.Path, u.RawPath = p, canonical
+				}
+				status := http.StatusPermanentRedirect
+				if r.Method == "GET" || r.Method == "HEAD" {
+					status = http.StatusMovedPermanently
+				}
+				w.Header().Set("Location", u.String())
+				w.WriteHeader(status)
+				return
+			}
+			p, err := url.PathUnescape(canonical)
+			if err != nil {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			r2 := new(http.Request)
+			*r2 = *r
+			u := *r.URL
+			u.Path, u.RawPath = p, canonical
+			r2.URL = &u
+			next.ServeHTTPContext(ctx, w, r2)
+		})
+	}
+}
+
+func canonicalPath(p string, policy TrailingSlash) string {
+	trailing := strings.HasSuffix(p, "/")
+	clean := path.Clean("/" + p)
+	if clean == "/" {
+		return clean
+	}
+	switch policy {
+	case AddTrailingSlash:
+		trailing = true
+	case StripTrailingSlash:
+		trailing = false
+	}
+	if trailing {
+		clean += "/"
+	}
+	return clean
+}
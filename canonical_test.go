@@ -0,0 +1,67 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestCanonicalPath(t *testing.T) {
+	cases := []struct {
+		policy         TrailingSlash
+		path, location string
+	}{
+		{KeepTrailingSlash, "/a//b/./c/../d/", "/a/b/d/"},
+		{KeepTrailingSlash, "/a/b?x=1", ""},
+		{StripTrailingSlash, "/a/b/?x=1", "/a/b?x=1"},
+		{AddTrailingSlash, "/a/b", "/a/b/"},
+		{AddTrailingSlash, "/", ""},
+		{KeepTrailingSlash, "/../etc/passwd", "/etc/passwd"},
+		{KeepTrailingSlash, "/files//a%2Fb", "/files/a%2Fb"},
+	}
+	for _, c := range cases {
+		h := New(CanonicalPath(CanonicalPathOptions{TrailingSlash: c.policy})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {}))
+		r := httptest.NewRequest("GET", "/", nil)
+		r.URL, _ = url.Parse(c.path)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, w.Header().Get("Location"), c.location, c.path)
+		if c.location != "" {
+			assert.Equal(t, w.Code, http.StatusMovedPermanently)
+		}
+	}
+}
+
+func TestCanonicalPathRewrite(t *testing.T) {
+	var path string
+	h := New(CanonicalPath(CanonicalPathOptions{Rewrite: true, TrailingSlash: StripTrailingSlash})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+	r := httptest.NewRequest("POST", "/a//b/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, path, "/a/b")
+}
+
+func TestCanonicalPathRedirectKeepsMethod(t *testing.T) {
+	h := New(CanonicalPath(CanonicalPathOptions{})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/a//b", nil))
+	assert.Equal(t, w.Code, http.StatusPermanentRedirect)
+}
+
+func TestCanonicalPathLeavesAsteriskForm(t *testing.T) {
+	served := false
+	h := New(CanonicalPath(CanonicalPathOptions{TrailingSlash: AddTrailingSlash})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		served = true
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("OPTIONS", "*", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.True(t, served)
+}
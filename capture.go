@@ -0,0 +1,275 @@
+package alice
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultCaptureBodySize is the default number of body bytes Capture keeps.
+const DefaultCaptureBodySize = 4 << 10
+
+// CapturedExchange is a request and its response recorded by Capture.
+// Headers and bodies are redacted.
+type CapturedExchange struct {
+	ID       uint64        `json:"id"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+
+	Method        string      `json:"method"`
+	URL           string      `json:"url"`
+	Proto         string      `json:"proto"`
+	Host          string      `json:"host"`
+	RemoteAddr    string      `json:"remote_addr"`
+	RequestHeader http.Header `json:"request_header"`
+	RequestBody   string      `json:"request_body,omitempty"`
+	// RequestTruncated reports whether RequestBody was cut
+	// at CaptureOptions.MaxBodySize.
+	RequestTruncated bool `json:"request_truncated,omitempty"`
+
+	Status            int         `json:"status"`
+	ResponseHeader    http.Header `json:"response_header"`
+	ResponseBody      string      `json:"response_body,omitempty"`
+	ResponseTruncated bool        `json:"response_truncated,omitempty"`
+}
+
+// CaptureBuffer is a ring buffer of the latest captured exchanges.
+// It serves them as JSON, newest first, and can be toggled at runtime:
+//
+//	GET  /debug/requests                  list the captured exchanges
+//	GET  /debug/requests?format=har       export them as HAR, see WriteHAR
+//	POST /debug/requests?enabled=true     start capturing
+//	POST /debug/requests?enabled=false    stop capturing
+//	DELETE /debug/requests                clear the buffer
+//
+// A new CaptureBuffer is disabled.
+type CaptureBuffer struct {
+	enabled atomic.Bool
+	ids     atomic.Uint64
+
+	mu      sync.Mutex
+	entries []CapturedExchange
+	next    int
+	full    bool
+}
+
+// NewCaptureBuffer returns a CaptureBuffer keeping the latest size exchanges.
+func NewCaptureBuffer(size int) *CaptureBuffer {
+	if size < 1 {
+		size = 1
+	}
+	return &CaptureBuffer{entries: make([]CapturedExchange, size)}
+}
+
+// Enable starts capturing.
+func (b *CaptureBuffer) Enable() { b.enabled.Store(true) }
+
+// Disable stops capturing. Captured exchanges are kept.
+func (b *CaptureBuffer) Disable() { b.enabled.Store(false) }
+
+// Enabled reports whether requests are being captured.
+func (b *CaptureBuffer) Enabled() bool { return b.enabled.Load() }
+
+func (b *CaptureBuffer) add(ex CapturedExchange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = ex
+	b.next = (b.next + 1) % len(b.entries)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Exchanges returns the captured exchanges, newest first.
+func (b *CaptureBuffer) Exchanges() []CapturedExchange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.next
+	if b.full {
+		n = len(b.entries)
+	}
+	out := make([]CapturedExchange, n)
+	for i := range out {
+		out[i] = b.entries[(b.next-1-i+len(b.entries))%len(b.entries)]
+	}
+	return out
+}
+
+// Reset removes all captured exchanges.
+func (b *CaptureBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.entries)
+	b.next = 0
+	b.full = false
+}
+
+// ServeHTTPContext serves the inspection endpoint.
+func (b *CaptureBuffer) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET", "HEAD":
+		if r.FormValue("format") == "har" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Disposition", `attachment; filename="requests.har"`)
+			b.WriteHAR(w)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Enabled   bool               `json:"enabled"`
+			Exchanges []CapturedExchange `json:"exchanges"`
+		}{b.Enabled(), b.Exchanges()})
+	case "POST":
+		enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+		if err != nil {
+			WriteError(ctx, w, &HTTPError{Status: http.StatusBadRequest, Detail: "enabled must be true or false"})
+			return
+		}
+		b.enabled.Store(enabled)
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE":
+		b.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST, DELETE")
+		WriteError(ctx, w, &HTTPError{Status: http.StatusMethodNotAllowed})
+	}
+}
+
+// CaptureOptions configure Capture.
+type CaptureOptions struct {
+	// Buffer receives the captured exchanges.
+	Buffer *CaptureBuffer
+	// MaxBodySize is the number of body bytes kept per request and response.
+	// It defaults to DefaultCaptureBodySize; negative values keep none.
+	MaxBodySize int
+	// Redactor masks headers, query parameters and JSON bodies
+	// before they are stored.
+	// It defaults to DefaultRedactor.
+	Redactor *Redactor
+}
+
+// Capture returns a Constructor recording requests and responses into
+// opts.Buffer while it is enabled, for diagnosing production issues.
+// It panics if opts.Buffer is nil. Headers, query parameters, and JSON
+// and form bodies are redacted by opts.Redactor. Bodies are recorded as
+// the handler reads and writes them, truncated to opts.MaxBodySize;
+// truncated JSON bodies and multipart bodies are masked entirely.
+//
+//	requests := alice.NewCaptureBuffer(100)
+//	chain := alice.New(alice.Capture(alice.CaptureOptions{Buffer: requests}), ...)
+//	mux.Handle("/debug/", alice.NewContextAdapter(ctx, alice.Debug(alice.DebugOptions{
+//	    Auth:     adminOnly,
+//	    Requests: requests,
+//	})))
+func Capture(opts CaptureOptions) Constructor {
+	if opts.Buffer == nil {
+		panic("alice: Capture: nil Buffer")
+	}
+	limit := opts.MaxBodySize
+	if limit == 0 {
+		limit = DefaultCaptureBodySize
+	}
+	limit = max(limit, 0)
+	rd := opts.Redactor
+	if rd == nil {
+		rd = DefaultRedactor
+	}
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if !opts.Buffer.Enabled() {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			start := time.Now()
+			reqBody := &limitedBuffer{limit: limit}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.TeeReader(r.Body, reqBody), r.Body}
+			}
+			cw := &captureWriter{ResponseRecorder: NewResponseRecorder(w), body: limitedBuffer{limit: limit}}
+			next.ServeHTTPContext(ctx, cw, r)
+
+			opts.Buffer.add(CapturedExchange{
+				ID:                opts.Buffer.ids.Add(1),
+				Time:              start,
+				Duration:          time.Since(start),
+				Method:            r.Method,
+				URL:               rd.URL(r.URL),
+				Proto:             r.Proto,
+				Host:              r.Host,
+				RemoteAddr:        r.RemoteAddr,
+				RequestHeader:     rd.Header(r.Header),
+				RequestBody:       capturedBody(rd, r.Header.Get("Content-Type"), &reqBody.Buffer, reqBody.truncated),
+				RequestTruncated:  reqBody.truncated,
+				Status:            cw.Status(),
+				ResponseHeader:    rd.Header(cw.Header()),
+				ResponseBody:      capturedBody(rd, cw.Header().Get("Content-Type"), &cw.body.Buffer, cw.body.truncated),
+				ResponseTruncated: cw.body.truncated,
+			})
+		})
+	}
+}
+
+// capturedBody redacts a recorded body of type contentType. Form fields
+// are masked like JSON ones, and multipart bodies, which cannot be
+// redacted, entirely. A truncated JSON body cannot be parsed to find the
+// fields to mask, so it is replaced by the mask as a whole too.
+func capturedBody(rd *Redactor, contentType string, body *bytes.Buffer, truncated bool) string {
+	mt, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mt == "application/x-www-form-urlencoded":
+		return string(rd.Form(body.Bytes()))
+	case strings.HasPrefix(mt, "multipart/"):
+		if body.Len() == 0 {
+			return ""
+		}
+		return rd.mask()
+	}
+	b := bytes.TrimSpace(body.Bytes())
+	if truncated && len(b) > 0 && (b[0] == '{' || b[0] == '[') {
+		return rd.mask()
+	}
+	return string(rd.JSON(body.Bytes()))
+}
+
+// limitedBuffer keeps the first limit bytes written to it.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// It never fails, so it can be used with io.TeeReader.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.limit - b.Len(); room < n {
+		b.truncated = true
+		p = p[:max(room, 0)]
+	}
+	b.Buffer.Write(p)
+	return n, nil
+}
+
+// captureWriter copies the response body into a limitedBuffer.
+type captureWriter struct {
+	*ResponseRecorder
+	body limitedBuffer
+}
+
+func (c *captureWriter) Write(b []byte) (int, error) {
+	n, err := c.ResponseRecorder.Write(b)
+	c.body.Write(b[:n])
+	return n, err
+}
@@ -0,0 +1,105 @@
+package alice
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestCaptureRecordsRedactedExchanges(t *testing.T) {
+	buf := NewCaptureBuffer(2)
+	h := New(Capture(CaptureOptions{Buffer: buf, MaxBodySize: 32})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(strings.Repeat("x", 40)))
+	}))
+
+	r := httptest.NewRequest("POST", "/login", strings.NewReader(`{"user":"alice","password":"hunter2"}`))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, len(buf.Exchanges()), 0)
+
+	buf.Enable()
+	for _, path := range []string{"/a", "/b", "/login?api_key=s3cret"} {
+		r = httptest.NewRequest("POST", path, strings.NewReader(`{"password":"hunter2"}`))
+		r.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, w.Body.Len(), 40)
+	}
+
+	exchanges := buf.Exchanges()
+	assert.Equal(t, len(exchanges), 2)
+	ex := exchanges[0]
+	assert.Equal(t, ex.URL, "/login?api_key=[REDACTED]")
+	assert.Equal(t, exchanges[1].URL, "/b")
+	assert.Equal(t, ex.Status, http.StatusCreated)
+	assert.Equal(t, ex.RequestHeader.Get("Authorization"), DefaultRedactionMask)
+	assert.Equal(t, ex.RequestBody, `{"password":"[REDACTED]"}`)
+	assert.Equal(t, len(ex.ResponseBody), 32)
+	assert.True(t, ex.ResponseTruncated)
+}
+
+func TestCaptureMasksTruncatedJSON(t *testing.T) {
+	buf := NewCaptureBuffer(1)
+	buf.Enable()
+	h := New(Capture(CaptureOptions{Buffer: buf, MaxBodySize: 8})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"password":"hunter2"}`))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, buf.Exchanges()[0].RequestBody, DefaultRedactionMask)
+}
+
+func TestCaptureRedactsForms(t *testing.T) {
+	buf := NewCaptureBuffer(2)
+	buf.Enable()
+	h := New(Capture(CaptureOptions{Buffer: buf})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+	}))
+
+	r := httptest.NewRequest("POST", "/login", strings.NewReader("user=alice&password=hunter2"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	r = httptest.NewRequest("POST", "/upload", strings.NewReader("--b\r\nContent-Disposition: form-data; name=\"password\"\r\n\r\nhunter2\r\n--b--\r\n"))
+	r.Header.Set("Content-Type", "multipart/form-data; boundary=b")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	exchanges := buf.Exchanges()
+	assert.Equal(t, exchanges[1].RequestBody, "user=alice&password="+DefaultRedactionMask)
+	assert.Equal(t, exchanges[0].RequestBody, DefaultRedactionMask)
+}
+
+func TestCaptureRequiresBuffer(t *testing.T) {
+	assert.PanicsWithValue(t, "alice: Capture: nil Buffer", func() { Capture(CaptureOptions{}) })
+}
+
+func TestCaptureBufferToggle(t *testing.T) {
+	buf := NewCaptureBuffer(10)
+	h := Debug(DebugOptions{Requests: buf})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTPContext(context.Background(), w, httptest.NewRequest("POST", "/debug/requests?enabled=true", nil))
+	assert.Equal(t, w.Code, http.StatusNoContent)
+	assert.True(t, buf.Enabled())
+
+	w = httptest.NewRecorder()
+	h.ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/debug/requests", nil))
+	var out struct {
+		Enabled   bool               `json:"enabled"`
+		Exchanges []CapturedExchange `json:"exchanges"`
+	}
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&out))
+	assert.True(t, out.Enabled)
+	assert.Equal(t, len(out.Exchanges), 0)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTPContext(context.Background(), w, httptest.NewRequest("POST", "/debug/requests?enabled=maybe", nil))
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
@@ -2,9 +2,9 @@
 package alice
 
 import (
-	"log"
 	"golang.org/x/net/context"
 	"net/http"
+	"os"
 )
 
 func NewContextAdapter(c context.Context, handler ContextHandler) *ContextAdapter {
@@ -17,6 +17,9 @@ func NewContextAdapter(c context.Context, handler ContextHandler) *ContextAdapte
 type ContextAdapter struct {
 	ctx context.Context
 	handler ContextHandler
+	// chain and the layers it built, outermost first.
+	chain  Chain
+	layers []ContextHandler
 }
 
 func (ca *ContextAdapter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
@@ -52,7 +55,9 @@ type Chain struct {
 // memorizing the given list of middleware constructors.
 // New serves no other function,
 // constructors are only called upon a call to Then().
+// New panics if a constructor is nil.
 func New(constructors ...Constructor) Chain {
+	requireConstructors("New", constructors)
 	c := Chain{}
 	c.constructors = append(c.constructors, constructors...)
 
@@ -85,13 +90,31 @@ func (c Chain) ThenWithContext(cnx context.Context, h ContextHandler) *ContextAd
 		final = h
 	} else {
 		final = nil // TODO: implement wrapper around http.DefaultServeMux
-		log.Fatalf("Error Contexthandler cant be null")
+		logger().Error("alice: ContextHandler can't be nil")
+		os.Exit(1)
 	}
 
+	layers := c.layers(final)
+	ca := NewContextAdapter(cnx, layers[0])
+	ca.chain, ca.layers = c, layers
+	return ca
+}
+
+// compose wraps h in the chain's constructors.
+func (c Chain) compose(h ContextHandler) ContextHandler {
+	return c.layers(h)[0]
+}
+
+// layers wraps h in the chain's constructors,
+// returning every handler built, outermost first.
+func (c Chain) layers(h ContextHandler) []ContextHandler {
+	layers := make([]ContextHandler, len(c.constructors)+1)
+	layers[len(c.constructors)] = h
 	for i := len(c.constructors) - 1; i >= 0; i-- {
-		final = c.constructors[i](final)
+		h = c.constructors[i](h)
+		layers[i] = h
 	}
-	return NewContextAdapter(cnx, final)
+	return layers
 }
 
 // ThenFunc works identically to Then, but takes
@@ -109,16 +132,32 @@ func (c Chain) ThenFuncWithContext(cnx context.Context, fn ContextHandlerFunc) *
 	return c.ThenWithContext(cnx, ContextHandlerFunc(fn))
 }
 
+// ThenWith works like ThenWithContext, with extra constructors run
+// after the chain's, right before h. It adds route-specific middleware
+// without building a chain for every route:
+//     api := alice.New(auth, logging)
+//     mux.Handle("/users", api.ThenWith(ctx, users))
+//     mux.Handle("/admin", api.ThenWith(ctx, admin, requireAdmin))
+// Like New, it panics if a constructor is nil.
+func (c Chain) ThenWith(cnx context.Context, h ContextHandler, extra ...Constructor) *ContextAdapter {
+	requireConstructors("ThenWith", extra)
+	cons := make([]Constructor, 0, len(c.constructors)+len(extra))
+	cons = append(append(cons, c.constructors...), extra...)
+	return Chain{constructors: cons}.ThenWithContext(cnx, h)
+}
+
 // Append extends a chain, adding the specified constructors
 // as the last ones in the request flow.
 //
 // Append returns a new chain, leaving the original one untouched.
+// Like New, it panics if a constructor is nil.
 //
 //     stdChain := alice.New(m1, m2)
 //     extChain := stdChain.Append(m3, m4)
 //     // requests in stdChain go m1 -> m2
 //     // requests in extChain go m1 -> m2 -> m3 -> m4
 func (c Chain) Append(constructors ...Constructor) Chain {
+	requireConstructors("Append", constructors)
 	newCons := make([]Constructor, len(c.constructors)+len(constructors))
 	copy(newCons, c.constructors)
 	copy(newCons[len(c.constructors):], constructors)
@@ -151,3 +190,9 @@ func (c Chain) Append(constructors ...Constructor) Chain {
 func (c Chain) Extend(chain Chain) Chain {
 	return c.Append(chain.constructors...)
 }
+
+// Constructors returns a copy of the chain's constructors,
+// in request flow order.
+func (c Chain) Constructors() []Constructor {
+	return append([]Constructor(nil), c.constructors...)
+}
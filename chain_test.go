@@ -2,22 +2,26 @@
 package alice
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"os/exec"
 	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
 )
 
 // A constructor for middleware
 // that writes its own "tag" into the RW and does nothing else.
 // Useful in checking if a chain is behaving in the right order.
 func tagMiddleware(tag string) Constructor {
-	return func(h http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return func(h ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 			w.Write([]byte(tag))
-			h.ServeHTTP(w, r)
+			h.ServeHTTPContext(ctx, w, r)
 		})
 	}
 }
@@ -30,17 +34,17 @@ func funcsEqual(f1, f2 interface{}) bool {
 	return val1.Pointer() == val2.Pointer()
 }
 
-var testApp = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+var testApp = ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("app\n"))
 })
 
 // Tests creating a new chain
 func TestNew(t *testing.T) {
-	c1 := func(h http.Handler) http.Handler {
+	c1 := func(h ContextHandler) ContextHandler {
 		return nil
 	}
-	c2 := func(h http.Handler) http.Handler {
-		return http.StripPrefix("potato", nil)
+	c2 := func(h ContextHandler) ContextHandler {
+		return testApp
 	}
 
 	slice := []Constructor{c1, c2}
@@ -50,31 +54,27 @@ func TestNew(t *testing.T) {
 	assert.True(t, funcsEqual(chain.constructors[1], slice[1]))
 }
 
+func TestNewRejectsNilConstructors(t *testing.T) {
+	assert.PanicsWithValue(t, "alice: New: constructor 1 is nil", func() {
+		New(tagMiddleware("t1\n"), nil)
+	})
+}
+
 func TestThenWorksWithNoMiddleware(t *testing.T) {
 	assert.NotPanics(t, func() {
 		chain := New()
-		final := chain.Then(testApp)
+		final := chain.ThenWithContext(context.Background(), testApp)
 
-		assert.True(t, funcsEqual(final, testApp))
+		assert.True(t, funcsEqual(final.handler, testApp))
 	})
 }
 
-func TestThenTreatsNilAsDefaultServeMux(t *testing.T) {
-	chained := New().Then(nil)
-	assert.Equal(t, chained, http.DefaultServeMux)
-}
-
-func TestThenFuncTreatsNilAsDefaultServeMux(t *testing.T) {
-	chained := New().ThenFunc(nil)
-	assert.Equal(t, chained, http.DefaultServeMux)
-}
-
 func TestThenOrdersHandlersRight(t *testing.T) {
 	t1 := tagMiddleware("t1\n")
 	t2 := tagMiddleware("t2\n")
 	t3 := tagMiddleware("t3\n")
 
-	chained := New(t1, t2, t3).Then(testApp)
+	chained := New(t1, t2, t3).ThenWithContext(context.Background(), testApp)
 
 	w := httptest.NewRecorder()
 	r, err := http.NewRequest("GET", "/", nil)
@@ -87,6 +87,37 @@ func TestThenOrdersHandlersRight(t *testing.T) {
 	assert.Equal(t, w.Body.String(), "t1\nt2\nt3\napp\n")
 }
 
+func TestThenPassesContext(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	chained := New(tagMiddleware("t1\n")).ThenFuncWithContext(ctx, func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(ctx.Value(key{}).(string)))
+	})
+
+	w := httptest.NewRecorder()
+	chained.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Body.String(), "t1\nvalue")
+}
+
+func TestThenWithAddsRouteMiddleware(t *testing.T) {
+	chain := New(tagMiddleware("t1\n"))
+	chained := chain.ThenWith(context.Background(), testApp, tagMiddleware("t2\n"), tagMiddleware("t3\n"))
+
+	w := httptest.NewRecorder()
+	chained.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Body.String(), "t1\nt2\nt3\napp\n")
+	assert.Equal(t, len(chain.constructors), 1)
+	assert.Equal(t, len(chained.chain.constructors), 3)
+}
+
+func TestThenWithRejectsNilConstructors(t *testing.T) {
+	assert.PanicsWithValue(t, "alice: ThenWith: constructor 0 is nil", func() {
+		New().ThenWith(context.Background(), testApp, nil)
+	})
+}
+
 func TestAppendAddsHandlersCorrectly(t *testing.T) {
 	chain := New(tagMiddleware("t1\n"), tagMiddleware("t2\n"))
 	newChain := chain.Append(tagMiddleware("t3\n"), tagMiddleware("t4\n"))
@@ -94,7 +125,7 @@ func TestAppendAddsHandlersCorrectly(t *testing.T) {
 	assert.Equal(t, len(chain.constructors), 2)
 	assert.Equal(t, len(newChain.constructors), 4)
 
-	chained := newChain.Then(testApp)
+	chained := newChain.ThenWithContext(context.Background(), testApp)
 
 	w := httptest.NewRecorder()
 	r, err := http.NewRequest("GET", "/", nil)
@@ -114,6 +145,12 @@ func TestAppendRespectsImmutability(t *testing.T) {
 	assert.NotEqual(t, &chain.constructors[0], &newChain.constructors[0])
 }
 
+func TestAppendRejectsNilConstructors(t *testing.T) {
+	assert.PanicsWithValue(t, "alice: Append: constructor 0 is nil", func() {
+		New(tagMiddleware("t1\n")).Append(nil)
+	})
+}
+
 func TestExtendAddsHandlersCorrectly(t *testing.T) {
 	chain1 := New(tagMiddleware("t1\n"), tagMiddleware("t2\n"))
 	chain2 := New(tagMiddleware("t3\n"), tagMiddleware("t4\n"))
@@ -123,7 +160,7 @@ func TestExtendAddsHandlersCorrectly(t *testing.T) {
 	assert.Equal(t, len(chain2.constructors), 2)
 	assert.Equal(t, len(newChain.constructors), 4)
 
-	chained := newChain.Then(testApp)
+	chained := newChain.ThenWithContext(context.Background(), testApp)
 
 	w := httptest.NewRecorder()
 	r, err := http.NewRequest("GET", "/", nil)
@@ -142,3 +179,31 @@ func TestExtendRespectsImmutability(t *testing.T) {
 
 	assert.NotEqual(t, &chain.constructors[0], &newChain.constructors[0])
 }
+
+// ThenWithContext exits the process on a nil handler,
+// so these run in a subprocess (see assertExits).
+func TestThenExitsOnNilHandler(t *testing.T) {
+	assertExits(t, func() {
+		New().ThenWithContext(context.Background(), nil)
+	})
+}
+
+func TestThenFuncExitsOnNilHandler(t *testing.T) {
+	assertExits(t, func() {
+		New().ThenFuncWithContext(context.Background(), nil)
+	})
+}
+
+// assertExits runs the calling test again in a subprocess, which calls
+// fn, and asserts that the subprocess exits with a non-zero status.
+func assertExits(t *testing.T, fn func()) {
+	if os.Getenv("ALICE_TEST_EXIT") == t.Name() {
+		fn()
+		return
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$")
+	cmd.Env = append(os.Environ(), "ALICE_TEST_EXIT="+t.Name())
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(err, &exitErr), "want a non-zero exit status, got %v", err)
+}
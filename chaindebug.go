@@ -0,0 +1,88 @@
+package alice
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// debugTraceKey identifies the traces of one Debug chain, so chains
+// nested in it keep their own. It is not empty, for distinct addresses.
+type debugTraceKey struct{ _ byte }
+
+// debugTrace records which layers of a debugged chain a request entered.
+type debugTrace struct {
+	mu      sync.Mutex
+	entered []bool
+}
+
+func (t *debugTrace) enter(i int) {
+	t.mu.Lock()
+	t.entered[i] = true
+	t.mu.Unlock()
+}
+
+func (t *debugTrace) reached(i int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.entered[i]
+}
+
+// Debug returns a copy of the chain logging, at debug level, every
+// middleware a request enters and leaves, with its name and the time
+// spent in it and the middleware after it. A middleware not calling
+// the next handler is logged as having short-circuited the request:
+//
+//	chain := alice.New(alice.RequestID(), auth).Debug(slog.Default())
+//	// alice: enter middleware=github.com/SimiPro/alice.RequestID.func1 position=0 method=GET path=/
+//	// alice: enter middleware=main.auth position=1 method=GET path=/
+//	// alice: exit middleware=main.auth position=1 duration=52µs short_circuit=true
+//	// alice: exit middleware=github.com/SimiPro/alice.RequestID.func1 position=0 duration=61µs short_circuit=false
+//
+// Middleware is named after the function that built it, as on the debug
+// endpoint. The returned chain reports the names of c's constructors
+// to Check, WriteDOT, Subtract and Intersect.
+// A nil logger logs to the package Logger (see SetLogger).
+// Meant for development; it adds overhead to every request.
+func (c Chain) Debug(l Logger) Chain {
+	n := len(c.constructors)
+	key := &debugTraceKey{}
+	constructors := make([]Constructor, n)
+	for i, cons := range c.constructors {
+		name := constructorName(cons)
+		constructors[i] = nameConstructor(func(next ContextHandler) ContextHandler {
+			marked := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+				if t, ok := ctx.Value(key).(*debugTrace); ok {
+					t.enter(i + 1)
+				}
+				next.ServeHTTPContext(ctx, w, r)
+			})
+			h := cons(marked)
+			if h == nil {
+				// Left for Check to report.
+				return nil
+			}
+			return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+				log := l
+				if log == nil {
+					log = logger()
+				}
+				// A request entering the chain again starts a new trace.
+				t, ok := ctx.Value(key).(*debugTrace)
+				if !ok || i == 0 {
+					t = &debugTrace{entered: make([]bool, n+1)}
+					ctx = context.WithValue(ctx, key, t)
+				}
+				t.enter(i)
+				log.Debug("alice: enter", "middleware", name, "position", i, "method", r.Method, "path", r.URL.Path)
+				start := time.Now()
+				h.ServeHTTPContext(ctx, w, r)
+				log.Debug("alice: exit", "middleware", name, "position", i,
+					"duration", time.Since(start), "short_circuit", !t.reached(i+1))
+			})
+		}, name)
+	}
+	return Chain{constructors: constructors}
+}
@@ -0,0 +1,79 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestChainDebugLogsEnterAndExit(t *testing.T) {
+	l := &memoryLogger{}
+	h := New(tagMiddleware("t1\n"), tagMiddleware("t2\n")).Debug(l).ThenWithContext(context.Background(), testApp)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Body.String(), "t1\nt2\napp\n")
+	assert.Equal(t, len(l.lines), 4)
+	assert.True(t, strings.HasPrefix(l.lines[0], "DEBUG alice: enter"))
+	assert.True(t, strings.Contains(l.lines[0], "middleware github.com/SimiPro/alice.tagMiddleware.func1"))
+	assert.True(t, strings.Contains(l.lines[1], "position 1"))
+	assert.True(t, strings.Contains(l.lines[2], "short_circuit false"))
+	assert.True(t, strings.Contains(l.lines[3], "short_circuit false"))
+}
+
+func TestChainDebugReportsShortCircuit(t *testing.T) {
+	l := &memoryLogger{}
+	stop := func(h ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		})
+	}
+	h := New(passThrough, stop, tagMiddleware("t3\n")).Debug(l).ThenWithContext(context.Background(), testApp)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Code, http.StatusForbidden)
+	assert.Equal(t, len(l.lines), 4)
+	assert.True(t, strings.Contains(l.lines[2], "position 1"))
+	assert.True(t, strings.Contains(l.lines[2], "short_circuit true"))
+	assert.True(t, strings.Contains(l.lines[3], "position 0"))
+	assert.True(t, strings.Contains(l.lines[3], "short_circuit false"))
+}
+
+func TestChainDebugNested(t *testing.T) {
+	l := &memoryLogger{}
+	inner := New(passThrough, passThrough, passThrough, passThrough).Debug(l)
+	h := New(passThrough, Mount("/api", inner, testApp)).Debug(l).ThenWithContext(context.Background(), testApp)
+	w := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/api/users", nil))
+	})
+
+	assert.Equal(t, w.Body.String(), "app\n")
+	assert.Equal(t, len(l.lines), 12)
+	// Only the Mount layer of the outer chain short-circuits it.
+	var short []string
+	for _, line := range l.lines {
+		if strings.Contains(line, "short_circuit true") {
+			short = append(short, line)
+		}
+	}
+	if assert.Len(t, short, 1) {
+		assert.Contains(t, short[0], "Mount")
+		assert.Contains(t, short[0], "position 1")
+	}
+}
+
+func TestChainDebugKeepsNames(t *testing.T) {
+	c := New(passThrough, tagMiddleware("t1\n"))
+	debugged := c.Debug(nil)
+	assert.Equal(t, debugged.names(), c.names())
+	assert.Equal(t, len(debugged.Subtract(New(passThrough)).constructors), 1)
+
+	err := New(passThrough, nilHandler).Debug(nil).Check(testApp)
+	assert.True(t, strings.HasSuffix(err.Error(), ".nilHandler) returned a nil handler"))
+}
@@ -0,0 +1,77 @@
+package alice
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// ChaosOptions configure the faults Chaos injects.
+// Each rate is the fraction (0-1) of requests the fault is injected into.
+type ChaosOptions struct {
+	// LatencyRate requests are delayed by Latency before being served.
+	LatencyRate float64
+	Latency     time.Duration
+
+	// ErrorRate requests fail with ErrorStatus
+	// (503 Service Unavailable if zero).
+	ErrorRate   float64
+	ErrorStatus int
+
+	// DropRate requests have their connection closed without a response.
+	DropRate float64
+
+	// Header, if set, limits faults to requests carrying it,
+	// so only test traffic is affected.
+	Header string
+}
+
+// Chaos returns a Constructor injecting artificial latency,
+// errors and dropped connections, for resilience testing:
+//
+//	chain := alice.New(alice.Chaos(alice.ChaosOptions{
+//	    LatencyRate: 0.1,
+//	    Latency:     2 * time.Second,
+//	    ErrorRate:   0.01,
+//	    Header:      "X-Chaos",
+//	}), ...)
+//
+// The latency is cut short when the client goes away
+// or the chain's context is done.
+func Chaos(opts ChaosOptions) Constructor {
+	status := opts.ErrorStatus
+	if status == 0 {
+		status = http.StatusServiceUnavailable
+	}
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if opts.Header != "" && r.Header.Get(opts.Header) == "" {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			if opts.LatencyRate > 0 && rand.Float64() < opts.LatencyRate {
+				t := time.NewTimer(opts.Latency)
+				select {
+				case <-t.C:
+				case <-r.Context().Done():
+					t.Stop()
+					return
+				case <-ctx.Done():
+					t.Stop()
+					return
+				}
+			}
+			if opts.DropRate > 0 && rand.Float64() < opts.DropRate {
+				// The server closes the connection without logging.
+				panic(http.ErrAbortHandler)
+			}
+			if opts.ErrorRate > 0 && rand.Float64() < opts.ErrorRate {
+				WriteError(ctx, w, &HTTPError{Status: status, Code: "chaos", Detail: "fault injected"})
+				return
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
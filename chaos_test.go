@@ -0,0 +1,59 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func serveChaos(opts ChaosOptions, header string) *httptest.ResponseRecorder {
+	h := New(Chaos(opts)).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	if header != "" {
+		r.Header.Set(header, "1")
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestChaosInjectsErrors(t *testing.T) {
+	w := serveChaos(ChaosOptions{ErrorRate: 1, ErrorStatus: http.StatusBadGateway}, "")
+	assert.Equal(t, w.Code, http.StatusBadGateway)
+}
+
+func TestChaosOnlyForHeader(t *testing.T) {
+	opts := ChaosOptions{ErrorRate: 1, Header: "X-Chaos"}
+	assert.Equal(t, serveChaos(opts, "").Code, http.StatusOK)
+	assert.Equal(t, serveChaos(opts, "X-Chaos").Code, http.StatusServiceUnavailable)
+}
+
+func TestChaosInjectsLatency(t *testing.T) {
+	start := time.Now()
+	w := serveChaos(ChaosOptions{LatencyRate: 1, Latency: 20 * time.Millisecond}, "")
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+	assert.Equal(t, w.Body.String(), "ok")
+}
+
+func TestChaosLatencyStopsWithClient(t *testing.T) {
+	h := Chaos(ChaosOptions{LatencyRate: 1, Latency: time.Hour})(writeTag("ok"))
+	reqCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	h.ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/", nil).WithContext(reqCtx))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestChaosDropsConnections(t *testing.T) {
+	defer func() {
+		assert.Equal(t, recover(), http.ErrAbortHandler)
+	}()
+	serveChaos(ChaosOptions{DropRate: 1}, "")
+	t.Fatal("connection not dropped")
+}
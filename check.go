@@ -0,0 +1,54 @@
+package alice
+
+import "fmt"
+
+// Check builds the chain around h the way ThenWithContext does,
+// reporting the first problem that would otherwise only surface when
+// serving: a nil handler, a nil constructor, a constructor returning a
+// nil handler or one panicking. Positions are indices into the
+// constructors the chain was created with. Call it at startup:
+//
+//	if err := chain.Check(h); err != nil {
+//	    log.Fatal(err)
+//	}
+//
+// The constructors are called, so middleware is instantiated once more.
+func (c Chain) Check(h ContextHandler) (err error) {
+	if h == nil {
+		return fmt.Errorf("alice: handler is nil")
+	}
+	for i := len(c.constructors) - 1; i >= 0; i-- {
+		cons := c.constructors[i]
+		if cons == nil {
+			return fmt.Errorf("alice: constructor %d is nil", i)
+		}
+		h, err = checkConstructor(cons, h)
+		if err != nil {
+			return fmt.Errorf("alice: constructor %d (%s) %v", i, constructorName(cons), err)
+		}
+		if h == nil {
+			return fmt.Errorf("alice: constructor %d (%s) returned a nil handler", i, constructorName(cons))
+		}
+	}
+	return nil
+}
+
+// checkConstructor calls cons, turning a panic into an error.
+func checkConstructor(cons Constructor, next ContextHandler) (h ContextHandler, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panicked: %v", p)
+		}
+	}()
+	return cons(next), nil
+}
+
+// requireConstructors panics if one of the constructors given to fn is nil,
+// so misconfigured chains fail when they are built rather than when serving.
+func requireConstructors(fn string, constructors []Constructor) {
+	for i, cons := range constructors {
+		if cons == nil {
+			panic(fmt.Sprintf("alice: %s: constructor %d is nil", fn, i))
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package alice
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nilHandler(h ContextHandler) ContextHandler {
+	return nil
+}
+
+func TestCheckAcceptsValidChain(t *testing.T) {
+	assert.Nil(t, New(tagMiddleware("t1"), passThrough).Check(testApp))
+}
+
+func TestCheckReportsNilHandler(t *testing.T) {
+	assert.EqualError(t, New().Check(nil), "alice: handler is nil")
+}
+
+func TestCheckReportsNilConstructor(t *testing.T) {
+	chain := Chain{constructors: []Constructor{passThrough, nil, passThrough}}
+	err := chain.Check(testApp)
+	assert.EqualError(t, err, "alice: constructor 1 is nil")
+}
+
+func TestCheckReportsNilResult(t *testing.T) {
+	err := New(passThrough, nilHandler).Check(testApp)
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "alice: constructor 1 ("))
+	assert.True(t, strings.HasSuffix(err.Error(), ".nilHandler) returned a nil handler"))
+}
+
+func TestCheckReportsPanics(t *testing.T) {
+	panics := func(h ContextHandler) ContextHandler {
+		panic("no config")
+	}
+	err := New(panics, passThrough).Check(testApp)
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasSuffix(err.Error(), "panicked: no config"))
+}
@@ -0,0 +1,99 @@
+package alice
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// RoundTripperFunc adapts a function to an http.RoundTripper.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req).
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// A TransportConstructor is a piece of outbound middleware.
+type TransportConstructor func(http.RoundTripper) http.RoundTripper
+
+// TransportChain is the client-side counterpart of Chain:
+// a list of RoundTripper constructors applied to outbound requests.
+// Like Chain, it is immutable.
+//
+//	transport := alice.NewTransport(logRequests, alice.Propagate(alice.InjectB3))
+//	client := transport.Client(ctx)
+type TransportChain struct {
+	constructors []TransportConstructor
+}
+
+// NewTransport creates a new TransportChain of the given constructors.
+func NewTransport(constructors ...TransportConstructor) TransportChain {
+	return TransportChain{append([]TransportConstructor(nil), constructors...)}
+}
+
+// Append returns a new TransportChain with constructors added
+// as the last ones before the underlying transport.
+func (c TransportChain) Append(constructors ...TransportConstructor) TransportChain {
+	newCons := make([]TransportConstructor, 0, len(c.constructors)+len(constructors))
+	newCons = append(newCons, c.constructors...)
+	newCons = append(newCons, constructors...)
+	return TransportChain{newCons}
+}
+
+// Then returns rt wrapped in the chain's constructors, the first
+// constructor seeing requests first. It treats nil as http.DefaultTransport.
+func (c TransportChain) Then(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for i := len(c.constructors) - 1; i >= 0; i-- {
+		rt = c.constructors[i](rt)
+	}
+	return rt
+}
+
+// Client returns an http.Client whose requests run through the chain.
+// Requests made without a context of their own, e.g. by client.Get,
+// run with ctx, so they honor its deadline and cancellation.
+// The request ID, trace and baggage stored in the context (see RequestID,
+// PropagateTrace and Baggage) are sent along in the X-Request-Id,
+// traceparent and baggage headers, and the time left until the
+// context deadline in X-Request-Timeout (see Budget).
+//
+//	func handler(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+//	    resp, err := transport.Client(ctx).Get("http://users/42")
+//	    ...
+//	}
+func (c TransportChain) Client(ctx context.Context) *http.Client {
+	rt := c.Then(nil)
+	propagate := Propagate(InjectRequestID, InjectTraceContext, InjectBaggage, InjectBudget)
+	return &http.Client{Transport: RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Context() == context.Background() {
+			req = req.WithContext(ctx)
+		}
+		return propagate(rt).RoundTrip(req)
+	})}
+}
+
+// Client returns an http.Client bound to ctx
+// without further outbound middleware, see TransportChain.Client.
+func Client(ctx context.Context) *http.Client {
+	return TransportChain{}.Client(ctx)
+}
+
+// Propagate returns a TransportConstructor setting headers on outbound
+// requests from their context with the given propagators.
+func Propagate(propagators ...Propagator) TransportConstructor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			// RoundTrippers must not modify the caller's request.
+			req = req.Clone(ctx)
+			for _, p := range propagators {
+				p(ctx, req.Header)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func tagTransport(tag string) TransportConstructor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Add("X-Tags", tag)
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+func TestTransportChainOrder(t *testing.T) {
+	var tags []string
+	final := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		tags = req.Header.Values("X-Tags")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	chain := NewTransport(tagTransport("t1")).Append(tagTransport("t2"))
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	_, err := chain.Then(final).RoundTrip(req)
+	assert.Nil(t, err)
+	assert.Equal(t, tags, []string{"t1", "t2"})
+	assert.Equal(t, len(req.Header), 0)
+}
+
+func TestClientPropagatesContext(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer upstream.Close()
+
+	h := New(RequestID(), PropagateTrace()).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		resp, err := NewTransport(tagTransport("t1")).Client(ctx).Get(upstream.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	r.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, got.Get("X-Request-Id"), "abc")
+	assert.Equal(t, got.Get("X-Tags"), "t1")
+	assert.Contains(t, got.Get("Traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-")
+}
+
+func TestClientHonorsDeadline(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer upstream.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := Client(ctx).Get(upstream.URL)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,76 @@
+package alice
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// ClientIdentity describes the verified TLS client certificate of a request.
+type ClientIdentity struct {
+	// Subject is the certificate subject, e.g. "CN=billing,O=Example".
+	Subject string
+	// SPIFFEID is the spiffe:// URI SAN of the certificate, if any.
+	SPIFFEID string
+	// Certificate is the verified leaf certificate.
+	Certificate *x509.Certificate
+}
+
+type clientCertKey struct{}
+
+// ClientCert returns a Constructor storing the identity of the
+// verified client certificate in the context (see ClientCertFrom).
+// Certificates are only considered if the TLS stack verified them,
+// i.e. with tls.Config.ClientAuth set to VerifyClientCertIfGiven
+// or RequireAndVerifyClientCert.
+//
+// If allowed is not empty, only clients whose SPIFFE ID or subject
+// is listed get through; others get 403 Forbidden.
+func ClientCert(allowed ...string) Constructor {
+	allow := make(map[string]bool, len(allowed))
+	for _, id := range allowed {
+		allow[id] = true
+	}
+
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			id, ok := clientIdentity(r)
+			if len(allow) > 0 && (!ok || !(allow[id.SPIFFEID] || allow[id.Subject])) {
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusForbidden,
+					Detail: "client certificate not allowed",
+				})
+				return
+			}
+			if ok {
+				ctx = context.WithValue(ctx, clientCertKey{}, id)
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+func clientIdentity(r *http.Request) (ClientIdentity, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ClientIdentity{}, false
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	id := ClientIdentity{
+		Subject:     cert.Subject.String(),
+		Certificate: cert,
+	}
+	for _, u := range cert.URIs {
+		if u.Scheme == "spiffe" {
+			id.SPIFFEID = u.String()
+			break
+		}
+	}
+	return id, true
+}
+
+// ClientCertFrom returns the client identity stored by ClientCert.
+func ClientCertFrom(ctx context.Context) (ClientIdentity, bool) {
+	id, ok := ctx.Value(clientCertKey{}).(ClientIdentity)
+	return id, ok
+}
@@ -0,0 +1,53 @@
+package alice
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func requestWithCert(spiffeID string) *http.Request {
+	u, _ := url.Parse(spiffeID)
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "billing", Organization: []string{"Example"}},
+		URIs:    []*url.URL{u},
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	return r
+}
+
+func TestClientCertStoresIdentity(t *testing.T) {
+	var id ClientIdentity
+	h := ClientCert()(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		id, _ = ClientCertFrom(ctx)
+	}))
+	h.ServeHTTPContext(context.Background(), httptest.NewRecorder(), requestWithCert("spiffe://example.org/billing"))
+
+	assert.Equal(t, id.Subject, "CN=billing,O=Example")
+	assert.Equal(t, id.SPIFFEID, "spiffe://example.org/billing")
+}
+
+func TestClientCertAllowlist(t *testing.T) {
+	h := ClientCert("spiffe://example.org/billing", "CN=admin")(writeTag("ok"))
+	tests := []struct {
+		r    *http.Request
+		code int
+	}{
+		{requestWithCert("spiffe://example.org/billing"), http.StatusOK},
+		{requestWithCert("spiffe://example.org/web"), http.StatusForbidden},
+		{httptest.NewRequest("GET", "/", nil), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTPContext(context.Background(), w, tt.r)
+		assert.Equal(t, w.Code, tt.code)
+	}
+}
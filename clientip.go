@@ -0,0 +1,191 @@
+package alice
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+type clientIPKey struct{}
+
+// ClientIP returns the client address resolved by RealIP or IPFilter,
+// or the zero netip.Addr if neither ran.
+func ClientIP(ctx context.Context) netip.Addr {
+	ip, _ := ctx.Value(clientIPKey{}).(netip.Addr)
+	return ip
+}
+
+// remoteIP returns the address of the peer connected to the server.
+func remoteIP(r *http.Request) netip.Addr {
+	if ap, err := netip.ParseAddrPort(r.RemoteAddr); err == nil {
+		return ap.Addr().Unmap()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, _ := netip.ParseAddr(host)
+	return ip.Unmap()
+}
+
+// requestIP returns the client IP resolved by RealIP,
+// or else resolves it with the ProxyConfig in effect.
+func requestIP(ctx context.Context, r *http.Request) netip.Addr {
+	if ip := ClientIP(ctx); ip.IsValid() {
+		return ip
+	}
+	cfg, _ := proxyConfig(ctx, r)
+	return cfg.ClientIP(r)
+}
+
+func trusted(ip netip.Addr, proxies []netip.Prefix) bool {
+	for _, p := range proxies {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RealIP returns a Constructor resolving the client address of requests
+// with cfg and storing it in the context (see ClientIP). With a nil cfg,
+// the ProxyConfig of the chain or Server is used if there is one, and
+// the address of the connected peer otherwise.
+// Forwarding headers are only believed when the request comes from
+// a trusted proxy, and only the ones listed in the Headers of the
+// ProxyConfig, so list only the headers the proxies set:
+//
+//	alice.RealIP(&alice.ProxyConfig{
+//	    TrustedProxies: lbNetworks,
+//	    Headers:        []string{"X-Forwarded-For"},
+//	})
+func RealIP(cfg *ProxyConfig) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			var ip netip.Addr
+			if cfg != nil {
+				ip = cfg.ClientIP(r)
+			} else if chainCfg, ok := proxyConfig(ctx, r); ok {
+				ip = chainCfg.ClientIP(r)
+			} else {
+				ip = remoteIP(r)
+			}
+			next.ServeHTTPContext(context.WithValue(ctx, clientIPKey{}, ip), w, r)
+		})
+	}
+}
+
+// resolveClientIP returns the client address of r,
+// using the first of headers present if r comes from a trusted proxy.
+func resolveClientIP(r *http.Request, proxies []netip.Prefix, headers []string) netip.Addr {
+	ip := remoteIP(r)
+	if !ip.IsValid() || !trusted(ip, proxies) {
+		return ip
+	}
+
+	for _, header := range headers {
+		hops := forwardedHops(r.Header, header)
+		if len(hops) == 0 {
+			continue
+		}
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, err := netip.ParseAddr(hops[i])
+			if err != nil {
+				break
+			}
+			ip = hop.Unmap()
+			if !trusted(ip, proxies) {
+				break
+			}
+		}
+		return ip
+	}
+	return ip
+}
+
+// forwardedHops returns the addresses listed in a forwarding header,
+// closest to the client first.
+func forwardedHops(h http.Header, header string) []string {
+	values := h.Values(header)
+	if len(values) == 0 {
+		return nil
+	}
+	var hops []string
+	switch http.CanonicalHeaderKey(header) {
+	case "Forwarded":
+		// Forwarded: for=192.0.2.43, for="[2001:db8::1]:4711";proto=https
+		for _, element := range strings.Split(strings.Join(values, ","), ",") {
+			for _, pair := range strings.Split(element, ";") {
+				k, v, _ := strings.Cut(strings.TrimSpace(pair), "=")
+				if strings.EqualFold(k, "for") {
+					hops = append(hops, forwardedNode(v))
+				}
+			}
+		}
+	case "X-Forwarded-For":
+		for _, hop := range strings.Split(strings.Join(values, ","), ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	default:
+		hops = []string{strings.TrimSpace(values[0])}
+	}
+	return hops
+}
+
+// forwardedNode strips quotes, brackets and the port
+// from a node of the Forwarded header.
+func forwardedNode(v string) string {
+	v = strings.Trim(v, `"`)
+	if host, _, err := net.SplitHostPort(v); err == nil {
+		return host
+	}
+	return strings.Trim(v, "[]")
+}
+
+// IPFilterOptions configure how IPFilter resolves client addresses.
+type IPFilterOptions struct {
+	// TrustedProxies are the networks of proxies whose Header is believed.
+	// Without any, the address resolved by an earlier RealIP is used,
+	// falling back to the ProxyConfig of the chain or Server and then
+	// to the address of the connected peer.
+	TrustedProxies []netip.Prefix
+	// Header carries the client address set by trusted proxies,
+	// "X-Forwarded-For" (the default), "Forwarded" or "X-Real-IP".
+	Header string
+}
+
+// IPFilter returns a Constructor admitting clients by address.
+// Clients in deny are rejected; if allow is not empty, only clients in it
+// are admitted. Rejected clients get 403 Forbidden.
+// The resolved client address is stored in the context (see ClientIP).
+//
+//	alice.New(alice.IPFilter(
+//	    []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+//	    nil,
+//	    alice.IPFilterOptions{TrustedProxies: lbNetworks},
+//	))
+func IPFilter(allow, deny []netip.Prefix, opts IPFilterOptions) Constructor {
+	headers := []string{opts.Header}
+	if opts.Header == "" {
+		headers = []string{"X-Forwarded-For"}
+	}
+
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(ctx)
+			if cfg, ok := proxyConfig(ctx, r); ok && !ip.IsValid() && len(opts.TrustedProxies) == 0 {
+				ip = cfg.ClientIP(r)
+			} else if !ip.IsValid() || len(opts.TrustedProxies) > 0 {
+				ip = resolveClientIP(r, opts.TrustedProxies, headers)
+			}
+			if !ip.IsValid() || trusted(ip, deny) || (len(allow) > 0 && !trusted(ip, allow)) {
+				WriteError(ctx, w, &HTTPError{Status: http.StatusForbidden})
+				return
+			}
+			next.ServeHTTPContext(context.WithValue(ctx, clientIPKey{}, ip), w, r)
+		})
+	}
+}
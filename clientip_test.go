@@ -0,0 +1,104 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var proxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+func TestResolveClientIP(t *testing.T) {
+	tests := []struct {
+		remote, header, value, want string
+	}{
+		{"203.0.113.7:1234", "X-Forwarded-For", "198.51.100.1", "203.0.113.7"},
+		{"10.0.0.1:1234", "X-Forwarded-For", "198.51.100.1", "198.51.100.1"},
+		{"10.0.0.1:1234", "X-Forwarded-For", "1.2.3.4, 198.51.100.1, 10.0.0.2", "198.51.100.1"},
+		{"10.0.0.1:1234", "X-Forwarded-For", "10.0.0.3, 10.0.0.2", "10.0.0.3"},
+		{"10.0.0.1:1234", "X-Forwarded-For", "garbage, 198.51.100.1", "198.51.100.1"},
+		{"10.0.0.1:1234", "X-Forwarded-For", "", "10.0.0.1"},
+		{"10.0.0.1:1234", "X-Real-IP", "198.51.100.1", "198.51.100.1"},
+		{"[::ffff:10.0.0.1]:1234", "X-Real-IP", "198.51.100.1", "198.51.100.1"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tt.remote
+		if tt.value != "" {
+			r.Header.Set(tt.header, tt.value)
+		}
+		assert.Equal(t, resolveClientIP(r, proxies, []string{tt.header}).String(), tt.want, tt.value)
+	}
+}
+
+func TestIPFilter(t *testing.T) {
+	allow := []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")}
+	deny := []netip.Prefix{netip.MustParsePrefix("198.51.100.66/32")}
+	var seen netip.Addr
+	h := IPFilter(allow, deny, IPFilterOptions{TrustedProxies: proxies})(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		seen = ClientIP(ctx)
+	}))
+
+	tests := map[string]int{
+		"198.51.100.1":  http.StatusOK,
+		"198.51.100.66": http.StatusForbidden,
+		"203.0.113.7":   http.StatusForbidden,
+	}
+	for client, code := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("X-Forwarded-For", client)
+		h.ServeHTTPContext(context.Background(), w, r)
+		assert.Equal(t, w.Code, code, client)
+	}
+	assert.Equal(t, seen.String(), "198.51.100.1")
+}
+
+func TestRealIP(t *testing.T) {
+	tests := []struct {
+		headers []string
+		header  map[string]string
+		want    string
+	}{
+		{[]string{"Forwarded"}, map[string]string{"Forwarded": `for=198.51.100.1;proto=https, for="[2001:db8::1]:4711"`}, "2001:db8::1"},
+		{[]string{"Forwarded"}, map[string]string{"Forwarded": "for=198.51.100.1, for=10.0.0.2"}, "198.51.100.1"},
+		{[]string{"Forwarded"}, map[string]string{"Forwarded": "for=unknown"}, "10.0.0.1"},
+		{[]string{"Forwarded", "X-Forwarded-For"}, map[string]string{"Forwarded": "for=198.51.100.1", "X-Forwarded-For": "198.51.100.2"}, "198.51.100.1"},
+		{[]string{"X-Forwarded-For", "X-Real-IP"}, map[string]string{"X-Forwarded-For": "198.51.100.2", "X-Real-IP": "198.51.100.3"}, "198.51.100.2"},
+		{[]string{"X-Real-IP"}, map[string]string{"X-Real-IP": "198.51.100.3"}, "198.51.100.3"},
+		// Headers the proxies do not set are not believed.
+		{[]string{"X-Forwarded-For"}, map[string]string{"Forwarded": "for=203.0.113.66", "X-Forwarded-For": "198.51.100.2"}, "198.51.100.2"},
+		{nil, map[string]string{"X-Forwarded-For": "198.51.100.2"}, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		var ip netip.Addr
+		h := RealIP(&ProxyConfig{TrustedProxies: proxies, Headers: tt.headers})(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			ip = ClientIP(ctx)
+		}))
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		for k, v := range tt.header {
+			r.Header.Set(k, v)
+		}
+		h.ServeHTTPContext(context.Background(), httptest.NewRecorder(), r)
+		assert.Equal(t, ip.String(), tt.want, tt.header)
+	}
+}
+
+func TestIPFilterUsesRealIP(t *testing.T) {
+	allow := []netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")}
+	cfg := &ProxyConfig{TrustedProxies: proxies, Headers: []string{"Forwarded"}}
+	h := New(RealIP(cfg), IPFilter(allow, nil, IPFilterOptions{})).ThenWithContext(context.Background(), writeTag("ok"))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("Forwarded", "for=198.51.100.1")
+	h.ServeHTTP(w, r)
+	assert.Equal(t, w.Code, http.StatusOK)
+}
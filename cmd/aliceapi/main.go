@@ -0,0 +1,402 @@
+// Command aliceapi generates per-operation chains from an OpenAPI 3
+// specification, keeping the spec and the middleware in sync.
+//
+// For every operation, the generated Chains function appends to a base
+// chain the scope check of its security requirement, the content types
+// of its request body (alice.RequireContentType) and, for JSON bodies,
+// the validation of its schema (alice.Validate). Routes registers the
+// handlers bound to the operationIds on an http.ServeMux:
+//
+//	//go:generate aliceapi -o api_gen.go openapi.yaml
+//
+//	api.Routes(ctx, mux, base, api.Handlers{
+//	    ListPets:  alice.ContextHandlerFunc(listPets),
+//	    CreatePet: alice.ContextHandlerFunc(createPet),
+//	}, api.Options{RequireScopes: alice.RequireScopes})
+//
+// Specs are read as YAML or JSON. Only the first security requirement of
+// an operation is enforced, and request bodies must be defined inline
+// (their schemas may use $ref into components).
+// The package name defaults to $GOPACKAGE.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// spec is the part of an OpenAPI document aliceapi reads.
+type spec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components json.RawMessage                       `json:"components"`
+	Security   []map[string][]string                 `json:"security"`
+}
+
+type operation struct {
+	OperationID string                 `json:"operationId"`
+	Security    *[]map[string][]string `json:"security"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema json.RawMessage `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+// op is an operation as the template sees it.
+type op struct {
+	ID, Name, Method, Path string
+	Scopes, ContentTypes   []string
+	Schema                 string
+}
+
+func main() {
+	out := flag.String("o", "", "output file (default stdout)")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package name")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: aliceapi [flags] spec.yaml")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	doc, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "aliceapi:", err)
+		os.Exit(1)
+	}
+	src, err := generate(*pkg, filepath.Base(flag.Arg(0)), doc)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "aliceapi:", err)
+		os.Exit(2)
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "aliceapi:", err)
+		os.Exit(1)
+	}
+}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// generate returns the formatted source of the chains for the spec doc.
+func generate(pkg, name string, doc []byte) ([]byte, error) {
+	if pkg == "" {
+		return nil, errors.New("no package name; set -package or run through go generate")
+	}
+	var raw any
+	if err := yaml.Unmarshal(doc, &raw); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(normalize(raw))
+	if err != nil {
+		return nil, err
+	}
+	var s spec
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+
+	var ops []op
+	names := make(map[string]string)
+	paths := make([]string, 0, len(s.Paths))
+	for p := range s.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		for _, m := range methods {
+			rawOp, ok := s.Paths[p][m]
+			if !ok {
+				continue
+			}
+			var o operation
+			if err := json.Unmarshal(rawOp, &o); err != nil {
+				return nil, fmt.Errorf("%s %s: %v", strings.ToUpper(m), p, err)
+			}
+			if o.OperationID == "" {
+				return nil, fmt.Errorf("%s %s: missing operationId", strings.ToUpper(m), p)
+			}
+			goName := identifier(o.OperationID)
+			if prev, ok := names[goName]; ok {
+				return nil, fmt.Errorf("operationIds %q and %q both map to %s", prev, o.OperationID, goName)
+			}
+			names[goName] = o.OperationID
+
+			security := s.Security
+			if o.Security != nil {
+				security = *o.Security
+			}
+			g := op{ID: o.OperationID, Name: goName, Method: strings.ToUpper(m), Path: p}
+			if len(security) > 0 {
+				for _, scheme := range sortedKeys(security[0]) {
+					g.Scopes = append(g.Scopes, security[0][scheme]...)
+				}
+			}
+			if o.RequestBody != nil {
+				g.ContentTypes = sortedKeys(o.RequestBody.Content)
+				if schema := jsonSchema(o.RequestBody.Content, s.Components); schema != "" {
+					g.Schema = schema
+				}
+			}
+			ops = append(ops, g)
+		}
+	}
+	if len(ops) == 0 {
+		return nil, errors.New("no operations in spec")
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, struct {
+		Package, Source string
+		Ops             []op
+	}{pkg, name, ops})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// jsonSchema returns the schema of a request body accepting only JSON,
+// with the spec's components added so $refs into them resolve.
+func jsonSchema(content map[string]struct {
+	Schema json.RawMessage `json:"schema"`
+}, components json.RawMessage) string {
+	var schema json.RawMessage
+	for ct, c := range content {
+		mt := strings.ToLower(strings.TrimSpace(strings.Split(ct, ";")[0]))
+		if mt != "application/json" && !strings.HasSuffix(mt, "+json") {
+			return ""
+		}
+		if len(c.Schema) == 0 || (schema != nil && !bytes.Equal(schema, c.Schema)) {
+			return ""
+		}
+		schema = c.Schema
+	}
+	if schema == nil {
+		return ""
+	}
+	var m map[string]any
+	if err := json.Unmarshal(schema, &m); err != nil {
+		return ""
+	}
+	if len(components) > 0 {
+		m["components"] = components
+	}
+	b, _ := json.MarshalIndent(m, "", "  ")
+	return string(b)
+}
+
+// normalize turns the map[any]any YAML produces for mappings
+// with non-string keys, such as response codes, into map[string]any.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+		return v
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalize(e)
+		}
+		return m
+	case []any:
+		for i, e := range v {
+			v[i] = normalize(e)
+		}
+	}
+	return v
+}
+
+// identifier turns an operationId such as "list-pets" into "ListPets".
+func identifier(id string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range id {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "Op" + s
+	}
+	return s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var tmpl = template.Must(template.New("").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+	"unexported": func(s string) string {
+		return strings.ToLower(s[:1]) + s[1:]
+	},
+	"strings": func(list []string) string {
+		q := make([]string, len(list))
+		for i, s := range list {
+			q[i] = strconv.Quote(s)
+		}
+		return strings.Join(q, ", ")
+	},
+	"raw": func(s string) string {
+		if strings.Contains(s, "`") {
+			return strconv.Quote(s)
+		}
+		return "`" + s + "`"
+	},
+	"hasScopes": func(ops []op) bool {
+		for _, o := range ops {
+			if len(o.Scopes) > 0 {
+				return true
+			}
+		}
+		return false
+	},
+	"hasSchema": func(ops []op) bool {
+		for _, o := range ops {
+			if o.Schema != "" {
+				return true
+			}
+		}
+		return false
+	},
+}).Parse(`// Code generated by aliceapi from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"net/http"
+
+	"github.com/SimiPro/alice"
+{{- if hasSchema .Ops}}
+	"github.com/SimiPro/alice/jsonschema"
+{{- end}}
+	"golang.org/x/net/context"
+)
+
+// Operation describes an operation of the API.
+type Operation struct {
+	ID           string
+	Method       string
+	Path         string
+	Scopes       []string
+	ContentTypes []string
+}
+
+// Operations lists the operations of the API, keyed by operationId.
+var Operations = map[string]Operation{
+{{- range .Ops}}
+	{{quote .ID}}: {
+		ID:     {{quote .ID}},
+		Method: {{quote .Method}},
+		Path:   {{quote .Path}},
+		{{- if .Scopes}}
+		Scopes: []string{ {{- strings .Scopes -}} },
+		{{- end}}
+		{{- if .ContentTypes}}
+		ContentTypes: []string{ {{- strings .ContentTypes -}} },
+		{{- end}}
+	},
+{{- end}}
+}
+
+// Handlers binds the operations to their handlers.
+type Handlers struct {
+{{- range .Ops}}
+	{{.Name}} alice.ContextHandler
+{{- end}}
+}
+
+// Options configure the generated chains.
+type Options struct {
+	// RequireScopes builds the middleware checking the scopes of
+	// operations requiring some, e.g. alice.RequireScopes.
+{{- if hasScopes .Ops}}
+	// Chains panics if it is nil, rather than serve them unchecked.
+{{- end}}
+	RequireScopes func(scopes ...string) alice.Constructor
+}
+{{range .Ops}}{{if .Schema}}
+var {{unexported .Name}}Schema = jsonschema.MustCompile({{raw .Schema}})
+{{end}}{{end}}
+// Chains returns the chain of every operation, keyed by operationId:
+// base followed by the scope check, content type check and body
+// validation the spec asks for.
+func Chains(base alice.Chain, opts Options) map[string]alice.Chain {
+{{- if hasScopes .Ops}}
+	if opts.RequireScopes == nil {
+		panic("Options.RequireScopes is nil, but operations require scopes")
+	}
+{{- end}}
+	chains := make(map[string]alice.Chain, len(Operations))
+	var c alice.Chain
+{{- range .Ops}}
+
+	c = base
+	{{- if .Scopes}}
+	c = c.Append(opts.RequireScopes({{strings .Scopes}}))
+	{{- end}}
+	{{- if .ContentTypes}}
+	c = c.Append(alice.RequireContentType({{strings .ContentTypes}}))
+	{{- end}}
+	{{- if .Schema}}
+	c = c.Append(alice.Validate({{unexported .Name}}Schema))
+	{{- end}}
+	chains[{{quote .ID}}] = c
+{{- end}}
+	return chains
+}
+
+// Routes registers the handler of every operation on mux, wrapped in its
+// chain and served with ctx, under patterns such as "GET /pets/{id}".
+// Requests carry their operationId and pattern as alice.RouteInfo.
+// It panics if a handler is missing
+{{- if hasScopes .Ops}} or opts.RequireScopes is nil{{end}}.
+func Routes(ctx context.Context, mux *http.ServeMux, base alice.Chain, h Handlers, opts Options) {
+	chains := Chains(base, opts)
+	route := func(id string, handler alice.ContextHandler) {
+		if handler == nil {
+			panic("missing handler for operation " + id)
+		}
+		op := Operations[id]
+		pattern := op.Method + " " + op.Path
+		mux.Handle(pattern, chains[id].ThenWith(ctx, handler, alice.Route(alice.RouteInfo{Name: id, Pattern: pattern})))
+	}
+{{- range .Ops}}
+	route({{quote .ID}}, h.{{.Name}})
+{{- end}}
+}
+`))
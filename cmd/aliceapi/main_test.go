@@ -0,0 +1,78 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	doc, err := os.ReadFile("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate("api", "petstore.yaml", doc)
+	assert.Nil(t, err)
+
+	out := string(src)
+	assert.Contains(t, out, "// Code generated by aliceapi from petstore.yaml. DO NOT EDIT.")
+	assert.Contains(t, out, "package api")
+	assert.Contains(t, out, "\tCreatePet alice.ContextHandler\n")
+	assert.Contains(t, out, `var createPetSchema = jsonschema.MustCompile(`)
+	assert.Contains(t, out, `"$ref": "#/components/schemas/Pet"`)
+	assert.Contains(t, out, `panic("Options.RequireScopes is nil, but operations require scopes")`)
+	assert.NotContains(t, out, `if opts.RequireScopes != nil`)
+	assert.Contains(t, out, `c = c.Append(opts.RequireScopes("pets:write"))`)
+	assert.Contains(t, out, `c = c.Append(opts.RequireScopes("pets:read"))`)
+	assert.Contains(t, out, `c = c.Append(alice.RequireContentType("application/json"))`)
+	assert.Contains(t, out, `route("deletePet", h.DeletePet)`)
+	assert.Contains(t, out, `Path:   "/pets/{petId}",`)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, err := generate("", "spec.yaml", []byte("paths: {}"))
+	assert.NotNil(t, err)
+	_, err = generate("api", "spec.yaml", []byte("paths: {}"))
+	assert.EqualError(t, err, "no operations in spec")
+	_, err = generate("api", "spec.yaml", []byte("paths:\n  /a:\n    get: {}\n"))
+	assert.EqualError(t, err, "GET /a: missing operationId")
+	_, err = generate("api", "spec.yaml", []byte("paths:\n  /a:\n    get: {operationId: a-b}\n    put: {operationId: aB}\n"))
+	assert.NotNil(t, err)
+}
+
+func TestIdentifier(t *testing.T) {
+	assert.Equal(t, identifier("list-pets"), "ListPets")
+	assert.Equal(t, identifier("get_pet.byId"), "GetPetById")
+	assert.Equal(t, identifier("2fa"), "Op2fa")
+}
+
+func TestGeneratedCodeCompiles(t *testing.T) {
+	doc, err := os.ReadFile("testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate("api", "petstore.yaml", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The source importer resolves the imports from the file's directory.
+	filename, err := filepath.Abs("api_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("api", fset, []*ast.File{f}, nil)
+	assert.Nil(t, err)
+}
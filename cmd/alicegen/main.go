@@ -0,0 +1,139 @@
+// Command alicegen generates typed context accessors,
+// replacing hand-written context plumbing in middleware.
+//
+// Each declaration "Name Type" yields an unexported key type and
+//
+//	func WithName(ctx context.Context, v Type) context.Context
+//	func NameFrom(ctx context.Context) (Type, bool)
+//
+// It is meant to be run by go generate:
+//
+//	//go:generate alicegen -o context_gen.go "UserID string" "Roles []string"
+//
+// Packages used by the types are imported with -import, e.g.
+// -import time for "Expiry time.Time".
+// The package name defaults to $GOPACKAGE.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// decl is a context value to generate accessors for.
+type decl struct {
+	Name, Type string
+}
+
+func (d decl) Key() string {
+	r, n := utf8.DecodeRuneInString(d.Name)
+	return string(unicode.ToLower(r)) + d.Name[n:] + "Key"
+}
+
+type imports []string
+
+func (i *imports) String() string     { return strings.Join(*i, ",") }
+func (i *imports) Set(s string) error { *i = append(*i, s); return nil }
+
+func main() {
+	var imps imports
+	out := flag.String("o", "", "output file (default stdout)")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package name")
+	flag.Var(&imps, "import", "import path needed by the types (repeatable)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, `usage: alicegen [flags] "Name Type"...`)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	src, err := generate(*pkg, imps, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "alicegen:", err)
+		os.Exit(2)
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "alicegen:", err)
+		os.Exit(1)
+	}
+}
+
+// generate returns the formatted source of the accessors for args.
+func generate(pkg string, imps []string, args []string) ([]byte, error) {
+	if pkg == "" {
+		return nil, errors.New("no package name; set -package or run through go generate")
+	}
+	if len(args) == 0 {
+		return nil, errors.New(`no declarations; expected "Name Type"`)
+	}
+	decls := make([]decl, len(args))
+	seen := make(map[string]bool)
+	for i, arg := range args {
+		name, typ, ok := strings.Cut(strings.TrimSpace(arg), " ")
+		typ = strings.TrimSpace(typ)
+		if !ok || typ == "" {
+			return nil, fmt.Errorf("%q: expected \"Name Type\"", arg)
+		}
+		if !token.IsIdentifier(name) || !token.IsExported(name) {
+			return nil, fmt.Errorf("%q: %s is not an exported identifier", arg, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s declared twice", name)
+		}
+		seen[name] = true
+		decls[i] = decl{name, typ}
+	}
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, struct {
+		Package string
+		Imports []string
+		Decls   []decl
+	}{pkg, imps, decls})
+	if err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid type: %v", err)
+	}
+	return src, nil
+}
+
+var tmpl = template.Must(template.New("").Parse(`// Code generated by alicegen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+{{if .Imports}}
+{{end}}	"golang.org/x/net/context"
+)
+{{range .Decls}}
+type {{.Key}} struct{}
+
+// With{{.Name}} returns a context carrying the {{.Name}} v.
+func With{{.Name}}(ctx context.Context, v {{.Type}}) context.Context {
+	return context.WithValue(ctx, {{.Key}}{}, v)
+}
+
+// {{.Name}}From returns the {{.Name}} stored in ctx.
+func {{.Name}}From(ctx context.Context) ({{.Type}}, bool) {
+	v, ok := ctx.Value({{.Key}}{}).({{.Type}})
+	return v, ok
+}
+{{end}}`))
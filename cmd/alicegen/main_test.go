@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	src, err := generate("auth", []string{"time"}, []string{"UserID string", "Expiry time.Time"})
+	assert.Nil(t, err)
+
+	out := string(src)
+	assert.Contains(t, out, "// Code generated by alicegen. DO NOT EDIT.")
+	assert.Contains(t, out, "package auth")
+	assert.Contains(t, out, "import (\n\t\"time\"\n\n\t\"golang.org/x/net/context\"\n)")
+	assert.Contains(t, out, "type userIDKey struct{}")
+	assert.Contains(t, out, "func WithUserID(ctx context.Context, v string) context.Context {")
+	assert.Contains(t, out, "func ExpiryFrom(ctx context.Context) (time.Time, bool) {")
+}
+
+func TestGenerateRejectsInvalidDeclarations(t *testing.T) {
+	for _, arg := range []string{"UserID", "userID string", "UserID [", "User-ID string"} {
+		_, err := generate("auth", nil, []string{arg})
+		assert.NotNil(t, err, arg)
+	}
+	_, err := generate("auth", nil, []string{"A int", "A string"})
+	assert.NotNil(t, err)
+	_, err = generate("", nil, []string{"A int"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,48 @@
+package alice
+
+import (
+	"mime"
+	"strings"
+	"sync"
+)
+
+// A Codec encodes and decodes values in a wire format,
+// such as protocol buffers or MessagePack.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var codecs struct {
+	sync.RWMutex
+	m map[string]Codec
+}
+
+// RegisterCodec makes Bind decode request bodies of the given media type,
+// and render.Respond encode responses negotiated to it, with c:
+//
+//	alice.RegisterCodec("application/x-protobuf", protobuf.Codec{})
+//	alice.RegisterCodec("application/msgpack", msgpack.Codec{})
+//
+// Codecs are usually registered during program initialization.
+func RegisterCodec(mediaType string, c Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	if codecs.m == nil {
+		codecs.m = make(map[string]Codec)
+	}
+	codecs.m[strings.ToLower(mediaType)] = c
+}
+
+// CodecFor returns the Codec registered for the media type
+// of contentType, ignoring its parameters.
+func CodecFor(contentType string) (Codec, bool) {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, ok := codecs.m[mt]
+	return c, ok
+}
@@ -0,0 +1,36 @@
+package alice
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// upperCodec decodes bodies into strings, upper-cased.
+type upperCodec struct{}
+
+func (upperCodec) Marshal(v any) ([]byte, error) {
+	return []byte(strings.ToUpper(v.(string))), nil
+}
+
+func (upperCodec) Unmarshal(data []byte, v any) error {
+	*v.(*string) = strings.ToUpper(string(data))
+	return nil
+}
+
+func TestBindUsesRegisteredCodec(t *testing.T) {
+	RegisterCodec("application/x-upper", upperCodec{})
+
+	c, ok := CodecFor("Application/X-Upper; version=1")
+	assert.True(t, ok)
+	assert.Equal(t, c, Codec(upperCodec{}))
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader("hello"))
+	r.Header.Set("Content-Type", "application/x-upper")
+	s, err := Bind[string](context.Background(), r)
+	assert.Nil(t, err)
+	assert.Equal(t, s, "HELLO")
+}
@@ -0,0 +1,236 @@
+package alice
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// An Encoding compresses response bodies with one content coding.
+type Encoding interface {
+	// Name returns the content coding, e.g. "gzip".
+	Name() string
+	// NewWriter returns a writer compressing into w.
+	// Closing it flushes the compressed stream and releases the writer.
+	NewWriter(w io.Writer) io.WriteCloser
+}
+
+// ResettableWriter is a compressing writer that can be reused
+// for another destination, as provided by most compression libraries.
+type ResettableWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+type pooledEncoding struct {
+	name string
+	pool sync.Pool
+}
+
+// NewEncoding returns an Encoding named name whose writers,
+// created by newWriter, are pooled and reused across responses.
+func NewEncoding(name string, newWriter func() ResettableWriter) Encoding {
+	e := &pooledEncoding{name: name}
+	e.pool.New = func() any {
+		return newWriter()
+	}
+	return e
+}
+
+func (e *pooledEncoding) Name() string {
+	return e.name
+}
+
+func (e *pooledEncoding) NewWriter(w io.Writer) io.WriteCloser {
+	rw := e.pool.Get().(ResettableWriter)
+	rw.Reset(w)
+	return &pooledWriter{ResettableWriter: rw, pool: &e.pool}
+}
+
+// pooledWriter returns its writer to the pool when closed.
+type pooledWriter struct {
+	ResettableWriter
+	pool *sync.Pool
+}
+
+func (w *pooledWriter) Close() error {
+	err := w.ResettableWriter.Close()
+	w.ResettableWriter.Reset(nil)
+	w.pool.Put(w.ResettableWriter)
+	return err
+}
+
+// Gzip returns the gzip Encoding with the given compression level,
+// such as gzip.DefaultCompression. It panics if level is invalid.
+func Gzip(level int) Encoding {
+	if _, err := gzip.NewWriterLevel(nil, level); err != nil {
+		panic(err)
+	}
+	return NewEncoding("gzip", func() ResettableWriter {
+		w, _ := gzip.NewWriterLevel(nil, level)
+		return w
+	})
+}
+
+// Compress returns a Constructor compressing response bodies with
+// the first of encodings, in order of preference, that the client accepts.
+// With no encodings, it uses Gzip(gzip.DefaultCompression).
+//
+// Responses that already have a Content-Encoding, have no body,
+// are partial (see Ranges) or whose Content-Type is already compressed
+// (images, video, archives) are left as they are.
+func Compress(encodings ...Encoding) Constructor {
+	if len(encodings) == 0 {
+		encodings = []Encoding{Gzip(gzip.DefaultCompression)}
+	}
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			enc := chooseEncoding(r.Header.Get("Accept-Encoding"), encodings)
+			if enc == nil || r.Method == "HEAD" {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, enc: enc}
+			defer cw.close()
+			next.ServeHTTPContext(ctx, cw, r)
+		})
+	}
+}
+
+// chooseEncoding returns the first encoding accepted by header, or nil.
+func chooseEncoding(header string, encodings []Encoding) Encoding {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "q") {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		accepted[name] = q
+	}
+
+	for _, enc := range encodings {
+		q, ok := accepted[enc.Name()]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > 0 {
+			return enc
+		}
+	}
+	return nil
+}
+
+// compressWriter decides on the first write whether to compress the response.
+type compressWriter struct {
+	http.ResponseWriter
+	enc     Encoding
+	w       io.WriteCloser
+	decided bool
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if !cw.decided {
+		cw.decide(code)
+	}
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.decided {
+		// Sniff now: net/http would otherwise sniff the compressed bytes.
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.w != nil {
+		return cw.w.Write(b)
+	}
+	return cw.ResponseWriter.Write(b)
+}
+
+func (cw *compressWriter) decide(code int) {
+	cw.decided = true
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" || !bodyAllowed(code) || code == http.StatusPartialContent ||
+		!compressible(h.Get("Content-Type")) {
+		return
+	}
+	h.Set("Content-Encoding", cw.enc.Name())
+	h.Del("Content-Length")
+	cw.w = cw.enc.NewWriter(cw.ResponseWriter)
+}
+
+// Flush flushes buffered compressed data to the client.
+func (cw *compressWriter) Flush() {
+	if f, ok := cw.w.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Hijack takes over the connection, leaving the response uncompressed.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(cw.ResponseWriter).Hijack()
+	if err == nil {
+		cw.decided = true
+	}
+	return conn, brw, err
+}
+
+func (cw *compressWriter) close() {
+	if cw.w != nil {
+		cw.w.Close()
+	}
+}
+
+func bodyAllowed(code int) bool {
+	return code >= 200 && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// compressible reports whether a body of the given Content-Type
+// is worth compressing.
+func compressible(contentType string) bool {
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.HasPrefix(ct, "image/svg"):
+		return true
+	case strings.HasPrefix(ct, "image/"),
+		strings.HasPrefix(ct, "video/"),
+		strings.HasPrefix(ct, "audio/"),
+		strings.HasPrefix(ct, "application/zip"),
+		strings.HasPrefix(ct, "application/gzip"),
+		strings.HasPrefix(ct, "application/x-gzip"),
+		strings.HasPrefix(ct, "application/zstd"),
+		strings.HasPrefix(ct, "font/woff"):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+// Package compress provides Brotli and Zstandard encodings
+// for alice.Compress.
+//
+//	alice.New(alice.Compress(
+//	    compress.Zstd(3),
+//	    compress.Brotli(5),
+//	    alice.Gzip(gzip.DefaultCompression),
+//	))
+package compress
+
+import (
+	"github.com/SimiPro/alice"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Brotli returns the "br" Encoding with the given quality level (0-11).
+func Brotli(level int) alice.Encoding {
+	return alice.NewEncoding("br", func() alice.ResettableWriter {
+		return brotli.NewWriterLevel(nil, level)
+	})
+}
+
+// Zstd returns the "zstd" Encoding with the given zstd compression level,
+// mapped to the closest level the encoder supports.
+func Zstd(level int) alice.Encoding {
+	return alice.NewEncoding("zstd", func() alice.ResettableWriter {
+		// Options are static, so NewWriter cannot fail.
+		w, _ := zstd.NewWriter(nil,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+			zstd.WithEncoderConcurrency(1),
+		)
+		return w
+	})
+}
@@ -0,0 +1,53 @@
+package compress
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var text = strings.Repeat("all work and no play makes jack a dull boy\n", 100)
+
+func serve(acceptEncoding string) *httptest.ResponseRecorder {
+	h := alice.Compress(Zstd(3), Brotli(5))(alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(text))
+	}))
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", acceptEncoding)
+	h.ServeHTTPContext(context.Background(), w, r)
+	return w
+}
+
+func TestBrotli(t *testing.T) {
+	w := serve("gzip, br")
+	assert.Equal(t, w.Header().Get("Content-Encoding"), "br")
+
+	body, err := io.ReadAll(brotli.NewReader(w.Body))
+	assert.Nil(t, err)
+	assert.Equal(t, string(body), text)
+}
+
+func TestZstd(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		w := serve("br, zstd")
+		assert.Equal(t, w.Header().Get("Content-Encoding"), "zstd")
+
+		d, err := zstd.NewReader(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(d)
+		d.Close()
+		assert.Nil(t, err)
+		assert.Equal(t, string(body), text)
+	}
+}
@@ -0,0 +1,89 @@
+package alice
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var longText = strings.Repeat("all work and no play makes jack a dull boy\n", 100)
+
+func serveCompressed(t *testing.T, c Constructor, acceptEncoding string, h ContextHandler) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept-Encoding", acceptEncoding)
+	c(h).ServeHTTPContext(context.Background(), w, r)
+	return w
+}
+
+func TestCompressGzip(t *testing.T) {
+	w := serveCompressed(t, Compress(), "br, gzip", writeTag(longText))
+
+	assert.Equal(t, w.Header().Get("Content-Encoding"), "gzip")
+	assert.Equal(t, w.Header().Get("Vary"), "Accept-Encoding")
+	assert.Equal(t, w.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(gz)
+	assert.Nil(t, err)
+	assert.Equal(t, string(body), longText)
+}
+
+func TestCompressReusesPooledWriters(t *testing.T) {
+	c := Compress(Gzip(gzip.BestSpeed))
+	for i := 0; i < 3; i++ {
+		w := serveCompressed(t, c, "gzip", writeTag(longText))
+		gz, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(gz)
+		assert.Equal(t, string(body), longText)
+	}
+}
+
+func TestCompressSkips(t *testing.T) {
+	tests := map[string]struct {
+		acceptEncoding string
+		h              ContextHandler
+	}{
+		"not accepted": {"br", writeTag(longText)},
+		"refused":      {"gzip;q=0, *", writeTag(longText)},
+		"no content": {"gzip", ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})},
+		"image": {"gzip", ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(longText))
+		})},
+		"already encoded": {"gzip", ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			w.Write([]byte(longText))
+		})},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := serveCompressed(t, Compress(), tt.acceptEncoding, tt.h)
+			assert.NotEqual(t, w.Header().Get("Content-Encoding"), "gzip")
+		})
+	}
+}
+
+func TestChooseEncodingPrefersServerOrder(t *testing.T) {
+	a := NewEncoding("a", nil)
+	b := NewEncoding("b", nil)
+
+	assert.Equal(t, chooseEncoding("b, a", []Encoding{a, b}), a)
+	assert.Equal(t, chooseEncoding("a;q=0, b", []Encoding{a, b}), b)
+	assert.Equal(t, chooseEncoding("*", []Encoding{a, b}), a)
+	assert.Nil(t, chooseEncoding("", []Encoding{a, b}))
+}
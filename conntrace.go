@@ -0,0 +1,236 @@
+package alice
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConnTiming describes how an outbound request got its connection.
+type ConnTiming struct {
+	// Host is the host and port the request was sent to.
+	Host string
+	// Wait is the time from asking the transport for a connection
+	// to getting one, including DNS, Connect and TLS for new ones.
+	Wait time.Duration
+	// DNS, Connect and TLS are the time spent resolving the host,
+	// dialing and in the TLS handshake, zero for reused connections.
+	DNS, Connect, TLS time.Duration
+	// Reused reports whether the connection was reused,
+	// and WasIdle whether it came from the idle pool.
+	Reused, WasIdle bool
+}
+
+// ConnTraceOptions configure TraceConns.
+type ConnTraceOptions struct {
+	// Pool, if set, collects per-host connection statistics.
+	Pool *ConnPool
+	// Observe, if set, is called with the ConnTiming of every request
+	// that got a connection.
+	Observe func(req *http.Request, t ConnTiming)
+}
+
+// TraceConns returns a TransportConstructor tracing how outbound
+// requests get their connections with an httptrace.ClientTrace bound
+// to the request context, alongside traces already there.
+// The durations are added to the Stats of the request context (see
+// CollectStats), as "upstream_wait", "upstream_dns", "upstream_connect"
+// and "upstream_tls", so prom.Metrics exports them. Requests made with
+// TransportChain.Client carry the handler's context, and so its Stats:
+//
+//	pool := alice.NewConnPool()
+//	transport := alice.NewTransport(alice.TraceConns(alice.ConnTraceOptions{Pool: pool}))
+//	prom.RegisterConnPool(reg, pool, promOpts)
+func TraceConns(opts ConnTraceOptions) TransportConstructor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			ct := &connTrace{timing: ConnTiming{Host: canonicalAddr(req)}}
+			req = req.WithContext(httptrace.WithClientTrace(ctx, ct.clientTrace()))
+
+			done := opts.Pool.start(ct.timing.Host)
+			resp, err := next.RoundTrip(req)
+			if timing, ok := ct.result(); ok {
+				if stats := StatsFrom(ctx); stats != nil {
+					stats.Add("upstream_wait", timing.Wait)
+					stats.Add("upstream_dns", timing.DNS)
+					stats.Add("upstream_connect", timing.Connect)
+					stats.Add("upstream_tls", timing.TLS)
+				}
+				opts.Pool.got(timing)
+				if opts.Observe != nil {
+					opts.Observe(req, timing)
+				}
+			}
+			if err != nil {
+				done()
+				return nil, err
+			}
+			resp.Body = &doneBody{ReadCloser: resp.Body, done: done}
+			return resp, nil
+		})
+	}
+}
+
+// connTrace collects the ConnTiming of a request.
+// The transport may call its hooks from several goroutines.
+type connTrace struct {
+	mu                                     sync.Mutex
+	timing                                 ConnTiming
+	got                                    bool
+	getConn, dnsStart, connStart, tlsStart time.Time
+}
+
+func (ct *connTrace) clientTrace() *httptrace.ClientTrace {
+	since := func(start *time.Time, d *time.Duration) {
+		ct.mu.Lock()
+		defer ct.mu.Unlock()
+		if !start.IsZero() {
+			*d += time.Since(*start)
+		}
+	}
+	begin := func(start *time.Time) {
+		ct.mu.Lock()
+		defer ct.mu.Unlock()
+		*start = time.Now()
+	}
+	return &httptrace.ClientTrace{
+		GetConn:  func(string) { begin(&ct.getConn) },
+		DNSStart: func(httptrace.DNSStartInfo) { begin(&ct.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { since(&ct.dnsStart, &ct.timing.DNS) },
+		ConnectStart: func(string, string) {
+			ct.mu.Lock()
+			defer ct.mu.Unlock()
+			// Dials to several addresses may race; time from the first.
+			if ct.connStart.IsZero() {
+				ct.connStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				since(&ct.connStart, &ct.timing.Connect)
+			}
+		},
+		TLSHandshakeStart: func() { begin(&ct.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { since(&ct.tlsStart, &ct.timing.TLS) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			since(&ct.getConn, &ct.timing.Wait)
+			ct.mu.Lock()
+			defer ct.mu.Unlock()
+			ct.got = true
+			ct.timing.Reused, ct.timing.WasIdle = info.Reused, info.WasIdle
+		},
+	}
+}
+
+// result returns the ConnTiming, if the request got a connection.
+func (ct *connTrace) result() (ConnTiming, bool) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return ct.timing, ct.got
+}
+
+// canonicalAddr returns the host and port req is sent to.
+func canonicalAddr(req *http.Request) string {
+	host, port := req.URL.Hostname(), req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// doneBody calls done once when closed.
+type doneBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *doneBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+// ConnPool collects per-host statistics of outbound connections
+// traced by TraceConns. It is safe for concurrent use.
+type ConnPool struct {
+	mu    sync.Mutex
+	hosts map[string]*HostConnStats
+}
+
+// HostConnStats are the connection statistics of one host.
+type HostConnStats struct {
+	// Host is the host and port.
+	Host string
+	// InFlight is the number of requests sent whose response body
+	// is not closed yet.
+	InFlight int
+	// Opened and Reused count the requests sent over new
+	// and reused connections.
+	Opened, Reused int64
+}
+
+// NewConnPool returns an empty ConnPool.
+func NewConnPool() *ConnPool {
+	return &ConnPool{hosts: make(map[string]*HostConnStats)}
+}
+
+// Stats returns the statistics of every host seen, sorted by host.
+func (p *ConnPool) Stats() []HostConnStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]HostConnStats, 0, len(p.hosts))
+	for _, h := range p.hosts {
+		out = append(out, *h)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
+
+func (p *ConnPool) host(host string) *HostConnStats {
+	h, ok := p.hosts[host]
+	if !ok {
+		h = &HostConnStats{Host: host}
+		p.hosts[host] = h
+	}
+	return h
+}
+
+// start counts a request in flight to host,
+// returning the function ending it. A nil pool counts nothing.
+func (p *ConnPool) start(host string) func() {
+	if p == nil {
+		return func() {}
+	}
+	p.mu.Lock()
+	p.host(host).InFlight++
+	p.mu.Unlock()
+	return func() {
+		p.mu.Lock()
+		p.host(host).InFlight--
+		p.mu.Unlock()
+	}
+}
+
+// got counts the connection a request got.
+func (p *ConnPool) got(t ConnTiming) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t.Reused {
+		p.host(t.Host).Reused++
+	} else {
+		p.host(t.Host).Opened++
+	}
+}
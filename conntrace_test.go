@@ -0,0 +1,98 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestTraceConnsRecordsTimings(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	var timings []ConnTiming
+	rt := NewTransport(TraceConns(ConnTraceOptions{
+		Observe: func(req *http.Request, t ConnTiming) {
+			timings = append(timings, t)
+		},
+	})).Then(srv.Client().Transport)
+
+	stats := &Stats{}
+	ctx := context.WithValue(context.Background(), statsKey{}, stats)
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+		resp, err := rt.RoundTrip(req)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}
+
+	if assert.Len(t, timings, 2) {
+		assert.Equal(t, timings[0].Host, strings.TrimPrefix(srv.URL, "https://"))
+		assert.False(t, timings[0].Reused)
+		assert.True(t, timings[0].Connect > 0)
+		assert.True(t, timings[0].TLS > 0)
+		assert.True(t, timings[1].Reused)
+		assert.True(t, timings[1].WasIdle)
+		assert.Zero(t, timings[1].Connect)
+		assert.Zero(t, timings[1].TLS)
+	}
+	durations := stats.Durations()
+	for _, name := range []string{"upstream_wait", "upstream_dns", "upstream_connect", "upstream_tls"} {
+		assert.Contains(t, durations, name)
+	}
+	assert.True(t, durations["upstream_tls"] > 0)
+}
+
+func TestTraceConnsKeepsContextTrace(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	rt := NewTransport(TraceConns(ConnTraceOptions{})).Then(nil)
+
+	gotConn := false
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) { gotConn = true },
+	})
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	resp, err := rt.RoundTrip(req)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+	assert.True(t, gotConn)
+}
+
+func TestConnPoolCountsRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	pool := NewConnPool()
+	rt := NewTransport(TraceConns(ConnTraceOptions{Pool: pool})).Then(nil)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	resp, err := rt.RoundTrip(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, pool.Stats(), []HostConnStats{{Host: host, InFlight: 1, Opened: 1}})
+	resp.Body.Close()
+	resp.Body.Close()
+	assert.Equal(t, pool.Stats(), []HostConnStats{{Host: host, Opened: 1}})
+}
+
+func TestCanonicalAddr(t *testing.T) {
+	for url, addr := range map[string]string{
+		"http://example.com/":       "example.com:80",
+		"https://example.com/":      "example.com:443",
+		"http://example.com:8080/":  "example.com:8080",
+		"https://[::1]:8443/health": "[::1]:8443",
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		assert.Equal(t, canonicalAddr(req), addr)
+	}
+}
@@ -0,0 +1,74 @@
+package alice
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// RequireContentType returns a Constructor responding
+// 415 Unsupported Media Type to requests whose Content-Type
+// matches none of types, e.g. "application/json" or "text/*".
+// Parameters of the request type are ignored, except a charset
+// when the allowed type names one, as in "text/plain; charset=utf-8".
+// GET, HEAD, OPTIONS, TRACE and DELETE requests and requests
+// without a body are exempt.
+func RequireContentType(types ...string) Constructor {
+	allowed := make([]mediaType, len(types))
+	for i, t := range types {
+		mt, params, err := mime.ParseMediaType(t)
+		if err != nil {
+			panic("alice: invalid content type " + t)
+		}
+		allowed[i] = mediaType{mt, strings.ToLower(params["charset"])}
+	}
+	accept := strings.Join(types, ", ")
+
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if bodyless(r) || matchContentType(r.Header.Get("Content-Type"), allowed) {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			// Tell clients which types would be accepted (RFC 9110, 15.5.16).
+			w.Header().Set("Accept-Post", accept)
+			WriteError(ctx, w, &HTTPError{
+				Status: http.StatusUnsupportedMediaType,
+				Code:   "unsupported_media_type",
+				Detail: "Content-Type must be one of " + accept,
+			})
+		})
+	}
+}
+
+type mediaType struct {
+	typ, charset string
+}
+
+func bodyless(r *http.Request) bool {
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS", "TRACE", "DELETE":
+		return true
+	}
+	return r.ContentLength == 0 && (r.Body == nil || r.Body == http.NoBody)
+}
+
+func matchContentType(header string, allowed []mediaType) bool {
+	mt, params, err := mime.ParseMediaType(header)
+	if err != nil {
+		return false
+	}
+	charset := strings.ToLower(params["charset"])
+	for _, a := range allowed {
+		if a.charset != "" && a.charset != charset {
+			continue
+		}
+		if a.typ == mt || a.typ == "*/*" ||
+			strings.HasSuffix(a.typ, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(a.typ, "*")) {
+			return true
+		}
+	}
+	return false
+}
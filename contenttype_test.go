@@ -0,0 +1,50 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRequireContentType(t *testing.T) {
+	h := New(RequireContentType("application/json", "text/*", "application/xml; charset=utf-8")).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {}))
+
+	cases := []struct {
+		method, contentType, body string
+		status                    int
+	}{
+		{"POST", "application/json", "{}", http.StatusOK},
+		{"POST", "Application/JSON; charset=utf-8", "{}", http.StatusOK},
+		{"PUT", "text/csv", "a,b", http.StatusOK},
+		{"POST", "application/xml; charset=UTF-8", "<a/>", http.StatusOK},
+		{"POST", "application/xml; charset=latin1", "<a/>", http.StatusUnsupportedMediaType},
+		{"POST", "application/xml", "<a/>", http.StatusUnsupportedMediaType},
+		{"POST", "application/x-www-form-urlencoded", "a=b", http.StatusUnsupportedMediaType},
+		{"POST", "", "{}", http.StatusUnsupportedMediaType},
+		{"POST", "", "", http.StatusOK},
+		{"GET", "image/png", "", http.StatusOK},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(c.method, "/", strings.NewReader(c.body))
+		if c.body == "" {
+			r.Body = http.NoBody
+		}
+		if c.contentType != "" {
+			r.Header.Set("Content-Type", c.contentType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, w.Code, c.status, c.method+" "+c.contentType)
+	}
+}
+
+func TestRequireContentTypeAdvertisesTypes(t *testing.T) {
+	h := New(RequireContentType("application/json")).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("a")))
+	assert.Equal(t, w.Header().Get("Accept-Post"), "application/json")
+}
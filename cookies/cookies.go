@@ -0,0 +1,292 @@
+// Package cookies signs, and optionally encrypts, cookies for alice
+// chains, loads them into the context for typed access and keeps
+// flash messages in them.
+package cookies
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// ErrInvalid is returned for cookies that were tampered with, signed
+// with an unknown key or older than Codec.MaxAge.
+var ErrInvalid = errors.New("cookies: invalid cookie")
+
+// MinKeySize is the length of the shortest key of a Codec.
+const MinKeySize = 32
+
+// Codec signs cookie values with HMAC-SHA256 or, with Encrypt,
+// encrypts them with AES-256-GCM. Values are bound to the cookie name,
+// so one cookie cannot be passed off as another.
+type Codec struct {
+	// Keys are the secret keys, at least MinKeySize random bytes each.
+	// The first one encodes values; all of them decode values,
+	// so keys are rotated by prepending the new one and dropping
+	// the old one once its cookies expired.
+	Keys [][]byte
+	// Encrypt hides values from clients.
+	Encrypt bool
+	// MaxAge, if positive, rejects values encoded longer ago.
+	MaxAge time.Duration
+}
+
+// check returns an error if c has no keys or one shorter than MinKeySize.
+func (c *Codec) check() error {
+	if len(c.Keys) == 0 {
+		return errors.New("cookies: Codec has no keys")
+	}
+	for _, key := range c.Keys {
+		if len(key) < MinKeySize {
+			return errors.New("cookies: Codec key is shorter than 32 bytes")
+		}
+	}
+	return nil
+}
+
+// derive returns the key for purpose derived from key.
+func derive(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+func (c *Codec) gcm(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(derive(key, "encrypt"))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (c *Codec) mac(key []byte, name string, data []byte) []byte {
+	mac := hmac.New(sha256.New, derive(key, "sign"))
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// Encode returns the value of the cookie name carrying value.
+func (c *Codec) Encode(name string, value []byte) (string, error) {
+	if err := c.check(); err != nil {
+		return "", err
+	}
+	data := binary.BigEndian.AppendUint64(nil, uint64(time.Now().Unix()))
+	data = append(data, value...)
+	var out []byte
+	if c.Encrypt {
+		aead, err := c.gcm(c.Keys[0])
+		if err != nil {
+			return "", err
+		}
+		nonce := make([]byte, aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		out = aead.Seal(nonce, nonce, data, []byte(name))
+	} else {
+		out = append(data, c.mac(c.Keys[0], name, data)...)
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// Decode returns the value carried by s, the value of the cookie name.
+func (c *Codec) Decode(name, s string) ([]byte, error) {
+	if err := c.check(); err != nil {
+		return nil, err
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	for _, key := range c.Keys {
+		if data, ok := c.open(key, name, b); ok {
+			if len(data) < 8 {
+				return nil, ErrInvalid
+			}
+			created := time.Unix(int64(binary.BigEndian.Uint64(data)), 0)
+			if c.MaxAge > 0 && time.Since(created) > c.MaxAge {
+				return nil, ErrInvalid
+			}
+			return data[8:], nil
+		}
+	}
+	return nil, ErrInvalid
+}
+
+func (c *Codec) open(key []byte, name string, b []byte) ([]byte, bool) {
+	if !c.Encrypt {
+		if len(b) < sha256.Size {
+			return nil, false
+		}
+		data, sum := b[:len(b)-sha256.Size], b[len(b)-sha256.Size:]
+		return data, hmac.Equal(sum, c.mac(key, name, data))
+	}
+	aead, err := c.gcm(key)
+	if err != nil || len(b) < aead.NonceSize() {
+		return nil, false
+	}
+	data, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], []byte(name))
+	return data, err == nil
+}
+
+// SetSigned encodes the value of cookie and adds it to the response.
+func (c *Codec) SetSigned(w http.ResponseWriter, cookie *http.Cookie) error {
+	value, err := c.Encode(cookie.Name, []byte(cookie.Value))
+	if err != nil {
+		return err
+	}
+	signed := *cookie
+	signed.Value = value
+	http.SetCookie(w, &signed)
+	return nil
+}
+
+// GetSigned returns the decoded value of the cookie name of r.
+// It returns http.ErrNoCookie if r has none, ErrInvalid if it is invalid.
+func (c *Codec) GetSigned(r *http.Request, name string) (string, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	value, err := c.Decode(name, cookie.Value)
+	return string(value), err
+}
+
+// Cookie is a cookie holding a T, stored as JSON.
+// Cookies are loaded into the context by Load and read with Get:
+//
+//	var cart = &cookies.Cookie[Cart]{Name: "cart", Codec: codec, MaxAge: 7 * 24 * time.Hour}
+//
+//	chain := alice.New(cookies.Load(cart))
+//
+//	func show(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+//	    c, _ := cart.Get(ctx)
+//	    ...
+//	}
+type Cookie[T any] struct {
+	Name  string
+	Codec *Codec
+	// Path defaults to "/".
+	Path   string
+	Domain string
+	// MaxAge, if positive, makes the cookie persistent.
+	MaxAge time.Duration
+	// SameSite defaults to http.SameSiteLaxMode.
+	SameSite http.SameSite
+}
+
+// Loader is a cookie Load can load, i.e. a *Cookie.
+type Loader interface {
+	load(r *http.Request) (any, bool)
+	name() string
+	codec() *Codec
+}
+
+func (c *Cookie[T]) name() string {
+	return c.Name
+}
+
+func (c *Cookie[T]) codec() *Codec {
+	return c.Codec
+}
+
+func (c *Cookie[T]) load(r *http.Request) (any, bool) {
+	s, err := c.Codec.GetSigned(r, c.Name)
+	if err != nil {
+		return nil, false
+	}
+	var v T
+	if json.Unmarshal([]byte(s), &v) != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+type loadedKey struct{}
+
+// Get returns the value of c loaded by Load.
+// It returns false if the request had no valid cookie.
+func (c *Cookie[T]) Get(ctx context.Context) (T, bool) {
+	loaded, _ := ctx.Value(loadedKey{}).(map[string]any)
+	v, ok := loaded[c.Name].(T)
+	return v, ok
+}
+
+// Set adds the cookie carrying v to the response.
+func (c *Cookie[T]) Set(w http.ResponseWriter, v T) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	cookie := c.cookie()
+	cookie.Value = string(b)
+	if c.MaxAge > 0 {
+		cookie.Expires = time.Now().Add(c.MaxAge)
+	}
+	return c.Codec.SetSigned(w, cookie)
+}
+
+// Delete removes the cookie from the client.
+func (c *Cookie[T]) Delete(w http.ResponseWriter) {
+	cookie := c.cookie()
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+}
+
+func (c *Cookie[T]) cookie() *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     c.Name,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: c.SameSite,
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	return cookie
+}
+
+// Load returns a Constructor decoding the given cookies of every
+// request into the context, for Cookie.Get.
+// Missing and invalid cookies are left out. Load panics if the Codec
+// of a cookie has no keys or one shorter than MinKeySize.
+func Load(cookies ...Loader) alice.Constructor {
+	for _, c := range cookies {
+		if err := c.codec().check(); err != nil {
+			panic(err.Error())
+		}
+	}
+	return func(next alice.ContextHandler) alice.ContextHandler {
+		return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			prev, _ := ctx.Value(loadedKey{}).(map[string]any)
+			loaded := make(map[string]any, len(prev)+len(cookies))
+			for name, v := range prev {
+				loaded[name] = v
+			}
+			for _, c := range cookies {
+				if v, ok := c.load(r); ok {
+					loaded[c.name()] = v
+				}
+			}
+			next.ServeHTTPContext(context.WithValue(ctx, loadedKey{}, loaded), w, r)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package cookies
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var (
+	oldKey = []byte("0123456789abcdef0123456789abcdef")
+	newKey = []byte("fedcba9876543210fedcba9876543210")
+)
+
+// roundTrip sets a cookie with from and reads it back with to.
+func roundTrip(t *testing.T, from, to *Codec, name, value string) (string, error) {
+	w := httptest.NewRecorder()
+	if err := from.SetSigned(w, &http.Cookie{Name: name, Value: value}); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(w.Result().Cookies()[0])
+	return to.GetSigned(r, name)
+}
+
+// tamper changes a character in the middle of s.
+func tamper(s string) string {
+	b := []byte(s)
+	b[len(b)/2] ^= 1
+	return string(b)
+}
+
+func TestCodec(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		c := &Codec{Keys: [][]byte{oldKey}, Encrypt: encrypt}
+		v, err := roundTrip(t, c, c, "theme", "dark")
+		assert.Nil(t, err)
+		assert.Equal(t, v, "dark")
+
+		s, err := c.Encode("theme", []byte("dark"))
+		assert.Nil(t, err)
+		_, err = c.Decode("other", s)
+		assert.Equal(t, err, ErrInvalid)
+		_, err = c.Decode("theme", tamper(s))
+		assert.Equal(t, err, ErrInvalid)
+	}
+}
+
+func TestCodecEncrypt(t *testing.T) {
+	c := &Codec{Keys: [][]byte{oldKey}, Encrypt: true}
+	s, err := c.Encode("theme", []byte("dark"))
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(s, "ZGFyaw")) // base64 of "dark"
+}
+
+func TestCodecKeyRotation(t *testing.T) {
+	old := &Codec{Keys: [][]byte{oldKey}}
+	rotated := &Codec{Keys: [][]byte{newKey, oldKey}}
+	retired := &Codec{Keys: [][]byte{newKey}}
+
+	v, err := roundTrip(t, old, rotated, "theme", "dark")
+	assert.Nil(t, err)
+	assert.Equal(t, v, "dark")
+	_, err = roundTrip(t, old, retired, "theme", "dark")
+	assert.Equal(t, err, ErrInvalid)
+	_, err = roundTrip(t, rotated, retired, "theme", "dark")
+	assert.Nil(t, err)
+}
+
+func TestCodecMaxAge(t *testing.T) {
+	c := &Codec{Keys: [][]byte{oldKey}, MaxAge: time.Hour}
+	s, _ := c.Encode("theme", []byte("dark"))
+	_, err := c.Decode("theme", s)
+	assert.Nil(t, err)
+
+	data := binary.BigEndian.AppendUint64(nil, uint64(time.Now().Add(-2*time.Hour).Unix()))
+	data = append(data, "dark"...)
+	s = base64.RawURLEncoding.EncodeToString(append(data, c.mac(oldKey, "theme", data)...))
+	_, err = c.Decode("theme", s)
+	assert.Equal(t, err, ErrInvalid)
+}
+
+func TestGetSignedMissing(t *testing.T) {
+	c := &Codec{Keys: [][]byte{oldKey}}
+	_, err := c.GetSigned(httptest.NewRequest("GET", "/", nil), "theme")
+	assert.Equal(t, err, http.ErrNoCookie)
+	assert.NotNil(t, (&Codec{}).SetSigned(httptest.NewRecorder(), &http.Cookie{Name: "theme"}))
+}
+
+func TestCodecRejectsShortKeys(t *testing.T) {
+	short := &Codec{Keys: [][]byte{oldKey, []byte("too short")}}
+	_, err := short.Encode("theme", []byte("dark"))
+	assert.EqualError(t, err, "cookies: Codec key is shorter than 32 bytes")
+	_, err = short.Decode("theme", "")
+	assert.EqualError(t, err, "cookies: Codec key is shorter than 32 bytes")
+	assert.PanicsWithValue(t, "cookies: Codec key is shorter than 32 bytes", func() {
+		Load(&Cookie[string]{Name: "theme", Codec: short})
+	})
+	assert.PanicsWithValue(t, "cookies: Codec has no keys", func() {
+		Load(&Cookie[string]{Name: "theme", Codec: &Codec{}})
+	})
+}
+
+type cart struct {
+	Items []string
+}
+
+func TestLoad(t *testing.T) {
+	codec := &Codec{Keys: [][]byte{oldKey}, Encrypt: true}
+	cartCookie := &Cookie[cart]{Name: "cart", Codec: codec, MaxAge: time.Hour}
+	themeCookie := &Cookie[string]{Name: "theme", Codec: codec}
+
+	w := httptest.NewRecorder()
+	assert.Nil(t, cartCookie.Set(w, cart{Items: []string{"apple"}}))
+	set := w.Result().Cookies()[0]
+	assert.True(t, set.Secure)
+	assert.True(t, set.HttpOnly)
+	assert.Equal(t, set.Path, "/")
+
+	var got cart
+	var loaded, hasTheme bool
+	h := alice.New(Load(cartCookie, themeCookie)).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		got, loaded = cartCookie.Get(ctx)
+		_, hasTheme = themeCookie.Get(ctx)
+	})
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(set)
+	r.AddCookie(&http.Cookie{Name: "theme", Value: "forged"})
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.True(t, loaded)
+	assert.Equal(t, got.Items, []string{"apple"})
+	assert.False(t, hasTheme)
+
+	w = httptest.NewRecorder()
+	cartCookie.Delete(w)
+	assert.Equal(t, w.Result().Cookies()[0].MaxAge, -1)
+}
@@ -0,0 +1,104 @@
+package cookies
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// FlashCookie is the cookie Flash keeps messages in.
+const FlashCookie = "alice_flash"
+
+type flashKey struct{}
+
+// flashes are the messages of a request.
+type flashes struct {
+	mu      sync.Mutex
+	pending []string
+	// dirty is set once pending differs from the cookie.
+	dirty bool
+}
+
+// Flash returns a Constructor keeping flash messages, shown once on the
+// next page, in a cookie encoded with codec. Handlers add messages with
+// AddFlash, typically before redirecting, and the next page reads them
+// with Flashes, which clears them:
+//
+//	func save(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+//	    ...
+//	    cookies.AddFlash(ctx, "Profile saved.")
+//	    http.Redirect(w, r, "/profile", http.StatusSeeOther)
+//	}
+//
+// Pass FlashData to the render.Renderer to give templates .Flashes.
+// Flash panics if codec has no keys or one shorter than MinKeySize.
+func Flash(codec *Codec) alice.Constructor {
+	if err := codec.check(); err != nil {
+		panic(err.Error())
+	}
+	cookie := &Cookie[[]string]{Name: FlashCookie, Codec: codec}
+	return func(next alice.ContextHandler) alice.ContextHandler {
+		return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			f := &flashes{}
+			if v, ok := cookie.load(r); ok {
+				f.pending = v.([]string)
+			}
+			ctx = context.WithValue(ctx, flashKey{}, f)
+			hw := alice.NewHookWriter(w, func() { f.save(ctx, w, cookie) })
+			next.ServeHTTPContext(ctx, hw, r)
+			hw.Run()
+		})
+	}
+}
+
+// AddFlash adds msg to the messages shown by the next page.
+// It must be called before the response is written.
+func AddFlash(ctx context.Context, msg string) {
+	if f, ok := ctx.Value(flashKey{}).(*flashes); ok {
+		f.mu.Lock()
+		f.pending = append(f.pending, msg)
+		f.dirty = true
+		f.mu.Unlock()
+	}
+}
+
+// Flashes returns and clears the flash messages,
+// including those added by the current request.
+func Flashes(ctx context.Context) []string {
+	f, ok := ctx.Value(flashKey{}).(*flashes)
+	if !ok {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	msgs := f.pending
+	if len(msgs) > 0 {
+		f.pending = nil
+		f.dirty = true
+	}
+	return msgs
+}
+
+// FlashData sets data["Flashes"] to the flash messages.
+// It is a render.DataFunc.
+func FlashData(ctx context.Context, data map[string]any) {
+	data["Flashes"] = Flashes(ctx)
+}
+
+// save stores the messages left in cookie.
+func (f *flashes) save(ctx context.Context, w http.ResponseWriter, cookie *Cookie[[]string]) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.dirty {
+		return
+	}
+	if len(f.pending) == 0 {
+		cookie.Delete(w)
+		return
+	}
+	if err := cookie.Set(w, f.pending); err != nil {
+		alice.ReportError(ctx, err)
+	}
+}
@@ -0,0 +1,65 @@
+package cookies
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestFlash(t *testing.T) {
+	var shown []string
+	h := alice.New(Flash(&Codec{Keys: [][]byte{oldKey}})).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/save":
+			AddFlash(ctx, "Profile saved.")
+			AddFlash(ctx, "Welcome back.")
+			http.Redirect(w, r, "/profile", http.StatusSeeOther)
+		case "/profile":
+			data := make(map[string]any)
+			FlashData(ctx, data)
+			shown = data["Flashes"].([]string)
+		}
+	})
+	do := func(path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := do("/save")
+	saved := w.Result().Cookies()
+	assert.Equal(t, len(saved), 1)
+	assert.Equal(t, saved[0].Name, FlashCookie)
+
+	// Pages not reading the messages keep them.
+	w = do("/other", saved...)
+	assert.Equal(t, len(w.Result().Cookies()), 0)
+
+	w = do("/profile", saved...)
+	assert.Equal(t, shown, []string{"Profile saved.", "Welcome back."})
+	assert.Equal(t, w.Result().Cookies()[0].MaxAge, -1)
+
+	shown = nil
+	do("/profile")
+	assert.Equal(t, len(shown), 0)
+}
+
+func TestFlashRejectsShortKeys(t *testing.T) {
+	assert.PanicsWithValue(t, "cookies: Codec key is shorter than 32 bytes", func() {
+		Flash(&Codec{Keys: [][]byte{[]byte("secret")}})
+	})
+}
+
+func TestFlashesWithoutMiddleware(t *testing.T) {
+	ctx := context.Background()
+	AddFlash(ctx, "lost")
+	assert.Equal(t, len(Flashes(ctx)), 0)
+}
@@ -0,0 +1,167 @@
+package alice
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/net/context"
+)
+
+// DebugOptions configure the handler returned by Debug.
+type DebugOptions struct {
+	// Prefix is the path the endpoints are mounted under.
+	// It defaults to "/debug".
+	Prefix string
+	// Auth, if set, guards all endpoints.
+	Auth Constructor
+	// Chains are listed by the chains endpoint, keyed by name.
+	Chains map[string]Chain
+	// Requests, if set, is served by the requests endpoint.
+	Requests *CaptureBuffer
+}
+
+// Debug returns a ContextHandler serving debugging endpoints:
+//
+//	/debug/pprof/    runtime profiles, see net/http/pprof
+//	/debug/vars      exported variables, see expvar
+//	/debug/chains    the constructors of opts.Chains, as JSON
+//	/debug/requests  the exchanges captured into opts.Requests, see Capture
+//
+// It can be attached to any mux:
+//
+//	mux.Handle("/debug/", alice.NewContextAdapter(ctx, alice.Debug(alice.DebugOptions{
+//	    Auth:   adminOnly,
+//	    Chains: map[string]alice.Chain{"api": apiChain},
+//	})))
+func Debug(opts DebugOptions) ContextHandler {
+	prefix := strings.TrimSuffix(opts.Prefix, "/")
+	if prefix == "" {
+		prefix = "/debug"
+	}
+
+	mux := http.NewServeMux()
+	// pprof.Index only serves profiles under /debug/pprof/,
+	// so strip any other prefix in front of it.
+	mux.Handle(prefix+"/pprof/", rebase(prefix, "/debug", http.HandlerFunc(pprof.Index)))
+	mux.HandleFunc(prefix+"/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc(prefix+"/pprof/profile", pprof.Profile)
+	mux.HandleFunc(prefix+"/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc(prefix+"/pprof/trace", pprof.Trace)
+	mux.Handle(prefix+"/vars", expvar.Handler())
+	mux.Handle(prefix+"/chains", chainsHandler(opts.Chains))
+
+	var h ContextHandler = ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if opts.Requests != nil && r.URL.Path == prefix+"/requests" {
+			opts.Requests.ServeHTTPContext(ctx, w, r)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+	if opts.Auth != nil {
+		h = opts.Auth(h)
+	}
+	return h
+}
+
+// rebase serves h as if the request path started with to instead of from.
+func rebase(from, to string, h http.Handler) http.Handler {
+	if from == to {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		u.Path = to + strings.TrimPrefix(r.URL.Path, from)
+		r2.URL = &u
+		h.ServeHTTP(w, r2)
+	})
+}
+
+func chainsHandler(chains map[string]Chain) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		out := make(map[string][]string, len(chains))
+		for name, c := range chains {
+			out[name] = c.names()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	})
+}
+
+// names returns the function names of the chain's constructors,
+// in request flow order.
+func (c Chain) names() []string {
+	names := make([]string, len(c.constructors))
+	for i, cons := range c.constructors {
+		names[i] = constructorName(cons)
+	}
+	return names
+}
+
+func constructorName(c Constructor) string {
+	if c == nil {
+		return "<nil>"
+	}
+	if named, ok := constructorNames.Load(constructorKey(c)); ok {
+		return named.(namedConstructor).name
+	}
+	return funcName(c)
+}
+
+// funcName returns the name of the function fn.
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "<unknown>"
+	}
+	return f.Name()
+}
+
+// ConstructorName returns a short name for c, such as "alice.RequestID"
+// for the constructor returned by alice.RequestID(), as WriteDOT labels it.
+func ConstructorName(c Constructor) string {
+	return shortName(constructorName(c))
+}
+
+var closureSuffix = regexp.MustCompile(`(\.func\d+|\.\d+)+$`)
+
+// shortName strips the import path and closure suffixes of a function name.
+func shortName(name string) string {
+	name = closureSuffix.ReplaceAllString(name, "")
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// constructorNames holds the names given with nameConstructor,
+// keyed by constructorKey.
+var constructorNames sync.Map
+
+// namedConstructor keeps its constructor alive, so that the key
+// of its name is not reused by another closure.
+type namedConstructor struct {
+	c    Constructor
+	name string
+}
+
+// nameConstructor makes constructorName report name for c, as for
+// constructors wrapping another one or built by a helper shared by
+// several factories, which function names do not tell apart.
+// It returns c.
+func nameConstructor(c Constructor, name string) Constructor {
+	constructorNames.Store(constructorKey(c), namedConstructor{c, name})
+	return c
+}
+
+// constructorKey returns the address of the closure c refers to,
+// which unlike its function tells apart the closures of one literal.
+func constructorKey(c Constructor) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&c))
+}
@@ -0,0 +1,60 @@
+package alice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func passThrough(h ContextHandler) ContextHandler {
+	return h
+}
+
+func denyAll(h ContextHandler) ContextHandler {
+	return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+}
+
+func serveDebug(t *testing.T, opts DebugOptions, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	Debug(opts).ServeHTTPContext(context.Background(), w, r)
+	return w
+}
+
+func TestDebugListsChains(t *testing.T) {
+	w := serveDebug(t, DebugOptions{
+		Chains: map[string]Chain{"api": New(passThrough)},
+	}, "/debug/chains")
+
+	var chains map[string][]string
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&chains))
+	assert.Equal(t, len(chains["api"]), 1)
+	assert.True(t, strings.HasSuffix(chains["api"][0], ".passThrough"))
+}
+
+func TestDebugServesPprofUnderPrefix(t *testing.T) {
+	w := serveDebug(t, DebugOptions{Prefix: "/admin/debug"}, "/admin/debug/pprof/")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Contains(t, w.Body.String(), "goroutine")
+}
+
+func TestDebugServesExpvar(t *testing.T) {
+	w := serveDebug(t, DebugOptions{}, "/debug/vars")
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Contains(t, w.Body.String(), "memstats")
+}
+
+func TestDebugAuth(t *testing.T) {
+	w := serveDebug(t, DebugOptions{Auth: denyAll}, "/debug/vars")
+	assert.Equal(t, w.Code, http.StatusForbidden)
+}
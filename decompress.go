@@ -0,0 +1,72 @@
+package alice
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultMaxDecompressedSize is the limit Decompress puts
+// on decoded request bodies.
+const DefaultMaxDecompressedSize = 10 << 20
+
+// Decompress returns a Constructor transparently decoding request bodies
+// sent with a gzip or deflate Content-Encoding, so handlers always see
+// the plain body. Decoded bodies are limited to DefaultMaxDecompressedSize;
+// reading past it fails with an *http.MaxBytesError.
+// Requests with other encodings get 415 Unsupported Media Type.
+func Decompress() Constructor {
+	return DecompressLimit(DefaultMaxDecompressedSize)
+}
+
+// DecompressLimit works like Decompress,
+// limiting decoded bodies to maxBytes instead.
+func DecompressLimit(maxBytes int64) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+
+			var body io.ReadCloser
+			var err error
+			switch encoding {
+			case "gzip", "x-gzip":
+				body, err = gzip.NewReader(r.Body)
+			case "deflate":
+				body, err = zlib.NewReader(r.Body)
+			default:
+				w.Header().Set("Accept-Encoding", "gzip, deflate")
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusUnsupportedMediaType,
+					Detail: "unsupported Content-Encoding " + encoding,
+				})
+				return
+			}
+			if err != nil {
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusBadRequest,
+					Detail: "malformed " + encoding + " body",
+					Err:    err,
+				})
+				return
+			}
+			defer body.Close()
+
+			r2 := new(http.Request)
+			*r2 = *r
+			r2.Header = r.Header.Clone()
+			r2.Header.Del("Content-Encoding")
+			r2.Header.Del("Content-Length")
+			r2.ContentLength = -1
+			r2.Body = http.MaxBytesReader(w, body, maxBytes)
+			next.ServeHTTPContext(ctx, w, r2)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package alice
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var echoBody = ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Write([]byte(r.Header.Get("Content-Encoding") + ":" + string(body)))
+})
+
+func sendEncoded(c Constructor, encoding string, body []byte) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	r.Header.Set("Content-Encoding", encoding)
+	c(echoBody).ServeHTTPContext(context.Background(), w, r)
+	return w
+}
+
+func gzipped(s string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(s))
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	assert.Equal(t, sendEncoded(Decompress(), "gzip", gzipped("hello")).Body.String(), ":hello")
+	assert.Equal(t, sendEncoded(Decompress(), "deflate", deflated.Bytes()).Body.String(), ":hello")
+	assert.Equal(t, sendEncoded(Decompress(), "", []byte("hello")).Body.String(), ":hello")
+}
+
+func TestDecompressLimit(t *testing.T) {
+	w := sendEncoded(DecompressLimit(10), "gzip", gzipped(strings.Repeat("a", 100)))
+	assert.Equal(t, w.Code, http.StatusRequestEntityTooLarge)
+}
+
+func TestDecompressRejects(t *testing.T) {
+	assert.Equal(t, sendEncoded(Decompress(), "br", []byte("hello")).Code, http.StatusUnsupportedMediaType)
+	assert.Equal(t, sendEncoded(Decompress(), "gzip", []byte("hello")).Code, http.StatusBadRequest)
+}
@@ -0,0 +1,19 @@
+package alice
+
+import (
+	stdcontext "context"
+
+	"golang.org/x/net/context"
+)
+
+// Detach returns a context with the values of ctx, such as the request
+// ID, trace and logger, that is never cancelled and has no deadline.
+// Handlers use it for work that must outlive the response while staying
+// correlated with the request:
+//
+//	go sendReceipt(alice.Detach(ctx), order)
+//
+// Bound such work with its own timeout if needed.
+func Detach(ctx context.Context) context.Context {
+	return stdcontext.WithoutCancel(ctx)
+}
@@ -0,0 +1,21 @@
+package alice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestDetach(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	ctx = context.WithValue(ctx, requestIDKey{}, "abc")
+	cancel()
+
+	detached := Detach(ctx)
+	assert.Nil(t, detached.Err())
+	_, ok := detached.Deadline()
+	assert.False(t, ok)
+	assert.Equal(t, RequestIDFrom(detached), "abc")
+}
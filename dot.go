@@ -0,0 +1,150 @@
+package alice
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// branch is a sub-chain a handler dispatches some requests to,
+// such as a mounted application.
+type branch struct {
+	label  string
+	chain  Chain
+	layers []ContextHandler
+	// continues is set if the branch ends in the next handler
+	// of the chain it is part of.
+	continues bool
+}
+
+// brancher is implemented by handlers dispatching to branches.
+type brancher interface {
+	branches() []branch
+}
+
+// branching adds branches to a handler.
+type branching struct {
+	ContextHandler
+	list []branch
+}
+
+func (b *branching) branches() []branch { return b.list }
+
+// WriteDOT writes the composition of handlers, keyed by name, as a
+// Graphviz DOT graph: the middleware of each chain built with
+// ThenWithContext, the branches into chains mounted with Mount, chosen
+// by Version or hosted by a HostSwitch, and the final handlers.
+//
+//	alice.WriteDOT(os.Stdout, map[string]http.Handler{"site": site})
+//
+// Render it with: dot -Tsvg chains.dot > chains.svg
+func WriteDOT(w io.Writer, handlers map[string]http.Handler) error {
+	g := &dotGraph{w: bufio.NewWriter(w)}
+	g.printf("digraph alice {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		root := g.node(name, "shape=oval")
+		g.edge(root, g.end(handlers[name]), "")
+	}
+	g.printf("}\n")
+	return g.w.Flush()
+}
+
+type dotGraph struct {
+	w     *bufio.Writer
+	nodes int
+}
+
+func (g *dotGraph) printf(format string, args ...any) {
+	fmt.Fprintf(g.w, format, args...)
+}
+
+func (g *dotGraph) node(label, attrs string) string {
+	g.nodes++
+	id := fmt.Sprintf("n%d", g.nodes)
+	if attrs != "" {
+		attrs = ", " + attrs
+	}
+	g.printf("\t%s [label=%q%s];\n", id, label, attrs)
+	return id
+}
+
+func (g *dotGraph) edge(from, to, label string) {
+	if label == "" {
+		g.printf("\t%s -> %s;\n", from, to)
+	} else {
+		g.printf("\t%s -> %s [label=%q];\n", from, to, label)
+	}
+}
+
+// end draws h at the end of a chain, returning its node.
+func (g *dotGraph) end(h any) string {
+	switch h := h.(type) {
+	case *ContextAdapter:
+		if h.layers == nil {
+			// Created by NewContextAdapter rather than a chain.
+			return g.end(h.handler)
+		}
+		return g.chain(h.chain, h.layers, "")
+	case brancher:
+		id := g.node(handlerName(h), "")
+		g.branches(id, h.branches(), "")
+		return id
+	default:
+		return g.node(handlerName(h), "shape=box3d")
+	}
+}
+
+// chain draws the constructors of c followed by the handler it ends in,
+// or by the node cont for branches continuing in their parent chain.
+// It returns the node requests enter the chain at.
+func (g *dotGraph) chain(c Chain, layers []ContextHandler, cont string) string {
+	ids := make([]string, len(c.constructors)+1)
+	for i, cons := range c.constructors {
+		ids[i] = g.node(ConstructorName(cons), "")
+	}
+	ids[len(c.constructors)] = cont
+	if cont == "" {
+		ids[len(c.constructors)] = g.end(layers[len(layers)-1])
+	}
+	for i := range c.constructors {
+		g.edge(ids[i], ids[i+1], "")
+		// A constructor returning the handler it was given adds no branches.
+		if b, ok := layers[i].(brancher); ok && !identical(layers[i], layers[i+1]) {
+			g.branches(ids[i], b.branches(), ids[i+1])
+		}
+	}
+	return ids[0]
+}
+
+// branches draws bs from the node from, sorted by label.
+// Continuing branches lead to the node next.
+func (g *dotGraph) branches(from string, bs []branch, next string) {
+	sort.Slice(bs, func(i, j int) bool { return bs[i].label < bs[j].label })
+	for _, b := range bs {
+		cont := ""
+		if b.continues {
+			cont = next
+		}
+		g.edge(from, g.chain(b.chain, b.layers, cont), b.label)
+	}
+}
+
+// handlerName names a handler after its function or type.
+func handlerName(h any) string {
+	if fn, ok := h.(ContextHandlerFunc); ok {
+		if f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()); f != nil {
+			return shortName(f.Name())
+		}
+	}
+	return shortName(strings.TrimPrefix(reflect.TypeOf(h).String(), "*"))
+}
@@ -0,0 +1,59 @@
+package alice
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func dotApp(ctx context.Context, w http.ResponseWriter, r *http.Request) {}
+
+func TestWriteDOT(t *testing.T) {
+	app := ContextHandlerFunc(dotApp)
+	var hosts HostSwitch
+	hosts.Handle("api.example.com", New(RequestID()), app)
+	site := New(
+		passThrough,
+		Mount("/admin", New(denyAll), app),
+		Version(VersionOptions{Path: true, Chains: map[string]Chain{"v1": New(tagMiddleware("legacy"))}}),
+	).ThenWithContext(context.Background(), &hosts)
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteDOT(&buf, map[string]http.Handler{"site": site}))
+	want := `digraph alice {
+	rankdir=LR;
+	node [shape=box];
+	n1 [label="site", shape=oval];
+	n2 [label="alice.passThrough"];
+	n3 [label="alice.Mount"];
+	n4 [label="alice.Version"];
+	n5 [label="alice.HostSwitch"];
+	n6 [label="alice.RequestID"];
+	n7 [label="alice.dotApp", shape=box3d];
+	n6 -> n7;
+	n5 -> n6 [label="api.example.com"];
+	n2 -> n3;
+	n3 -> n4;
+	n8 [label="alice.denyAll"];
+	n9 [label="alice.dotApp", shape=box3d];
+	n8 -> n9;
+	n3 -> n8 [label="/admin"];
+	n4 -> n5;
+	n10 [label="alice.tagMiddleware"];
+	n10 -> n5;
+	n4 -> n10 [label="v1"];
+	n1 -> n2;
+}
+`
+	assert.Equal(t, buf.String(), want)
+}
+
+func TestShortName(t *testing.T) {
+	assert.Equal(t, shortName("github.com/SimiPro/alice.RequestID.func1.1"), "alice.RequestID")
+	assert.Equal(t, ConstructorName(RequestID()), "alice.RequestID")
+	assert.True(t, strings.HasSuffix(handlerName(&HostSwitch{}), "HostSwitch"))
+}
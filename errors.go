@@ -0,0 +1,176 @@
+package alice
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// ErrorHandlerFunc is a ContextHandlerFunc variant that returns an error.
+// A returned error is reported to middleware tracking errors
+// (see TrackErrors) and rendered with WriteError.
+//
+//	chain.ThenWithContext(ctx, alice.ErrorHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+//	    user, err := loadUser(ctx, r)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    ...
+//	}))
+type ErrorHandlerFunc func(context.Context, http.ResponseWriter, *http.Request) error
+
+// ServeHTTPContext calls h, handling the error it returns.
+func (h ErrorHandlerFunc) ServeHTTPContext(ctx context.Context, rw http.ResponseWriter, r *http.Request) {
+	if err := h(ctx, rw, r); err != nil {
+		ReportError(ctx, err)
+		WriteError(ctx, rw, err)
+	}
+}
+
+type errorsKey struct{}
+
+type errorRecord struct {
+	err error
+}
+
+// TrackErrors returns a context in which errors reported further down
+// the chain are recorded, and a function returning the last one.
+// Middleware call it before passing the request on:
+//
+//	ctx, lastErr := alice.TrackErrors(ctx)
+//	next.ServeHTTPContext(ctx, w, r)
+//	if err := lastErr(); err != nil {
+//	    ...
+//	}
+func TrackErrors(ctx context.Context) (context.Context, func() error) {
+	rec, ok := ctx.Value(errorsKey{}).(*errorRecord)
+	if !ok {
+		rec = &errorRecord{}
+		ctx = context.WithValue(ctx, errorsKey{}, rec)
+	}
+	return ctx, func() error {
+		return rec.err
+	}
+}
+
+// ReportError records err for middleware tracking errors in ctx.
+// It does nothing if no middleware does.
+func ReportError(ctx context.Context, err error) {
+	if rec, ok := ctx.Value(errorsKey{}).(*errorRecord); ok {
+		rec.err = err
+	}
+}
+
+// HTTPError is an error carrying an HTTP status,
+// rendered as an RFC 7807 application/problem+json response.
+//
+//	return &alice.HTTPError{
+//	    Status: http.StatusBadRequest,
+//	    Code:   "invalid_user",
+//	    Detail: "the user could not be created",
+//	    Fields: map[string]string{"email": "is required"},
+//	}
+type HTTPError struct {
+	// Status is the HTTP status code of the response.
+	Status int
+	// Code is a machine-readable error code.
+	Code string
+	// Detail is a human-readable explanation of this occurrence.
+	Detail string
+	// Fields maps invalid request fields to their errors.
+	Fields map[string]string
+	// Err is the underlying error. It is not rendered.
+	Err error
+}
+
+func (e *HTTPError) Error() string {
+	msg := http.StatusText(e.Status)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// problem is the RFC 7807 representation of an HTTPError.
+type problem struct {
+	Type   string            `json:"type"`
+	Title  string            `json:"title"`
+	Status int               `json:"status"`
+	Detail string            `json:"detail,omitempty"`
+	Code   string            `json:"code,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// An ErrorMapper converts an error into the HTTPError rendered for it.
+type ErrorMapper func(error) *HTTPError
+
+// DefaultErrorMapper renders HTTPErrors found in the error chain as they are
+// and every other error as 500 Internal Server Error,
+// without exposing its message.
+func DefaultErrorMapper(err error) *HTTPError {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr
+	}
+	return &HTTPError{Status: http.StatusInternalServerError, Err: err}
+}
+
+type errorMapperKey struct{}
+
+// MapErrors returns a Constructor making WriteError further down the chain
+// use mapper instead of DefaultErrorMapper.
+func MapErrors(mapper ErrorMapper) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTPContext(context.WithValue(ctx, errorMapperKey{}, mapper), w, r)
+		})
+	}
+}
+
+// MapError converts err into an HTTPError with the ErrorMapper installed
+// by MapErrors, for handlers rendering errors in formats of their own.
+func MapError(ctx context.Context, err error) *HTTPError {
+	mapper, ok := ctx.Value(errorMapperKey{}).(ErrorMapper)
+	if !ok {
+		mapper = DefaultErrorMapper
+	}
+	if httpErr := mapper(err); httpErr != nil {
+		return httpErr
+	}
+	return DefaultErrorMapper(err)
+}
+
+// WriteError renders err as application/problem+json,
+// mapping it with the ErrorMapper installed by MapErrors.
+// Nothing is written if w is a ResponseRecorder that was already written to.
+func WriteError(ctx context.Context, w http.ResponseWriter, err error) {
+	if rec, ok := w.(*ResponseRecorder); ok && rec.Written() {
+		return
+	}
+	httpErr := MapError(ctx, err)
+	status := httpErr.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: httpErr.Detail,
+		Code:   httpErr.Code,
+		Fields: httpErr.Fields,
+	})
+}
@@ -0,0 +1,113 @@
+package alice
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestErrorHandlerFuncReportsError(t *testing.T) {
+	failure := errors.New("failure")
+	h := ErrorHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return failure
+	})
+
+	ctx, lastErr := TrackErrors(context.Background())
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.ServeHTTPContext(ctx, w, r)
+
+	assert.Equal(t, lastErr(), failure)
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+}
+
+func TestTrackErrorsSharesRecord(t *testing.T) {
+	outer, outerErr := TrackErrors(context.Background())
+	inner, innerErr := TrackErrors(outer)
+
+	failure := errors.New("failure")
+	ReportError(inner, failure)
+
+	assert.Equal(t, outerErr(), failure)
+	assert.Equal(t, innerErr(), failure)
+}
+
+func serveError(t *testing.T, ctx context.Context, err error) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r, rerr := http.NewRequest("GET", "/", nil)
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	ErrorHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return err
+	}).ServeHTTPContext(ctx, w, r)
+	return w
+}
+
+func TestWriteErrorRendersProblem(t *testing.T) {
+	err := fmt.Errorf("creating user: %w", &HTTPError{
+		Status: http.StatusBadRequest,
+		Code:   "invalid_user",
+		Detail: "the user could not be created",
+		Fields: map[string]string{"email": "is required"},
+	})
+	w := serveError(t, context.Background(), err)
+
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/problem+json")
+	var p map[string]interface{}
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&p))
+	assert.Equal(t, p, map[string]interface{}{
+		"type":   "about:blank",
+		"title":  "Bad Request",
+		"status": float64(400),
+		"detail": "the user could not be created",
+		"code":   "invalid_user",
+		"fields": map[string]interface{}{"email": "is required"},
+	})
+}
+
+func TestWriteErrorHidesInternalErrors(t *testing.T) {
+	w := serveError(t, context.Background(), errors.New("secret connection string"))
+
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	assert.NotContains(t, w.Body.String(), "secret")
+}
+
+func TestMapErrors(t *testing.T) {
+	notFound := errors.New("not found")
+	mapper := func(err error) *HTTPError {
+		if errors.Is(err, notFound) {
+			return &HTTPError{Status: http.StatusNotFound}
+		}
+		return DefaultErrorMapper(err)
+	}
+	var ctx context.Context
+	MapErrors(mapper)(ContextHandlerFunc(func(c context.Context, w http.ResponseWriter, r *http.Request) {
+		ctx = c
+	})).ServeHTTPContext(context.Background(), nil, nil)
+
+	w := serveError(t, ctx, notFound)
+	assert.Equal(t, w.Code, http.StatusNotFound)
+	assert.Equal(t, MapError(ctx, notFound).Status, http.StatusNotFound)
+	assert.Equal(t, MapError(context.Background(), notFound).Status, http.StatusInternalServerError)
+}
+
+func TestWriteErrorSkipsWrittenResponses(t *testing.T) {
+	w := httptest.NewRecorder()
+	rec := NewResponseRecorder(w)
+	rec.WriteHeader(http.StatusAccepted)
+
+	WriteError(context.Background(), rec, errors.New("failure"))
+	assert.Equal(t, w.Code, http.StatusAccepted)
+	assert.Equal(t, w.Body.Len(), 0)
+}
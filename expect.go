@@ -0,0 +1,76 @@
+package alice
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// ErrBodyHeld is returned when reading a request body held by
+// ExpectContinue before its checks passed.
+var ErrBodyHeld = errors.New("alice: request body read before ExpectContinue checks passed")
+
+type heldBodyKey struct{}
+
+// heldBody fails reads until the ExpectContinue checks released it.
+type heldBody struct {
+	io.ReadCloser
+	released bool
+}
+
+func (b *heldBody) Read(p []byte) (int, error) {
+	if !b.released {
+		return 0, ErrBodyHeld
+	}
+	return b.ReadCloser.Read(p)
+}
+
+// ExpectContinue returns a Constructor running checks, such as
+// authentication or header validation, before the client of an
+// "Expect: 100-continue" request uploads the body:
+//
+//	upload := alice.New(alice.ExpectContinue(
+//	    alice.RequireScopes("files:write"),
+//	    alice.RequireContentType("application/octet-stream"),
+//	))
+//
+// net/http sends the 100 Continue the client waits for when the body is
+// first read, so the body is held while checks run and reading it fails
+// with ErrBodyHeld. A check rejecting the request responds before the
+// 100 Continue, and the client skips the upload. Once every check
+// passed, the rest of the chain reads the body as usual.
+// Other requests go through checks unchanged.
+// Like New, it panics if a check is nil.
+func ExpectContinue(checks ...Constructor) Constructor {
+	requireConstructors("ExpectContinue", checks)
+	checked := New(checks...)
+	return func(next ContextHandler) ContextHandler {
+		h := checked.compose(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if body, ok := ctx.Value(heldBodyKey{}).(*heldBody); ok {
+				body.released = true
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		}))
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if !expectsContinue(r) {
+				h.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			body := &heldBody{ReadCloser: r.Body}
+			r2 := new(http.Request)
+			*r2 = *r
+			r2.Body = body
+			h.ServeHTTPContext(context.WithValue(ctx, heldBodyKey{}, body), w, r2)
+		})
+	}
+}
+
+// expectsContinue reports whether the client of r waits for
+// 100 Continue before sending the body.
+func expectsContinue(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Expect"), "100-continue") &&
+		r.Body != nil && r.Body != http.NoBody
+}
@@ -0,0 +1,104 @@
+package alice
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// readBody responds with the request body, or the error reading it.
+var readBody = ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Write(b)
+})
+
+func expectRequest(body string) *http.Request {
+	r := httptest.NewRequest("PUT", "/upload", strings.NewReader(body))
+	r.Header.Set("Expect", "100-continue")
+	return r
+}
+
+func TestExpectContinueReleasesBodyAfterChecks(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(ExpectContinue(passThrough)).ThenWithContext(context.Background(), readBody).ServeHTTP(w, expectRequest("data"))
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), "data")
+}
+
+func TestExpectContinueHoldsBodyDuringChecks(t *testing.T) {
+	eager := func(next ContextHandler) ContextHandler {
+		return readBody
+	}
+	w := httptest.NewRecorder()
+	New(ExpectContinue(eager)).ThenWithContext(context.Background(), testApp).ServeHTTP(w, expectRequest("data"))
+
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	assert.Equal(t, w.Body.String(), ErrBodyHeld.Error()+"\n")
+}
+
+func TestExpectContinueLeavesOtherRequests(t *testing.T) {
+	eager := func(next ContextHandler) ContextHandler {
+		return readBody
+	}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("PUT", "/upload", strings.NewReader("data"))
+	New(ExpectContinue(eager)).ThenWithContext(context.Background(), testApp).ServeHTTP(w, r)
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), "data")
+}
+
+func TestExpectContinueNilCheckPanics(t *testing.T) {
+	assert.PanicsWithValue(t, "alice: ExpectContinue: constructor 1 is nil", func() {
+		ExpectContinue(passThrough, nil)
+	})
+}
+
+// countingReader counts the bytes the client uploads.
+type countingReader struct {
+	r    io.Reader
+	read atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func TestExpectContinueRejectsBeforeUpload(t *testing.T) {
+	for _, tt := range []struct {
+		check  Constructor
+		status int
+		sent   int64
+	}{
+		{passThrough, http.StatusOK, 4},
+		{denyAll, http.StatusForbidden, 0},
+	} {
+		srv := httptest.NewServer(New(ExpectContinue(tt.check)).ThenWithContext(context.Background(), readBody))
+		body := &countingReader{r: strings.NewReader("data")}
+		req, _ := http.NewRequest("PUT", srv.URL, body)
+		req.ContentLength = 4
+		req.Header.Set("Expect", "100-continue")
+		client := &http.Client{Transport: &http.Transport{ExpectContinueTimeout: 5 * time.Second}}
+		res, err := client.Do(req)
+		if assert.NoError(t, err) {
+			res.Body.Close()
+			assert.Equal(t, res.StatusCode, tt.status)
+		}
+		assert.Equal(t, body.read.Load(), tt.sent)
+		srv.Close()
+	}
+}
@@ -0,0 +1,62 @@
+package alice
+
+import (
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// A FlagProvider evaluates feature flags.
+// The context carries whatever the decision depends on,
+// such as the user or the tenant (see TenantFrom).
+type FlagProvider interface {
+	Enabled(ctx context.Context, name string) bool
+}
+
+// FlagProviderFunc adapts a function to a FlagProvider.
+type FlagProviderFunc func(ctx context.Context, name string) bool
+
+// Enabled calls f(ctx, name).
+func (f FlagProviderFunc) Enabled(ctx context.Context, name string) bool {
+	return f(ctx, name)
+}
+
+// flagSet caches flag decisions for one request.
+type flagSet struct {
+	provider FlagProvider
+	mu       sync.Mutex
+	cache    map[string]bool
+}
+
+type flagsKey struct{}
+
+// Flags returns a Constructor making provider's flags available
+// to the rest of the chain through FlagEnabled, without global state.
+// Each flag is evaluated at most once per request,
+// so a request sees consistent decisions.
+func Flags(provider FlagProvider) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			set := &flagSet{provider: provider, cache: make(map[string]bool)}
+			next.ServeHTTPContext(context.WithValue(ctx, flagsKey{}, set), w, r)
+		})
+	}
+}
+
+// FlagEnabled reports whether the flag name is enabled for the request.
+// Flags are disabled if the Flags middleware did not run.
+func FlagEnabled(ctx context.Context, name string) bool {
+	set, ok := ctx.Value(flagsKey{}).(*flagSet)
+	if !ok {
+		return false
+	}
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	enabled, ok := set.cache[name]
+	if !ok {
+		enabled = set.provider.Enabled(ctx, name)
+		set.cache[name] = enabled
+	}
+	return enabled
+}
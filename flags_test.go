@@ -0,0 +1,43 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestFlagsEvaluatesOncePerRequest(t *testing.T) {
+	evaluations := 0
+	provider := FlagProviderFunc(func(ctx context.Context, name string) bool {
+		evaluations++
+		tenant, _ := TenantFrom(ctx)
+		return name == "beta" && tenant.ID == "acme"
+	})
+	withTenant := func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTPContext(context.WithValue(ctx, tenantKey{}, TenantInfo{ID: r.Header.Get("X-Tenant")}), w, r)
+		})
+	}
+	app := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		if FlagEnabled(ctx, "beta") && FlagEnabled(ctx, "beta") {
+			w.Write([]byte("beta"))
+		}
+	})
+	h := New(Flags(provider), withTenant).ThenWithContext(context.Background(), app)
+
+	for tenant, want := range map[string]string{"acme": "beta", "other": ""} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Tenant", tenant)
+		h.ServeHTTP(w, r)
+		assert.Equal(t, w.Body.String(), want)
+	}
+	assert.Equal(t, evaluations, 2)
+}
+
+func TestFlagEnabledWithoutFlags(t *testing.T) {
+	assert.False(t, FlagEnabled(context.Background(), "beta"))
+}
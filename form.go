@@ -0,0 +1,85 @@
+package alice
+
+import (
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultMaxFormSize is the limit ParseForm puts on form bodies.
+const DefaultMaxFormSize = 32 << 20
+
+type formKey struct{}
+
+type parsedForm struct {
+	values    url.Values
+	multipart *multipart.Form
+}
+
+// ParseForm returns a Constructor parsing url-encoded and multipart
+// request bodies up front, keeping up to maxMemory bytes of multipart
+// files in memory and the rest in temporary files, which are removed
+// after the handler returns. Bodies are limited to DefaultMaxFormSize.
+//
+// The parsed values, merged with the query, are stored in the context
+// (see FormFrom and MultipartFormFrom) as well as in r.Form,
+// r.PostForm and r.MultipartForm. Requests with malformed bodies
+// get 400 Bad Request, oversized ones 413 Request Entity Too Large.
+func ParseForm(maxMemory int64) Constructor {
+	return ParseFormLimit(maxMemory, DefaultMaxFormSize)
+}
+
+// ParseFormLimit works like ParseForm,
+// limiting bodies to maxBytes instead.
+func ParseFormLimit(maxMemory, maxBytes int64) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			var err error
+			if mt == "multipart/form-data" {
+				err = r.ParseMultipartForm(maxMemory)
+			} else {
+				err = r.ParseForm()
+			}
+			if r.MultipartForm != nil {
+				defer r.MultipartForm.RemoveAll()
+			}
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				WriteError(ctx, w, &HTTPError{Status: http.StatusRequestEntityTooLarge, Err: err})
+				return
+			} else if err != nil {
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusBadRequest,
+					Code:   "malformed_form",
+					Detail: err.Error(),
+					Err:    err,
+				})
+				return
+			}
+			ctx = context.WithValue(ctx, formKey{}, parsedForm{r.Form, r.MultipartForm})
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+// FormFrom returns the form values parsed by ParseForm,
+// including the query parameters.
+func FormFrom(ctx context.Context) url.Values {
+	f, _ := ctx.Value(formKey{}).(parsedForm)
+	return f.values
+}
+
+// MultipartFormFrom returns the multipart form parsed by ParseForm,
+// or nil if the request was not multipart.
+func MultipartFormFrom(ctx context.Context) *multipart.Form {
+	f, _ := ctx.Value(formKey{}).(parsedForm)
+	return f.multipart
+}
@@ -0,0 +1,69 @@
+package alice
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestParseFormURLEncoded(t *testing.T) {
+	var name, page string
+	h := New(ParseForm(1<<20)).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		name = FormFrom(ctx).Get("name")
+		page = FormFrom(ctx).Get("page")
+	}))
+
+	r := httptest.NewRequest("POST", "/?page=2", strings.NewReader("name=alice"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, name, "alice")
+	assert.Equal(t, page, "2")
+}
+
+func TestParseFormMultipartRemovesTempFiles(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "report")
+	fw, _ := mw.CreateFormFile("file", "report.txt")
+	fw.Write(bytes.Repeat([]byte("x"), 1024))
+	mw.Close()
+
+	var title, tmp string
+	h := New(ParseForm(16)).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		form := MultipartFormFrom(ctx)
+		title = form.Value["title"][0]
+		f, _ := form.File["file"][0].Open()
+		tmp = f.(*os.File).Name()
+		f.Close()
+	}))
+
+	r := httptest.NewRequest("POST", "/", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, title, "report")
+	_, err := os.Stat(tmp)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestParseFormLimit(t *testing.T) {
+	h := New(ParseFormLimit(1<<20, 8)).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader("name=alice+smith"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, w.Code, http.StatusRequestEntityTooLarge)
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader("%zz"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
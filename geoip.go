@@ -0,0 +1,54 @@
+package alice
+
+import (
+	"net/http"
+	"net/netip"
+
+	"golang.org/x/net/context"
+)
+
+// GeoInfo is the location of a client address.
+type GeoInfo struct {
+	// Country is the ISO 3166-1 country code, e.g. "DE".
+	Country string
+	// Region is the ISO 3166-2 subdivision code, e.g. "BY".
+	Region string
+	// City is the English city name.
+	City string
+}
+
+// A GeoDB looks up the location of addresses,
+// typically backed by a MaxMind-style database (see package maxmind).
+type GeoDB interface {
+	Lookup(ip netip.Addr) (GeoInfo, error)
+}
+
+type geoKey struct{}
+
+// GeoIP returns a Constructor looking up the location of the client
+// and storing it in the context (see GeoFrom).
+// The client address resolved by RealIP is used if present,
+// the address of the connected peer otherwise.
+// Requests whose address cannot be located continue without a location.
+func GeoIP(db GeoDB) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(ctx)
+			if !ip.IsValid() {
+				ip = remoteIP(r)
+			}
+			if ip.IsValid() {
+				if geo, err := db.Lookup(ip); err == nil {
+					ctx = context.WithValue(ctx, geoKey{}, geo)
+				}
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+// GeoFrom returns the client location stored by GeoIP.
+func GeoFrom(ctx context.Context) (GeoInfo, bool) {
+	geo, ok := ctx.Value(geoKey{}).(GeoInfo)
+	return geo, ok
+}
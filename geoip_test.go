@@ -0,0 +1,42 @@
+package alice
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type mapGeoDB map[netip.Addr]GeoInfo
+
+func (db mapGeoDB) Lookup(ip netip.Addr) (GeoInfo, error) {
+	geo, ok := db[ip]
+	if !ok {
+		return GeoInfo{}, errors.New("not found")
+	}
+	return geo, nil
+}
+
+func TestGeoIP(t *testing.T) {
+	db := mapGeoDB{netip.MustParseAddr("198.51.100.1"): {Country: "DE", Region: "BY", City: "Munich"}}
+	var geo GeoInfo
+	var found bool
+	h := New(RealIP(&ProxyConfig{TrustedProxies: proxies, Headers: []string{"X-Forwarded-For"}}), GeoIP(db)).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		geo, found = GeoFrom(ctx)
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.True(t, found)
+	assert.Equal(t, geo.City, "Munich")
+
+	r = httptest.NewRequest("GET", "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.False(t, found)
+}
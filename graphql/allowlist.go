@@ -0,0 +1,73 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// QueryHash returns the hex SHA-256 hash identifying query in Allowlist,
+// as used by Apollo's automatic persisted queries.
+func QueryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// Allowlist returns a Constructor letting through only the GraphQL
+// requests for one of queries, for endpoints serving known clients.
+// Clients send either the query text or only its hash (see QueryHash)
+// in the persistedQuery extension:
+//
+//	{"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "…"}}}
+//
+// Requests for other queries get a 403 Forbidden "query_not_allowed"
+// error, and unknown hashes a 400 Bad Request "persisted_query_not_found"
+// one. The request is read once and passed on to Handler with the query
+// text filled in (see RequestFrom).
+func Allowlist(queries ...string) alice.Constructor {
+	allowed := make(map[string]string, len(queries))
+	for _, q := range queries {
+		allowed[QueryHash(q)] = q
+	}
+	return func(next alice.ContextHandler) alice.ContextHandler {
+		return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			req, err := RequestFrom(ctx, r)
+			if err != nil {
+				alice.WriteError(ctx, w, err)
+				return
+			}
+			if req.Query != "" {
+				if _, ok := allowed[QueryHash(req.Query)]; !ok {
+					alice.WriteError(ctx, w, &alice.HTTPError{
+						Status: http.StatusForbidden,
+						Code:   "query_not_allowed",
+						Detail: "the query is not in the allowlist",
+					})
+					return
+				}
+			} else {
+				q, ok := allowed[persistedHash(req)]
+				if !ok {
+					alice.WriteError(ctx, w, &alice.HTTPError{
+						Status: http.StatusBadRequest,
+						Code:   "persisted_query_not_found",
+						Detail: "PersistedQueryNotFound",
+					})
+					return
+				}
+				req.Query = q
+			}
+			next.ServeHTTPContext(context.WithValue(ctx, requestKey{}, req), w, r)
+		})
+	}
+}
+
+// persistedHash returns the hash of the persistedQuery extension of req.
+func persistedHash(req Request) string {
+	pq, _ := req.Extensions["persistedQuery"].(map[string]any)
+	hash, _ := pq["sha256Hash"].(string)
+	return hash
+}
@@ -0,0 +1,40 @@
+package graphql
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowlist(t *testing.T) {
+	allowlist := Allowlist("{ me }", "query Users { users }")
+
+	w := serve(post("application/json", `{"query":"{ me }"}`), allowlist)
+	assert.Equal(t, w.Code, http.StatusOK)
+
+	w = serve(post("application/json", `{"query":"{ users { password } }"}`), allowlist)
+	assert.Equal(t, w.Code, http.StatusForbidden)
+	assert.Contains(t, w.Body.String(), `"code":"query_not_allowed"`)
+}
+
+func TestAllowlistPersistedQueries(t *testing.T) {
+	allowlist := Allowlist("{ me }", "query Users { users }")
+	hash := QueryHash("query Users { users }")
+
+	w := serve(post("application/json", `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"`+hash+`"}}}`), allowlist)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.JSONEq(t, w.Body.String(), `{"data":{"tenant":"acme","query":"query Users { users }","variables":null}}`)
+
+	w = serve(get(url.Values{"extensions": {`{"persistedQuery":{"version":1,"sha256Hash":"` + hash + `"}}`}}), allowlist)
+	assert.Equal(t, w.Code, http.StatusOK)
+
+	w = serve(post("application/json", `{"extensions":{"persistedQuery":{"version":1,"sha256Hash":"abc"}}}`), allowlist)
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+	assert.Contains(t, w.Body.String(), `"code":"persisted_query_not_found"`)
+}
+
+func TestQueryHash(t *testing.T) {
+	assert.Equal(t, QueryHash("{ me }"), "b7e4ef0c41abe27fe98d162502c81bdd0611cd1b7555f1d6cf8d12b822111ba5")
+}
@@ -0,0 +1,306 @@
+// Package graphql serves GraphQL endpoints at the end of alice chains,
+// independently of the GraphQL implementation: resolvers run with the
+// chain's context, their errors are mapped by the chain's ErrorMapper,
+// and Allowlist restricts the endpoint to persisted queries.
+//
+//	exec := graphql.ExecutorFunc(func(ctx context.Context, req graphql.Request) *graphql.Result {
+//	    res := gql.Do(gql.Params{
+//	        Schema:         schema,
+//	        RequestString:  req.Query,
+//	        OperationName:  req.OperationName,
+//	        VariableValues: req.Variables,
+//	        Context:        ctx,
+//	    })
+//	    return adapt(res)
+//	})
+//	mux.Handle("/graphql", chain.Append(graphql.Allowlist(queries...)).ThenWithContext(ctx, graphql.Handler(exec)))
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// MaxBodySize is the largest request body Handler reads.
+const MaxBodySize = 1 << 20
+
+// Request is a GraphQL request.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+// Result is the outcome of a GraphQL operation.
+type Result struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Error is a GraphQL error. Errors carrying the resolver error behind
+// them in Err are mapped by the chain's ErrorMapper (see alice.MapErrors):
+// the message becomes the Detail of the HTTPError, or the text of its
+// status, and the extensions get its "code" and "status", so internal
+// errors are not exposed.
+type Error struct {
+	Message    string         `json:"message"`
+	Locations  []Location     `json:"locations,omitempty"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+	// Err is the resolver error, if any.
+	Err error `json:"-"`
+}
+
+// Location is a position in the query document.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// An Executor runs GraphQL operations, typically by adapting
+// a GraphQL library. It passes ctx on to resolvers.
+type Executor interface {
+	Execute(ctx context.Context, req Request) *Result
+}
+
+// ExecutorFunc adapts a function to an Executor.
+type ExecutorFunc func(ctx context.Context, req Request) *Result
+
+// Execute calls f(ctx, req).
+func (f ExecutorFunc) Execute(ctx context.Context, req Request) *Result {
+	return f(ctx, req)
+}
+
+type requestKey struct{}
+
+// Handler returns a ContextHandler executing the GraphQL requests it
+// receives with exec and the chain's context: POST requests with
+// a JSON or application/graphql body, and GET requests with the query,
+// operationName, variables and extensions parameters. GET requests
+// cannot run mutations or subscriptions. Results are written as JSON;
+// requests that cannot be read get a 400 Bad Request error.
+func Handler(exec Executor) alice.ContextHandler {
+	return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		req, err := RequestFrom(ctx, r)
+		if err != nil {
+			alice.WriteError(ctx, w, err)
+			return
+		}
+		if req.Query == "" {
+			alice.WriteError(ctx, w, badRequest("missing query"))
+			return
+		}
+		if r.Method == "GET" && operationType(req.Query, req.OperationName) != "query" {
+			w.Header().Set("Allow", "POST")
+			alice.WriteError(ctx, w, &alice.HTTPError{
+				Status: http.StatusMethodNotAllowed,
+				Code:   "graphql_get_not_query",
+				Detail: "only queries can be sent with GET",
+			})
+			return
+		}
+
+		res := exec.Execute(ctx, req)
+		if res == nil {
+			res = &Result{}
+		}
+		for _, e := range res.Errors {
+			mapError(ctx, e)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(res)
+	})
+}
+
+// RequestFrom returns the GraphQL request of r, as already read by
+// Allowlist or else read from r. Errors are *alice.HTTPError.
+func RequestFrom(ctx context.Context, r *http.Request) (Request, error) {
+	if req, ok := ctx.Value(requestKey{}).(Request); ok {
+		return req, nil
+	}
+	return readRequest(r)
+}
+
+func readRequest(r *http.Request) (Request, error) {
+	var req Request
+	switch r.Method {
+	case "GET":
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		for name, dst := range map[string]*map[string]any{"variables": &req.Variables, "extensions": &req.Extensions} {
+			if v := q.Get(name); v != "" {
+				if err := json.Unmarshal([]byte(v), dst); err != nil {
+					return req, badRequest("invalid " + name + ": " + err.Error())
+				}
+			}
+		}
+	case "POST":
+		mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, MaxBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return req, &alice.HTTPError{Status: http.StatusRequestEntityTooLarge, Err: err}
+		} else if err != nil {
+			return req, badRequest(err.Error())
+		}
+		switch mt {
+		case "application/graphql":
+			req.Query = string(body)
+		case "application/json", "":
+			if err := json.Unmarshal(body, &req); err != nil {
+				return req, badRequest(err.Error())
+			}
+		default:
+			return req, &alice.HTTPError{Status: http.StatusUnsupportedMediaType, Detail: "unsupported Content-Type " + mt}
+		}
+	default:
+		return req, &alice.HTTPError{Status: http.StatusMethodNotAllowed}
+	}
+	return req, nil
+}
+
+func badRequest(detail string) error {
+	return &alice.HTTPError{Status: http.StatusBadRequest, Code: "invalid_graphql_request", Detail: detail}
+}
+
+// mapError replaces the message of e by the HTTPError its resolver
+// error maps to.
+func mapError(ctx context.Context, e *Error) {
+	if e.Err == nil {
+		return
+	}
+	httpErr := alice.MapError(ctx, e.Err)
+	status := httpErr.Status
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+	if status >= 500 {
+		alice.ReportError(ctx, e.Err)
+	}
+	e.Message = httpErr.Detail
+	if e.Message == "" {
+		e.Message = http.StatusText(status)
+	}
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]any)
+	}
+	e.Extensions["status"] = status
+	if httpErr.Code != "" {
+		e.Extensions["code"] = httpErr.Code
+	}
+	if httpErr.Fields != nil {
+		e.Extensions["fields"] = httpErr.Fields
+	}
+}
+
+// operationType returns the type ("query", "mutation" or "subscription")
+// of the operation of document named name, or of its only operation
+// if name is empty, scanning the top level of the document.
+func operationType(document, name string) string {
+	type operation struct{ typ, name string }
+	var ops []operation
+	// open is set between the keyword of a definition and its selection set.
+	depth, open, expectName := 0, false, false
+	for i := 0; i < len(document); {
+		c := document[i]
+		switch {
+		case c == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '"':
+			i = skipString(document, i)
+			expectName = false
+			continue
+		case isNameStart(c):
+			j := i
+			for j < len(document) && isNameChar(document[j]) {
+				j++
+			}
+			word := document[i:j]
+			i = j
+			if depth > 0 {
+				continue
+			}
+			switch {
+			case expectName:
+				ops[len(ops)-1].name = word
+				expectName = false
+			case !open && (word == "query" || word == "mutation" || word == "subscription"):
+				ops = append(ops, operation{typ: word})
+				open, expectName = true, true
+			case !open && word == "fragment":
+				ops = append(ops, operation{})
+				open = true
+			}
+			continue
+		case c == '{' && depth == 0:
+			if !open {
+				// A selection set alone is a query.
+				ops = append(ops, operation{typ: "query"})
+			}
+			open = false
+			depth++
+		case c == '{' || c == '(' || c == '[':
+			depth++
+		case c == '}' || c == ')' || c == ']':
+			depth--
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && c != ',' {
+			expectName = false
+		}
+		i++
+	}
+
+	var found string
+	for _, op := range ops {
+		if op.typ == "" {
+			continue
+		}
+		if name == "" {
+			if found != "" {
+				return ""
+			}
+			found = op.typ
+		} else if op.name == name {
+			return op.typ
+		}
+	}
+	return found
+}
+
+// skipString returns the index after the string starting at i.
+func skipString(document string, i int) int {
+	if strings.HasPrefix(document[i:], `"""`) {
+		if end := strings.Index(document[i+3:], `"""`); end >= 0 {
+			return i + 3 + end + 3
+		}
+		return len(document)
+	}
+	for i++; i < len(document); i++ {
+		switch document[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return i
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || '0' <= c && c <= '9'
+}
@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type tenantKey struct{}
+
+var errForbidden = errors.New("forbidden")
+
+// echo answers with the tenant of the context and the operation,
+// failing with a resolver error for operations named Secret.
+var echo = ExecutorFunc(func(ctx context.Context, req Request) *Result {
+	if req.OperationName == "Secret" {
+		return &Result{Errors: []*Error{
+			{Path: []any{"secret"}, Err: errForbidden},
+			{Path: []any{"db"}, Err: errors.New("connection string leaked")},
+		}}
+	}
+	return &Result{Data: map[string]any{
+		"tenant":    ctx.Value(tenantKey{}),
+		"query":     req.Query,
+		"variables": req.Variables,
+	}}
+})
+
+func serve(r *http.Request, cons ...alice.Constructor) *httptest.ResponseRecorder {
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	cons = append([]alice.Constructor{alice.MapErrors(func(err error) *alice.HTTPError {
+		if errors.Is(err, errForbidden) {
+			return &alice.HTTPError{Status: http.StatusForbidden, Code: "forbidden", Detail: "not yours"}
+		}
+		return alice.DefaultErrorMapper(err)
+	})}, cons...)
+	w := httptest.NewRecorder()
+	alice.New(cons...).ThenWithContext(ctx, Handler(echo)).ServeHTTP(w, r)
+	return w
+}
+
+func post(contentType, body string) *http.Request {
+	r := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	return r
+}
+
+func get(params url.Values) *http.Request {
+	return httptest.NewRequest("GET", "/graphql?"+params.Encode(), nil)
+}
+
+func TestHandlerPassesContext(t *testing.T) {
+	w := serve(post("application/json", `{"query":"{ me }","variables":{"n":1}}`))
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/json")
+	assert.JSONEq(t, w.Body.String(), `{"data":{"tenant":"acme","query":"{ me }","variables":{"n":1}}}`)
+
+	w = serve(post("application/graphql", "{ me }"))
+	assert.JSONEq(t, w.Body.String(), `{"data":{"tenant":"acme","query":"{ me }","variables":null}}`)
+
+	w = serve(get(url.Values{"query": {"{ me }"}, "variables": {`{"n":2}`}}))
+	assert.JSONEq(t, w.Body.String(), `{"data":{"tenant":"acme","query":"{ me }","variables":{"n":2}}}`)
+}
+
+func TestHandlerMapsResolverErrors(t *testing.T) {
+	w := serve(post("application/json", `{"query":"query Secret { secret db }","operationName":"Secret"}`))
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.JSONEq(t, w.Body.String(), `{"errors":[
+		{"message":"not yours","path":["secret"],"extensions":{"status":403,"code":"forbidden"}},
+		{"message":"Internal Server Error","path":["db"],"extensions":{"status":500}}
+	]}`)
+}
+
+func TestHandlerRejectsBadRequests(t *testing.T) {
+	for _, tt := range []struct {
+		r      *http.Request
+		status int
+	}{
+		{post("application/json", `{"query":`), http.StatusBadRequest},
+		{post("application/json", `{}`), http.StatusBadRequest},
+		{post("text/plain", `{ me }`), http.StatusUnsupportedMediaType},
+		{get(url.Values{"query": {"{ me }"}, "variables": {"{"}}), http.StatusBadRequest},
+		{get(url.Values{"query": {"mutation { kill }"}}), http.StatusMethodNotAllowed},
+		{httptest.NewRequest("PUT", "/graphql", nil), http.StatusMethodNotAllowed},
+	} {
+		w := serve(tt.r)
+		assert.Equal(t, w.Code, tt.status)
+		assert.Equal(t, w.Header().Get("Content-Type"), "application/problem+json")
+	}
+}
+
+func TestOperationType(t *testing.T) {
+	for _, tt := range []struct {
+		document, name, typ string
+	}{
+		{"{ me }", "", "query"},
+		{"query { me }", "", "query"},
+		{"mutation Kill($id: ID!) { kill(id: $id) }", "", "mutation"},
+		{"# mutation\nquery Q { me }", "", "query"},
+		{`query Q { f(s: "} mutation { ") }`, "", "query"},
+		{"query A { a } mutation B { b }", "B", "mutation"},
+		{"query A { a } mutation B { b }", "A", "query"},
+		{"query A { a } mutation B { b }", "", ""},
+		{"fragment F on User { name } subscription S { s ...F }", "", "subscription"},
+		{"query A { a }", "C", ""},
+	} {
+		assert.Equal(t, operationType(tt.document, tt.name), tt.typ, tt.document)
+	}
+}
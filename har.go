@@ -0,0 +1,216 @@
+package alice
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HAR is an HTTP Archive (HAR 1.2) document,
+// readable by browser developer tools and HAR viewers.
+// Only the parts filled from captured exchanges are modeled.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of a HAR document.
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the application that created the HAR document.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is one exchange of a HAR document.
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest is the request of a HAR entry.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	Cookies     []HARNameValue `json:"cookies"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+}
+
+// HARResponse is the response of a HAR entry.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a header, query parameter or cookie.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is a request body.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is a response body.
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// HARTimings break down the time of a HAR entry, in milliseconds.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// HAR returns the captured exchanges as a HAR document, oldest first.
+func (b *CaptureBuffer) HAR() *HAR {
+	exchanges := b.Exchanges()
+	har := &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "alice"},
+		Entries: make([]HAREntry, len(exchanges)),
+	}}
+	for i, ex := range exchanges {
+		har.Log.Entries[len(exchanges)-1-i] = harEntry(ex)
+	}
+	return har
+}
+
+func harEntry(ex CapturedExchange) HAREntry {
+	ms := float64(ex.Duration) / float64(time.Millisecond)
+	e := HAREntry{
+		StartedDateTime: ex.Time,
+		Time:            ms,
+		Request: HARRequest{
+			Method:      ex.Method,
+			URL:         ex.URL,
+			HTTPVersion: ex.Proto,
+			Headers:     harHeaders(ex.RequestHeader),
+			QueryString: []HARNameValue{},
+			Cookies:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(ex.RequestBody),
+		},
+		Response: HARResponse{
+			Status:      ex.Status,
+			StatusText:  http.StatusText(ex.Status),
+			HTTPVersion: ex.Proto,
+			Headers:     harHeaders(ex.ResponseHeader),
+			Cookies:     []HARNameValue{},
+			Content: HARContent{
+				Size:     len(ex.ResponseBody),
+				MimeType: ex.ResponseHeader.Get("Content-Type"),
+				Text:     ex.ResponseBody,
+			},
+			RedirectURL: ex.ResponseHeader.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(ex.ResponseBody),
+		},
+		Timings: HARTimings{Wait: ms},
+	}
+	if u, err := url.Parse(ex.URL); err == nil {
+		if !u.IsAbs() {
+			u.Scheme, u.Host = "http", ex.Host
+			e.Request.URL = u.String()
+		}
+		for k, vs := range u.Query() {
+			for _, v := range vs {
+				e.Request.QueryString = append(e.Request.QueryString, HARNameValue{k, v})
+			}
+		}
+	}
+	if ex.RequestBody != "" {
+		e.Request.PostData = &HARPostData{
+			MimeType: ex.RequestHeader.Get("Content-Type"),
+			Text:     ex.RequestBody,
+		}
+	}
+	return e
+}
+
+func harHeaders(h http.Header) []HARNameValue {
+	out := []HARNameValue{}
+	for k, vs := range h {
+		for _, v := range vs {
+			out = append(out, HARNameValue{k, v})
+		}
+	}
+	return out
+}
+
+// WriteHAR writes the captured exchanges to w as a HAR document.
+func (b *CaptureBuffer) WriteHAR(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b.HAR())
+}
+
+// ReadHAR reads the entries of a HAR document as captured exchanges,
+// e.g. to Replay traffic saved from the requests endpoint or a browser.
+func ReadHAR(r io.Reader) ([]CapturedExchange, error) {
+	var har HAR
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return nil, err
+	}
+	exchanges := make([]CapturedExchange, len(har.Log.Entries))
+	for i, e := range har.Log.Entries {
+		ex := CapturedExchange{
+			ID:             uint64(i + 1),
+			Time:           e.StartedDateTime,
+			Duration:       time.Duration(e.Time * float64(time.Millisecond)),
+			Method:         e.Request.Method,
+			URL:            e.Request.URL,
+			Proto:          e.Request.HTTPVersion,
+			RequestHeader:  http.Header{},
+			Status:         e.Response.Status,
+			ResponseHeader: http.Header{},
+			ResponseBody:   e.Response.Content.Text,
+		}
+		for _, h := range e.Request.Headers {
+			// Pseudo-headers of HTTP/2 captures are not request headers.
+			if !strings.HasPrefix(h.Name, ":") {
+				ex.RequestHeader.Add(h.Name, h.Value)
+			}
+		}
+		for _, h := range e.Response.Headers {
+			ex.ResponseHeader.Add(h.Name, h.Value)
+		}
+		if u, err := url.Parse(e.Request.URL); err == nil {
+			ex.Host = u.Host
+		}
+		if e.Request.PostData != nil {
+			ex.RequestBody = e.Request.PostData.Text
+		}
+		exchanges[i] = ex
+	}
+	return exchanges, nil
+}
@@ -0,0 +1,58 @@
+package alice
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func captureTraffic(t *testing.T) *CaptureBuffer {
+	buf := NewCaptureBuffer(10)
+	buf.Enable()
+	h := New(Capture(CaptureOptions{Buffer: buf})).ThenWithContext(context.Background(), echoBody)
+	for _, body := range []string{"first", "second"} {
+		r := httptest.NewRequest("POST", "/echo?n=1", strings.NewReader(body))
+		r.Header.Set("Content-Type", "text/plain")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	return buf
+}
+
+func TestCaptureBufferHAR(t *testing.T) {
+	har := captureTraffic(t).HAR()
+	assert.Equal(t, har.Log.Version, "1.2")
+	assert.Equal(t, len(har.Log.Entries), 2)
+
+	e := har.Log.Entries[0]
+	assert.Equal(t, e.Request.URL, "http://example.com/echo?n=1")
+	assert.Equal(t, e.Request.QueryString, []HARNameValue{{"n", "1"}})
+	assert.Equal(t, e.Request.PostData.Text, "first")
+	assert.Equal(t, e.Response.Status, http.StatusOK)
+	assert.Equal(t, e.Response.Content.Text, ":first")
+}
+
+func TestReadHARRoundTrip(t *testing.T) {
+	var b bytes.Buffer
+	assert.Nil(t, captureTraffic(t).WriteHAR(&b))
+
+	exchanges, err := ReadHAR(&b)
+	assert.Nil(t, err)
+	assert.Equal(t, len(exchanges), 2)
+	assert.Equal(t, exchanges[1].Method, "POST")
+	assert.Equal(t, exchanges[1].Host, "example.com")
+	assert.Equal(t, exchanges[1].RequestBody, "second")
+	assert.Equal(t, exchanges[1].RequestHeader.Get("Content-Type"), "text/plain")
+}
+
+func TestDebugServesHAR(t *testing.T) {
+	w := serveDebug(t, DebugOptions{Requests: captureTraffic(t)}, "/debug/requests?format=har")
+	body, _ := io.ReadAll(w.Body)
+	assert.Equal(t, w.Header().Get("Content-Disposition"), `attachment; filename="requests.har"`)
+	assert.Contains(t, string(body), `"version": "1.2"`)
+}
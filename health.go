@@ -0,0 +1,107 @@
+package alice
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// A Check reports whether a component is healthy.
+// It should respect ctx, which is canceled when the client goes away.
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Health aggregates liveness and readiness checks
+// registered by middleware and application code.
+// The zero value is ready to use.
+//
+//	var health alice.Health
+//	health.AddReadiness("db", func(ctx context.Context) error {
+//	    return db.PingContext(ctx)
+//	})
+//	mux.Handle("/healthz", alice.NewContextAdapter(ctx, health.Healthz()))
+//	mux.Handle("/readyz", alice.NewContextAdapter(ctx, health.Readyz()))
+type Health struct {
+	mu        sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+}
+
+// AddLiveness registers a check that fails when the process
+// needs to be restarted.
+func (h *Health) AddLiveness(name string, c Check) {
+	h.mu.Lock()
+	h.liveness = append(h.liveness, namedCheck{name, c})
+	h.mu.Unlock()
+}
+
+// AddReadiness registers a check that fails when the process
+// cannot currently serve traffic.
+func (h *Health) AddReadiness(name string, c Check) {
+	h.mu.Lock()
+	h.readiness = append(h.readiness, namedCheck{name, c})
+	h.mu.Unlock()
+}
+
+// Healthz returns a ContextHandler running all liveness checks.
+// It responds 200 if all of them pass and 503 otherwise,
+// listing the result of every check in the body.
+func (h *Health) Healthz() ContextHandler {
+	return h.handler("healthz", func() []namedCheck {
+		return h.liveness
+	})
+}
+
+// Readyz is like Healthz, but runs the readiness checks.
+func (h *Health) Readyz() ContextHandler {
+	return h.handler("readyz", func() []namedCheck {
+		return h.readiness
+	})
+}
+
+func (h *Health) handler(kind string, checks func() []namedCheck) ContextHandler {
+	return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		h.mu.RLock()
+		cs := checks()
+		h.mu.RUnlock()
+
+		// The checks stop with the request of the client.
+		cctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-r.Context().Done():
+				cancel()
+			case <-cctx.Done():
+			}
+		}()
+
+		var body bytes.Buffer
+		failed := false
+		for _, c := range cs {
+			if err := c.check(cctx); err != nil {
+				failed = true
+				fmt.Fprintf(&body, "[-]%s failed: %v\n", c.name, err)
+			} else {
+				fmt.Fprintf(&body, "[+]%s ok\n", c.name)
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if failed {
+			fmt.Fprintf(&body, "%s check failed\n", kind)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			fmt.Fprintf(&body, "%s check passed\n", kind)
+		}
+		body.WriteTo(w)
+	})
+}
@@ -0,0 +1,64 @@
+package alice
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func serveHealth(t *testing.T, h ContextHandler) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.ServeHTTPContext(context.Background(), w, r)
+	return w
+}
+
+func TestHealthPasses(t *testing.T) {
+	var health Health
+	health.AddLiveness("loop", func(ctx context.Context) error {
+		return nil
+	})
+
+	w := serveHealth(t, health.Healthz())
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), "[+]loop ok\nhealthz check passed\n")
+}
+
+func TestHealthReportsFailingReadiness(t *testing.T) {
+	var health Health
+	health.AddReadiness("cache", func(ctx context.Context) error {
+		return nil
+	})
+	health.AddReadiness("db", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+
+	w := serveHealth(t, health.Readyz())
+	assert.Equal(t, w.Code, http.StatusServiceUnavailable)
+	assert.Equal(t, w.Body.String(), "[+]cache ok\n[-]db failed: connection refused\nreadyz check failed\n")
+
+	// Readiness failures do not affect liveness.
+	assert.Equal(t, serveHealth(t, health.Healthz()).Code, http.StatusOK)
+}
+
+func TestHealthChecksStopWithRequest(t *testing.T) {
+	var health Health
+	health.AddReadiness("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	health.Readyz().ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/", nil).WithContext(reqCtx))
+	assert.Equal(t, w.Code, http.StatusServiceUnavailable)
+	assert.Contains(t, w.Body.String(), "[-]slow failed: context canceled")
+}
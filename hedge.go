@@ -0,0 +1,138 @@
+package alice
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Hedge returns a TransportConstructor sending a duplicate of an outbound
+// request whenever no response arrived within delay, up to maxAttempts
+// requests in total, failed ones included, cutting tail latency of slow
+// upstreams:
+//
+//	transport := alice.NewTransport(alice.Hedge(50*time.Millisecond, 3))
+//
+// The first response, whatever its status, is returned and the other
+// attempts are cancelled through their contexts. An attempt failing with
+// an error is replaced by a new one right away while attempts are left;
+// the last error is returned once all attempts failed. Like Retry, Hedge only duplicates
+// idempotent requests whose body can be replayed, and sends the others
+// once. Put Retry before Hedge to retry error statuses.
+func Hedge(delay time.Duration, maxAttempts int) TransportConstructor {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if maxAttempts < 2 || !canRetry(req) {
+				return next.RoundTrip(req)
+			}
+			h := &hedge{next: next, req: req, results: make(chan hedgeResult, maxAttempts)}
+			if err := h.launch(); err != nil {
+				return nil, err
+			}
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+
+			limit, pending := maxAttempts, 1
+			var lastErr error
+			for {
+				select {
+				case res := <-h.results:
+					pending--
+					if res.err == nil {
+						return h.win(res, pending), nil
+					}
+					lastErr = res.err
+				case <-timer.C:
+					if len(h.cancels) < limit {
+						timer.Reset(delay)
+					}
+				}
+				if len(h.cancels) < limit && req.Context().Err() == nil {
+					if err := h.launch(); err != nil {
+						// The body cannot be replayed anymore.
+						limit, lastErr = len(h.cancels), err
+					} else {
+						pending++
+					}
+				}
+				if pending == 0 {
+					h.cancel(-1)
+					return nil, lastErr
+				}
+			}
+		})
+	}
+}
+
+// hedge tracks the attempts of a hedged request.
+type hedge struct {
+	next    http.RoundTripper
+	req     *http.Request
+	results chan hedgeResult
+	cancels []context.CancelFunc
+}
+
+type hedgeResult struct {
+	attempt int
+	resp    *http.Response
+	err     error
+}
+
+// launch starts another attempt.
+func (h *hedge) launch() error {
+	ctx, cancel := context.WithCancel(h.req.Context())
+	r := h.req.Clone(ctx)
+	if len(h.cancels) > 0 && h.req.GetBody != nil {
+		body, err := h.req.GetBody()
+		if err != nil {
+			cancel()
+			return err
+		}
+		r.Body = body
+	}
+	attempt := len(h.cancels)
+	h.cancels = append(h.cancels, cancel)
+	go func() {
+		resp, err := h.next.RoundTrip(r)
+		h.results <- hedgeResult{attempt: attempt, resp: resp, err: err}
+	}()
+	return nil
+}
+
+// win returns the response of res, cancelling the other attempts
+// and discarding the responses of the pending ones.
+func (h *hedge) win(res hedgeResult, pending int) *http.Response {
+	h.cancel(res.attempt)
+	go func() {
+		for ; pending > 0; pending-- {
+			if lost := <-h.results; lost.err == nil {
+				lost.resp.Body.Close()
+			}
+		}
+	}()
+	res.resp.Body = &cancelBody{ReadCloser: res.resp.Body, cancel: h.cancels[res.attempt]}
+	return res.resp
+}
+
+// cancel cancels every attempt but the one numbered except.
+func (h *hedge) cancel(except int) {
+	for i, cancel := range h.cancels {
+		if i != except {
+			cancel()
+		}
+	}
+}
+
+// cancelBody cancels the context of the request it answers when closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
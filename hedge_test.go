@@ -0,0 +1,111 @@
+package alice
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// slowFirstTransport blocks its first call until the request is cancelled,
+// reporting the cancellation on cancelled, and answers the others with
+// their attempt number and body.
+func slowFirstTransport(calls *atomic.Int32, cancelled chan<- error) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		n := calls.Add(1)
+		if n == 1 {
+			<-req.Context().Done()
+			cancelled <- req.Context().Err()
+			return nil, req.Context().Err()
+		}
+		body := ""
+		if req.Body != nil {
+			b, _ := io.ReadAll(req.Body)
+			body = string(b)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Attempt": {string('0' + rune(n))}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+}
+
+func TestHedgeUsesFirstResponse(t *testing.T) {
+	var calls atomic.Int32
+	cancelled := make(chan error, 1)
+	rt := NewTransport(Hedge(10*time.Millisecond, 3)).Then(slowFirstTransport(&calls, cancelled))
+
+	req, _ := http.NewRequest("PUT", "http://example.com/", strings.NewReader("data"))
+	resp, err := rt.RoundTrip(req)
+	if assert.NoError(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, resp.Header.Get("Attempt"), "2")
+		assert.Equal(t, string(body), "data")
+	}
+	assert.Equal(t, <-cancelled, context.Canceled)
+	assert.Equal(t, calls.Load(), int32(2))
+}
+
+func TestHedgeSkipsFastResponses(t *testing.T) {
+	var calls atomic.Int32
+	rt := NewTransport(Hedge(time.Hour, 3)).Then(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	}))
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	resp, err := rt.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, calls.Load(), int32(1))
+}
+
+func TestHedgeSkipsUnsafeRequests(t *testing.T) {
+	var calls atomic.Int32
+	cancelled := make(chan error, 1)
+	rt := NewTransport(Hedge(time.Millisecond, 3)).Then(slowFirstTransport(&calls, cancelled))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "POST", "http://example.com/", strings.NewReader("data"))
+	_, err := rt.RoundTrip(req)
+	assert.Equal(t, err, context.DeadlineExceeded)
+	assert.Equal(t, calls.Load(), int32(1))
+}
+
+func TestHedgeReplacesFailedAttempts(t *testing.T) {
+	var calls atomic.Int32
+	fail := errors.New("connection refused")
+	rt := NewTransport(Hedge(time.Hour, 3)).Then(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return nil, fail
+	}))
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	_, err := rt.RoundTrip(req)
+	assert.Equal(t, err, fail)
+	assert.Equal(t, calls.Load(), int32(3))
+}
+
+func TestHedgeCancelsWinnerOnClose(t *testing.T) {
+	var reqCtx context.Context
+	rt := NewTransport(Hedge(time.Hour, 2)).Then(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		reqCtx = req.Context()
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+	}))
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	resp, err := rt.RoundTrip(req)
+	assert.NoError(t, err)
+	assert.NoError(t, reqCtx.Err())
+	resp.Body.Close()
+	assert.Equal(t, reqCtx.Err(), context.Canceled)
+}
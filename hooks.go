@@ -0,0 +1,48 @@
+package alice
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// After returns a Constructor calling fn once the rest of the chain
+// has returned, giving it access to the recorded response.
+// fn also runs if a later handler panics; the panic continues afterwards.
+// This suits audit logging and cleanup tasks.
+//
+//	alice.New(alice.After(func(ctx context.Context, rec *alice.ResponseRecorder, r *http.Request) {
+//	    audit.Record(r.Method, r.URL.Path, rec.Status())
+//	}))
+func After(fn func(context.Context, *ResponseRecorder, *http.Request)) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			rec := NewResponseRecorder(w)
+			defer fn(ctx, rec, r)
+			next.ServeHTTPContext(ctx, rec, r)
+		})
+	}
+}
+
+// Around returns a Constructor built from a pair of functions:
+// pre runs before the rest of the chain and may return a new context
+// to pass on, and the function it returns, if not nil,
+// runs afterwards (also on panic, like After).
+//
+//	timer := alice.Around(func(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, func()) {
+//	    start := time.Now()
+//	    return ctx, func() {
+//	        log.Println(r.URL.Path, time.Since(start))
+//	    }
+//	})
+func Around(pre func(context.Context, http.ResponseWriter, *http.Request) (context.Context, func())) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			ctx, post := pre(ctx, w, r)
+			if post != nil {
+				defer post()
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestAfterRunsAfterHandler(t *testing.T) {
+	var order []string
+	after := After(func(ctx context.Context, rec *ResponseRecorder, r *http.Request) {
+		order = append(order, "after")
+		assert.Equal(t, rec.Status(), http.StatusCreated)
+	})
+	app := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		order = append(order, "app")
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	r, err := http.NewRequest("POST", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	New(after).ThenWithContext(context.Background(), app).ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, order, []string{"app", "after"})
+}
+
+func TestAfterRunsOnPanic(t *testing.T) {
+	ran := false
+	after := After(func(ctx context.Context, rec *ResponseRecorder, r *http.Request) {
+		ran = true
+	})
+	app := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Panics(t, func() {
+		New(after).ThenWithContext(context.Background(), app).ServeHTTP(httptest.NewRecorder(), r)
+	})
+	assert.True(t, ran)
+}
+
+func TestAroundWrapsHandler(t *testing.T) {
+	var order []string
+	around := Around(func(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, func()) {
+		order = append(order, "pre")
+		return context.WithValue(ctx, "field", "value"), func() {
+			order = append(order, "post")
+		}
+	})
+	app := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		order = append(order, "app:"+ctx.Value("field").(string))
+	})
+
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	New(around).ThenWithContext(context.Background(), app).ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, order, []string{"pre", "app:value", "post"})
+}
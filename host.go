@@ -0,0 +1,129 @@
+package alice
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// HostSwitch dispatches requests to chains by the Host header,
+// so one listener can serve several virtual hosts
+// with different middleware stacks.
+// The zero value is ready to use.
+//
+//	var hosts alice.HostSwitch
+//	hosts.Handle("api.example.com", apiChain, api)
+//	hosts.Handle("*.example.com", webChain, web)
+//	http.ListenAndServe(":8080", alice.NewContextAdapter(ctx, &hosts))
+type HostSwitch struct {
+	// NotFound handles requests for unknown hosts.
+	// If nil, they get 404 Not Found.
+	NotFound ContextHandler
+
+	mu        sync.RWMutex
+	hosts     map[string]ContextHandler
+	wildcards map[string]ContextHandler
+	routes    map[string]branch
+}
+
+// Handle serves requests for host with h wrapped in chain.
+// host may start with "*." to match any subdomain,
+// in which case the longest matching wildcard wins.
+// Exact hosts take precedence over wildcards.
+func (hs *HostSwitch) Handle(host string, chain Chain, h ContextHandler) {
+	hs.mu.Lock()
+	defer hs.mu.Unlock()
+	if hs.hosts == nil {
+		hs.hosts = make(map[string]ContextHandler)
+		hs.wildcards = make(map[string]ContextHandler)
+		hs.routes = make(map[string]branch)
+	}
+
+	host = normalizeHost(host)
+	layers := chain.layers(h)
+	hs.routes[host] = branch{label: host, chain: chain, layers: layers}
+	if strings.HasPrefix(host, "*.") {
+		hs.wildcards[host[1:]] = layers[0]
+	} else {
+		hs.hosts[host] = layers[0]
+	}
+}
+
+// branches returns a branch per host, for WriteDOT.
+func (hs *HostSwitch) branches() []branch {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	branches := make([]branch, 0, len(hs.routes))
+	for _, b := range hs.routes {
+		branches = append(branches, b)
+	}
+	return branches
+}
+
+// ServeHTTPContext dispatches the request to the handler of its host.
+func (hs *HostSwitch) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if h := hs.match(normalizeHost(r.Host)); h != nil {
+		h.ServeHTTPContext(ctx, w, r)
+	} else if hs.NotFound != nil {
+		hs.NotFound.ServeHTTPContext(ctx, w, r)
+	} else {
+		http.NotFound(w, r)
+	}
+}
+
+func (hs *HostSwitch) match(host string) ContextHandler {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	if h, ok := hs.hosts[host]; ok {
+		return h
+	}
+	// Try ".b.example.com", then ".example.com", then ".com".
+	for i := strings.IndexByte(host, '.'); i >= 0; {
+		if h, ok := hs.wildcards[host[i:]]; ok {
+			return h
+		}
+		next := strings.IndexByte(host[i+1:], '.')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return nil
+}
+
+// normalizeHost lowercases host and strips its port and trailing dot.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+type subdomainKey struct{}
+
+// Subdomain returns a Constructor storing the part of the request host
+// in front of baseDomain in the context (see SubdomainFrom),
+// e.g. "acme" for "acme.example.com" or "acme.eu" for "acme.eu.example.com"
+// with baseDomain "example.com". Hosts outside baseDomain, and baseDomain
+// itself, have an empty subdomain.
+func Subdomain(baseDomain string) Constructor {
+	suffix := "." + normalizeHost(baseDomain)
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			var sub string
+			if host := normalizeHost(r.Host); strings.HasSuffix(host, suffix) {
+				sub = strings.TrimSuffix(host, suffix)
+			}
+			next.ServeHTTPContext(context.WithValue(ctx, subdomainKey{}, sub), w, r)
+		})
+	}
+}
+
+// SubdomainFrom returns the subdomain stored by Subdomain.
+func SubdomainFrom(ctx context.Context) string {
+	sub, _ := ctx.Value(subdomainKey{}).(string)
+	return sub
+}
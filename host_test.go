@@ -0,0 +1,95 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func writeTag(tag string) ContextHandler {
+	return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(tag))
+	})
+}
+
+func TestHostSwitch(t *testing.T) {
+	var hosts HostSwitch
+	hosts.Handle("api.example.com", New(), writeTag("api"))
+	hosts.Handle("*.example.com", New(), writeTag("wildcard"))
+	hosts.Handle("*.eu.example.com", New(), writeTag("eu"))
+
+	tests := []struct {
+		host, want string
+		code       int
+	}{
+		{"api.example.com", "api", http.StatusOK},
+		{"API.example.com:8080", "api", http.StatusOK},
+		{"www.example.com", "wildcard", http.StatusOK},
+		{"a.b.example.com", "wildcard", http.StatusOK},
+		{"shop.eu.example.com", "eu", http.StatusOK},
+		{"example.com", "404 page not found\n", http.StatusNotFound},
+		{"example.org", "404 page not found\n", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, err := http.NewRequest("GET", "/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Host = tt.host
+			hosts.ServeHTTPContext(context.Background(), w, r)
+
+			assert.Equal(t, w.Code, tt.code)
+			assert.Equal(t, w.Body.String(), tt.want)
+		})
+	}
+}
+
+func TestHostSwitchRunsChain(t *testing.T) {
+	var hosts HostSwitch
+	hosts.NotFound = writeTag("unknown")
+	hosts.Handle("example.com", New(func(h ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("m1 "))
+			h.ServeHTTPContext(ctx, w, r)
+		})
+	}), writeTag("app"))
+
+	for host, want := range map[string]string{"example.com": "m1 app", "example.org": "unknown"} {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Host = host
+		hosts.ServeHTTPContext(context.Background(), w, r)
+		assert.Equal(t, w.Body.String(), want)
+	}
+}
+
+func TestSubdomain(t *testing.T) {
+	tests := map[string]string{
+		"acme.example.com":       "acme",
+		"ACME.eu.example.com:80": "acme.eu",
+		"example.com":            "",
+		"acme.example.org":       "",
+		"notexample.com":         "",
+	}
+	for host, want := range tests {
+		var got string
+		h := Subdomain("example.com")(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			got = SubdomainFrom(ctx)
+		}))
+		r, err := http.NewRequest("GET", "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Host = host
+		h.ServeHTTPContext(context.Background(), httptest.NewRecorder(), r)
+		assert.Equal(t, got, want, host)
+	}
+}
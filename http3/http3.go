@@ -0,0 +1,56 @@
+// Package http3 serves alice chains over HTTP/3 (QUIC)
+// using quic-go.
+//
+// A Server plugs into alice.Server as its HTTP3 field:
+//
+//	srv := &alice.Server{
+//	    Addr:     ":443",
+//	    Handler:  chain.ThenWithContext(ctx, h),
+//	    CertFile: "cert.pem",
+//	    KeyFile:  "key.pem",
+//	    HTTP3:    http3.New(":443", "cert.pem", "key.pem"),
+//	}
+package http3
+
+import (
+	"net/http"
+
+	quichttp3 "github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/context"
+)
+
+// Server is an alice.AltServer serving over QUIC.
+type Server struct {
+	srv               *quichttp3.Server
+	certFile, keyFile string
+}
+
+// New creates a Server listening on the UDP address addr
+// with the given TLS certificate and key.
+func New(addr, certFile, keyFile string) *Server {
+	return &Server{
+		srv:      &quichttp3.Server{Addr: addr},
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+}
+
+// ListenAndServe serves h over HTTP/3 until Shutdown is called.
+func (s *Server) ListenAndServe(h http.Handler) error {
+	s.srv.Handler = h
+	err := s.srv.ListenAndServeTLS(s.certFile, s.keyFile)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// SetAltSvc sets the Alt-Svc header advertising the HTTP/3 endpoint.
+func (s *Server) SetAltSvc(hdr http.Header) error {
+	return s.srv.SetQUICHeaders(hdr)
+}
+
+// Shutdown gracefully stops the server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.srv.Shutdown(ctx)
+}
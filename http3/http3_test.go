@@ -0,0 +1,16 @@
+package http3
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetAltSvcBeforeListening(t *testing.T) {
+	s := New(":8443", "", "")
+	hdr := http.Header{}
+
+	assert.NotNil(t, s.SetAltSvc(hdr))
+	assert.Equal(t, hdr.Get("Alt-Svc"), "")
+}
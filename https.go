@@ -0,0 +1,107 @@
+package alice
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// HTTPSOptions configure RequireHTTPS.
+// Build them per environment, e.g. without HSTS in staging
+// and exempting "localhost" in development.
+type HTTPSOptions struct {
+	// TrustedProxies are the addresses of TLS-terminating proxies
+	// whose X-Forwarded-Proto and Forwarded headers are believed.
+	// If nil, those of the ProxyConfig of the chain or Server are.
+	TrustedProxies []netip.Prefix
+	// Port is the HTTPS port redirects point to,
+	// "" for the default port.
+	Port string
+
+	// HSTSMaxAge, if positive, makes secure responses carry
+	// a Strict-Transport-Security header with this max-age.
+	HSTSMaxAge time.Duration
+	// HSTSIncludeSubdomains and HSTSPreload add the
+	// includeSubDomains and preload directives.
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+
+	// Exempt lists hosts served over plain HTTP, such as "localhost".
+	Exempt []string
+}
+
+// RequireHTTPS returns a Constructor redirecting plain HTTP requests
+// to the same URL over HTTPS, with 301 Moved Permanently for GET and
+// HEAD and 308 Permanent Redirect otherwise, so bodies are resent.
+// Requests count as secure when served over TLS, or when a trusted
+// proxy says it received them over HTTPS.
+// Secure responses get the Strict-Transport-Security header.
+func RequireHTTPS(opts HTTPSOptions) Constructor {
+	var hsts string
+	if opts.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(opts.HSTSMaxAge/time.Second))
+		if opts.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if opts.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			host := strings.Trim(normalizeHost(r.Host), "[]")
+			if contains(opts.Exempt, host) {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			proxies := opts.TrustedProxies
+			if cfg, ok := proxyConfig(ctx, r); ok && proxies == nil {
+				proxies = cfg.TrustedProxies
+			}
+			if !isSecure(r, proxies) {
+				if opts.Port != "" {
+					host = net.JoinHostPort(host, opts.Port)
+				} else if strings.Contains(host, ":") {
+					host = "[" + host + "]"
+				}
+				status := http.StatusPermanentRedirect
+				if r.Method == "GET" || r.Method == "HEAD" {
+					status = http.StatusMovedPermanently
+				}
+				http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+				return
+			}
+			if hsts != "" {
+				w.Header().Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+// isSecure reports whether r reached us, or a trusted proxy, over HTTPS.
+func isSecure(r *http.Request, proxies []netip.Prefix) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if !trusted(remoteIP(r), proxies) {
+		return false
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		// The proxy closest to the client comes first.
+		first, _, _ := strings.Cut(proto, ",")
+		return strings.EqualFold(strings.TrimSpace(first), "https")
+	}
+	for _, pair := range strings.Split(strings.Split(r.Header.Get("Forwarded"), ",")[0], ";") {
+		k, v, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if strings.EqualFold(k, "proto") {
+			return strings.EqualFold(strings.Trim(v, `"`), "https")
+		}
+	}
+	return false
+}
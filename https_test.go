@@ -0,0 +1,62 @@
+package alice
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func serveHTTPS(opts HTTPSOptions, r *http.Request) *httptest.ResponseRecorder {
+	h := New(RequireHTTPS(opts)).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestRequireHTTPSRedirects(t *testing.T) {
+	w := serveHTTPS(HTTPSOptions{}, httptest.NewRequest("GET", "http://example.com:8080/a?b=c", nil))
+	assert.Equal(t, w.Code, http.StatusMovedPermanently)
+	assert.Equal(t, w.Header().Get("Location"), "https://example.com/a?b=c")
+
+	w = serveHTTPS(HTTPSOptions{Port: "8443"}, httptest.NewRequest("POST", "http://example.com/a", nil))
+	assert.Equal(t, w.Code, http.StatusPermanentRedirect)
+	assert.Equal(t, w.Header().Get("Location"), "https://example.com:8443/a")
+}
+
+func TestRequireHTTPSSetsHSTS(t *testing.T) {
+	opts := HTTPSOptions{HSTSMaxAge: 365 * 24 * time.Hour, HSTSIncludeSubdomains: true}
+	r := httptest.NewRequest("GET", "https://example.com/", nil)
+	r.TLS = &tls.ConnectionState{}
+	w := serveHTTPS(opts, r)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("Strict-Transport-Security"), "max-age=31536000; includeSubDomains")
+}
+
+func TestRequireHTTPSTrustsProxies(t *testing.T) {
+	opts := HTTPSOptions{TrustedProxies: proxies}
+
+	r := httptest.NewRequest("GET", "http://example.com/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-Proto", "https")
+	assert.Equal(t, serveHTTPS(opts, r).Code, http.StatusOK)
+
+	r = httptest.NewRequest("GET", "http://example.com/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("Forwarded", `for=192.0.2.1;proto=https`)
+	assert.Equal(t, serveHTTPS(opts, r).Code, http.StatusOK)
+
+	r = httptest.NewRequest("GET", "http://example.com/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("X-Forwarded-Proto", "https")
+	assert.Equal(t, serveHTTPS(opts, r).Code, http.StatusMovedPermanently)
+}
+
+func TestRequireHTTPSExemptHosts(t *testing.T) {
+	w := serveHTTPS(HTTPSOptions{Exempt: []string{"localhost"}}, httptest.NewRequest("GET", "http://localhost:3000/", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+}
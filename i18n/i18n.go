@@ -0,0 +1,66 @@
+// Package i18n detects the locale of requests served by alice chains.
+package i18n
+
+import (
+	"net/http"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+	"golang.org/x/text/language"
+)
+
+// Options configure locale overrides.
+type Options struct {
+	// Query, if set, names a query parameter overriding the locale,
+	// e.g. "lang" for "?lang=de".
+	Query string
+	// Cookie, if set, names a cookie overriding the locale.
+	// The query parameter takes precedence over it.
+	Cookie string
+}
+
+type localeKey struct{}
+
+// Locale returns a Constructor matching the request's Accept-Language header
+// against the supported tags and storing the match in the context
+// (see LocaleFrom). The first supported tag is the fallback.
+// It panics if no tag is supported.
+func Locale(supported ...language.Tag) alice.Constructor {
+	return LocaleWith(Options{}, supported...)
+}
+
+// LocaleWith works like Locale, additionally honoring the overrides in opts.
+func LocaleWith(opts Options, supported ...language.Tag) alice.Constructor {
+	if len(supported) == 0 {
+		panic("i18n: Locale: no supported tags")
+	}
+	matcher := language.NewMatcher(supported)
+
+	return func(next alice.ContextHandler) alice.ContextHandler {
+		return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			var prefs []string
+			if opts.Query != "" {
+				prefs = append(prefs, r.URL.Query().Get(opts.Query))
+			}
+			if opts.Cookie != "" {
+				if c, err := r.Cookie(opts.Cookie); err == nil {
+					prefs = append(prefs, c.Value)
+				}
+			}
+			prefs = append(prefs, r.Header.Get("Accept-Language"))
+			w.Header().Add("Vary", "Accept-Language")
+
+			_, i := language.MatchStrings(matcher, prefs...)
+			next.ServeHTTPContext(context.WithValue(ctx, localeKey{}, supported[i]), w, r)
+		})
+	}
+}
+
+// LocaleFrom returns the locale chosen by Locale,
+// or language.Und if Locale did not run.
+func LocaleFrom(ctx context.Context) language.Tag {
+	if tag, ok := ctx.Value(localeKey{}).(language.Tag); ok {
+		return tag
+	}
+	return language.Und
+}
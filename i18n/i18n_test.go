@@ -0,0 +1,58 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"golang.org/x/text/language"
+)
+
+func detect(t *testing.T, c alice.Constructor, url string, prepare func(*http.Request)) language.Tag {
+	var tag language.Tag
+	h := c(alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		tag = LocaleFrom(ctx)
+	}))
+
+	r, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prepare(r)
+	h.ServeHTTPContext(context.Background(), httptest.NewRecorder(), r)
+	return tag
+}
+
+func TestLocaleMatchesAcceptLanguage(t *testing.T) {
+	c := Locale(language.English, language.German)
+
+	tag := detect(t, c, "/", func(r *http.Request) {
+		r.Header.Set("Accept-Language", "fr;q=0.9, de-CH;q=0.8")
+	})
+	assert.Equal(t, tag, language.German)
+
+	tag = detect(t, c, "/", func(r *http.Request) {})
+	assert.Equal(t, tag, language.English)
+}
+
+func TestLocaleOverrides(t *testing.T) {
+	c := LocaleWith(Options{Query: "lang", Cookie: "lang"}, language.English, language.German, language.French)
+	withCookie := func(r *http.Request) {
+		r.Header.Set("Accept-Language", "de")
+		r.AddCookie(&http.Cookie{Name: "lang", Value: "fr"})
+	}
+
+	assert.Equal(t, detect(t, c, "/", withCookie), language.French)
+	assert.Equal(t, detect(t, c, "/?lang=en", withCookie), language.English)
+}
+
+func TestLocaleFromWithoutLocale(t *testing.T) {
+	assert.Equal(t, LocaleFrom(context.Background()), language.Und)
+}
+
+func TestLocaleRequiresTags(t *testing.T) {
+	assert.PanicsWithValue(t, "i18n: Locale: no supported tags", func() { Locale() })
+}
@@ -0,0 +1,225 @@
+package alice
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// StoredResponse is a response recorded by Idempotency.
+type StoredResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyStore persists responses by idempotency key.
+// Implementations must be safe for concurrent use.
+type IdempotencyStore interface {
+	// Lock reserves key for a request in flight.
+	// It returns false if key is already reserved.
+	Lock(ctx context.Context, key string) (bool, error)
+	// Unlock releases the reservation of key.
+	Unlock(ctx context.Context, key string) error
+	// Get returns the response stored for key, or nil if there is none.
+	Get(ctx context.Context, key string) (*StoredResponse, error)
+	// Put stores the response for key.
+	Put(ctx context.Context, key string, resp *StoredResponse) error
+}
+
+// Idempotency returns a Constructor making unsafe requests
+// (POST, PUT, PATCH, DELETE) carrying an Idempotency-Key header safe to retry:
+// the first response for a key is stored and replayed for duplicates,
+// and duplicates arriving while the first one is in flight
+// get 409 Conflict. Server errors (5xx) are not stored.
+// Keys are scoped to the request method and path.
+func Idempotency(store IdempotencyStore) Constructor {
+	return IdempotencyKeyed(store, func(ctx context.Context, r *http.Request) string {
+		header := r.Header.Get("Idempotency-Key")
+		if header == "" || !isUnsafe(r.Method) {
+			return ""
+		}
+		return r.Method + " " + r.URL.Path + " " + header
+	})
+}
+
+// IdempotencyKeyed is like Idempotency with the key of a request
+// derived by key, such as the event ID of a webhook delivery.
+// Requests for which key returns "" are passed through.
+func IdempotencyKeyed(store IdempotencyStore, key func(ctx context.Context, r *http.Request) string) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			key := key(ctx, r)
+			if key == "" {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+
+			locked, err := store.Lock(ctx, key)
+			if err != nil {
+				WriteError(ctx, w, err)
+				return
+			}
+			if !locked {
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusConflict,
+					Detail: "a request with this key is in progress",
+				})
+				return
+			}
+			defer store.Unlock(ctx, key)
+
+			stored, err := store.Get(ctx, key)
+			if err != nil {
+				WriteError(ctx, w, err)
+				return
+			}
+			if stored != nil {
+				replay(w, stored)
+				return
+			}
+
+			tee := &teeWriter{ResponseRecorder: NewResponseRecorder(w)}
+			next.ServeHTTPContext(ctx, tee, r)
+			if status := tee.Status(); status < http.StatusInternalServerError {
+				store.Put(ctx, key, &StoredResponse{
+					Status: status,
+					Header: tee.Header().Clone(),
+					Body:   tee.body.Bytes(),
+				})
+			}
+		})
+	}
+}
+
+func isUnsafe(method string) bool {
+	switch method {
+	case "POST", "PUT", "PATCH", "DELETE":
+		return true
+	}
+	return false
+}
+
+func replay(w http.ResponseWriter, resp *StoredResponse) {
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// teeWriter keeps a copy of the response body.
+type teeWriter struct {
+	*ResponseRecorder
+	body bytes.Buffer
+}
+
+func (t *teeWriter) Write(b []byte) (int, error) {
+	n, err := t.ResponseRecorder.Write(b)
+	t.body.Write(b[:n])
+	return n, err
+}
+
+type memoryEntry struct {
+	resp    *StoredResponse
+	locked  bool
+	expires time.Time
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore
+// keeping responses for a limited time.
+type MemoryIdempotencyStore struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	swept   time.Time
+}
+
+// NewMemoryIdempotencyStore returns a store keeping responses for ttl.
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{ttl: ttl, entries: make(map[string]*memoryEntry)}
+}
+
+// live returns the entry of key, or nil if it has none or it expired.
+// s.mu must be held.
+func (s *MemoryIdempotencyStore) live(key string, now time.Time) *memoryEntry {
+	e, ok := s.entries[key]
+	if !ok || (!e.locked && now.After(e.expires)) {
+		return nil
+	}
+	return e
+}
+
+// entry returns the live entry of key, creating it if needed,
+// and drops the expired entries once per ttl so that unique keys
+// do not pile up. s.mu must be held.
+func (s *MemoryIdempotencyStore) entry(key string) *memoryEntry {
+	now := time.Now()
+	if now.Sub(s.swept) > s.ttl {
+		for k, e := range s.entries {
+			if !e.locked && now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.swept = now
+	}
+	e := s.live(key, now)
+	if e == nil {
+		e = &memoryEntry{expires: now.Add(s.ttl)}
+		s.entries[key] = e
+	}
+	return e
+}
+
+// Lock reserves key, unless it is already reserved.
+func (s *MemoryIdempotencyStore) Lock(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entry(key)
+	if e.locked {
+		return false, nil
+	}
+	e.locked = true
+	return true, nil
+}
+
+// Unlock releases the reservation of key,
+// forgetting key if it has no stored response.
+func (s *MemoryIdempotencyStore) Unlock(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.live(key, time.Now())
+	if e == nil {
+		return nil
+	}
+	e.locked = false
+	if e.resp == nil {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Get returns the response stored for key, or nil if there is none
+// or it expired.
+func (s *MemoryIdempotencyStore) Get(ctx context.Context, key string) (*StoredResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.live(key, time.Now()); e != nil {
+		return e.resp, nil
+	}
+	return nil, nil
+}
+
+// Put stores the response for key, keeping it for the ttl of the store.
+func (s *MemoryIdempotencyStore) Put(ctx context.Context, key string, resp *StoredResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entry(key)
+	e.resp = resp
+	e.expires = time.Now().Add(s.ttl)
+	return nil
+}
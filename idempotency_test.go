@@ -0,0 +1,89 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestIdempotencyReplaysResponses(t *testing.T) {
+	calls := 0
+	app := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Location", "/payments/"+strconv.Itoa(calls))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("payment " + strconv.Itoa(calls)))
+	})
+	h := Idempotency(NewMemoryIdempotencyStore(time.Minute))(app)
+
+	post := func(key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/payments", nil)
+		r.Header.Set("Idempotency-Key", key)
+		h.ServeHTTPContext(context.Background(), w, r)
+		return w
+	}
+
+	first := post("k1")
+	second := post("k1")
+	other := post("k2")
+
+	assert.Equal(t, calls, 2)
+	assert.Equal(t, second.Code, http.StatusCreated)
+	assert.Equal(t, second.Body.String(), first.Body.String())
+	assert.Equal(t, second.Header().Get("Location"), "/payments/1")
+	assert.Equal(t, second.Header().Get("Idempotent-Replayed"), "true")
+	assert.Equal(t, other.Body.String(), "payment 2")
+}
+
+func TestIdempotencyRejectsConcurrentDuplicates(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Minute)
+	var h ContextHandler
+	h = Idempotency(store)(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		// A duplicate arriving while this request is in flight.
+		dup := httptest.NewRecorder()
+		h.ServeHTTPContext(ctx, dup, r)
+		assert.Equal(t, dup.Code, http.StatusConflict)
+	}))
+
+	r := httptest.NewRequest("POST", "/payments", nil)
+	r.Header.Set("Idempotency-Key", "k1")
+	h.ServeHTTPContext(context.Background(), httptest.NewRecorder(), r)
+}
+
+func TestIdempotencyIgnoresSafeMethods(t *testing.T) {
+	calls := 0
+	h := Idempotency(NewMemoryIdempotencyStore(time.Minute))(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest("GET", "/payments", nil)
+		r.Header.Set("Idempotency-Key", "k1")
+		h.ServeHTTPContext(context.Background(), httptest.NewRecorder(), r)
+	}
+	assert.Equal(t, calls, 2)
+}
+
+func TestMemoryIdempotencyStoreDropsExpiredEntries(t *testing.T) {
+	store := NewMemoryIdempotencyStore(time.Millisecond)
+	ctx := context.Background()
+	for _, key := range []string{"evt_1", "evt_2"} {
+		store.Lock(ctx, key)
+		store.Put(ctx, key, &StoredResponse{Status: http.StatusOK})
+		store.Unlock(ctx, key)
+	}
+	resp, _ := store.Get(ctx, "unknown")
+	assert.Nil(t, resp)
+	assert.Len(t, store.entries, 2)
+
+	time.Sleep(5 * time.Millisecond)
+	resp, _ = store.Get(ctx, "evt_1")
+	assert.Nil(t, resp)
+	store.Lock(ctx, "evt_3")
+	assert.Len(t, store.entries, 1)
+}
@@ -0,0 +1,44 @@
+package alice
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// IOTimeouts returns a Constructor overriding the read and write
+// timeouts of the server (see Server.ReadTimeout and WriteTimeout)
+// for the rest of the chain, through http.ResponseController.
+// A positive timeout sets a deadline that far from now, a negative one
+// removes the deadline and zero keeps the server's. Branches with
+// other needs than the rest of the application get their own:
+//
+//	site := alice.New(
+//	    alice.Mount("/uploads", alice.New(alice.IOTimeouts(10*time.Minute, 0)), uploads),
+//	    alice.Mount("/events", alice.New(alice.IOTimeouts(0, -1)), events),
+//	).ThenWithContext(ctx, web)
+//
+// Writers not supporting deadlines, such as test recorders, are left alone.
+func IOTimeouts(read, write time.Duration) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			rc := http.NewResponseController(w)
+			if read != 0 {
+				rc.SetReadDeadline(deadline(read))
+			}
+			if write != 0 {
+				rc.SetWriteDeadline(deadline(write))
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+// deadline returns the deadline d from now, or none if d is negative.
+func deadline(d time.Duration) time.Time {
+	if d < 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(d)
+}
@@ -0,0 +1,50 @@
+package alice
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestIOTimeouts(t *testing.T) {
+	slow := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+	site := New(Mount("/stream", New(IOTimeouts(0, -1)), slow)).ThenWithContext(context.Background(), slow)
+	srv := httptest.NewUnstartedServer(site)
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	get := func(path string) (string, error) {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	body, err := get("/stream")
+	assert.Nil(t, err)
+	assert.Equal(t, body, "done")
+
+	_, err = get("/")
+	assert.NotNil(t, err)
+}
+
+func TestIOTimeoutsWithoutDeadlines(t *testing.T) {
+	h := New(IOTimeouts(time.Second, time.Second)).ThenWithContext(context.Background(), testApp)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("hi")))
+
+	assert.Equal(t, w.Body.String(), "app\n")
+}
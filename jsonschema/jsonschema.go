@@ -0,0 +1,83 @@
+// Package jsonschema provides alice.Schema implementations
+// backed by JSON Schema (draft 4 up to 2020-12),
+// for use with alice.Validate.
+package jsonschema
+
+import (
+	"strings"
+
+	"github.com/SimiPro/alice"
+	jsv "github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	s *jsv.Schema
+}
+
+// Compile compiles the JSON Schema document schema.
+//
+//	s := jsonschema.MustCompile(`{
+//	    "type": "object",
+//	    "required": ["email"],
+//	    "properties": {"email": {"type": "string", "format": "email"}}
+//	}`)
+//	chain := alice.New(alice.Validate(s))
+func Compile(schema string) (*Schema, error) {
+	c := jsv.NewCompiler()
+	c.AssertFormat = true
+	if err := c.AddResource("schema.json", strings.NewReader(schema)); err != nil {
+		return nil, err
+	}
+	s, err := c.Compile("schema.json")
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{s}, nil
+}
+
+// MustCompile is like Compile but panics if the schema is invalid.
+func MustCompile(schema string) *Schema {
+	s, err := Compile(schema)
+	if err != nil {
+		panic("jsonschema: " + err.Error())
+	}
+	return s
+}
+
+// ValidateJSON validates v, returning the messages of the violated
+// keywords keyed by the dotted location of the offending value
+// ("body" for the document itself).
+func (s *Schema) ValidateJSON(v any) map[string]string {
+	err := s.s.Validate(v)
+	if err == nil {
+		return nil
+	}
+	fields := make(map[string]string)
+	ve, ok := err.(*jsv.ValidationError)
+	if !ok {
+		fields["body"] = err.Error()
+		return fields
+	}
+	collect(ve, fields)
+	return fields
+}
+
+// collect records the innermost causes of ve.
+func collect(ve *jsv.ValidationError, fields map[string]string) {
+	if len(ve.Causes) == 0 {
+		field := strings.ReplaceAll(strings.TrimPrefix(ve.InstanceLocation, "/"), "/", ".")
+		if field == "" {
+			field = "body"
+		}
+		if _, ok := fields[field]; !ok {
+			fields[field] = ve.Message
+		}
+		return
+	}
+	for _, cause := range ve.Causes {
+		collect(cause, fields)
+	}
+}
+
+var _ alice.Schema = (*Schema)(nil)
@@ -0,0 +1,54 @@
+package jsonschema
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+const userSchema = `{
+	"type": "object",
+	"required": ["email"],
+	"properties": {
+		"email": {"type": "string", "format": "email"},
+		"address": {
+			"type": "object",
+			"properties": {"zip": {"type": "string", "pattern": "^[0-9]{5}$"}}
+		}
+	}
+}`
+
+func TestSchemaValidateJSON(t *testing.T) {
+	s := MustCompile(userSchema)
+
+	assert.Nil(t, s.ValidateJSON(map[string]any{"email": "alice@example.com"}))
+
+	fields := s.ValidateJSON(map[string]any{
+		"email":   "nope",
+		"address": map[string]any{"zip": "abc"},
+	})
+	assert.Equal(t, len(fields), 2)
+	assert.Contains(t, fields["email"], "email")
+	assert.Contains(t, fields["address.zip"], "does not match pattern")
+
+	fields = s.ValidateJSON(map[string]any{})
+	assert.Contains(t, fields["body"], "email")
+}
+
+func TestCompileRejectsInvalidSchema(t *testing.T) {
+	_, err := Compile(`{"type": 42}`)
+	assert.NotNil(t, err)
+}
+
+func TestValidateMiddleware(t *testing.T) {
+	h := alice.New(alice.Validate(MustCompile(userSchema))).ThenWithContext(context.Background(), alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(`{"email":"nope"}`)))
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
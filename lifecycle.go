@@ -0,0 +1,139 @@
+package alice
+
+import (
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// Starter is implemented by handlers needing setup before serving,
+// such as middleware opening connection pools or loading caches.
+type Starter interface {
+	Start(ctx context.Context) error
+}
+
+// Stopper is implemented by handlers owning resources
+// that must be released on shutdown.
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
+
+// Start calls Start on every handler built by the chain that
+// implements Starter, in request-flow order. If one fails, the
+// handlers already started are stopped and its error is returned.
+// Server calls Start before it begins serving.
+//
+// Middleware owning resources opts in by having its constructor
+// return a handler implementing Starter and Stopper:
+//
+//	func (l *Limiter) Middleware(next alice.ContextHandler) alice.ContextHandler {
+//	    return &limitHandler{limiter: l, next: next} // has Start and Stop
+//	}
+func (ca *ContextAdapter) Start(ctx context.Context) error {
+	layers := ca.lifecycleLayers()
+	for i, h := range layers {
+		s, ok := h.(Starter)
+		if !ok {
+			continue
+		}
+		if err := s.Start(ctx); err != nil {
+			stopLayers(ctx, layers[:i])
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop calls Stop on every handler built by the chain that
+// implements Stopper, in reverse request-flow order, and returns
+// their errors joined. Server calls Stop during shutdown, after
+// the listeners and background tasks are done.
+func (ca *ContextAdapter) Stop(ctx context.Context) error {
+	return stopLayers(ctx, ca.lifecycleLayers())
+}
+
+// lifecycleLayers returns the layers, leaving out the repeats
+// of constructors returning the handler they were given.
+func (ca *ContextAdapter) lifecycleLayers() []ContextHandler {
+	var layers []ContextHandler
+	for _, h := range ca.layers {
+		layers = appendDistinct(layers, h)
+	}
+	return layers
+}
+
+// appendDistinct appends v to list unless it is already in it.
+func appendDistinct[T any](list []T, v T) []T {
+	for _, seen := range list {
+		if identical(seen, v) {
+			return list
+		}
+	}
+	return append(list, v)
+}
+
+// identical reports whether a and b are equal. Values that cannot be
+// compared, such as funcs or structs holding one, never are.
+func identical(a, b any) (same bool) {
+	defer func() { recover() }()
+	return a == b
+}
+
+func stopLayers(ctx context.Context, layers []ContextHandler) error {
+	var errs []error
+	for i := len(layers) - 1; i >= 0; i-- {
+		if s, ok := layers[i].(Stopper); ok {
+			errs = append(errs, s.Stop(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lifecycleHandlers returns the distinct handlers of the server
+// that have a lifecycle, in start order.
+func (s *Server) lifecycleHandlers() []http.Handler {
+	var hs []http.Handler
+	add := func(h http.Handler) {
+		if h == nil {
+			return
+		}
+		_, start := h.(Starter)
+		_, stop := h.(Stopper)
+		if !start && !stop {
+			return
+		}
+		hs = appendDistinct(hs, h)
+	}
+	add(s.Handler)
+	for _, b := range s.Bindings {
+		add(b.Handler)
+	}
+	return hs
+}
+
+// start starts the server's handlers, stopping the started ones
+// if one fails.
+func (s *Server) start(ctx context.Context) error {
+	hs := s.lifecycleHandlers()
+	for i, h := range hs {
+		if st, ok := h.(Starter); ok {
+			if err := st.Start(ctx); err != nil {
+				s.stop(ctx, hs[:i])
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stop stops hs in reverse order.
+func (s *Server) stop(ctx context.Context, hs []http.Handler) error {
+	var errs []error
+	for i := len(hs) - 1; i >= 0; i-- {
+		if st, ok := hs[i].(Stopper); ok {
+			errs = append(errs, st.Stop(ctx))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package alice
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// resourceHandler is a middleware handler with a lifecycle.
+type resourceHandler struct {
+	ContextHandler
+	name     string
+	events   *[]string
+	startErr error
+}
+
+func (h *resourceHandler) Start(ctx context.Context) error {
+	*h.events = append(*h.events, "start "+h.name)
+	return h.startErr
+}
+
+func (h *resourceHandler) Stop(ctx context.Context) error {
+	*h.events = append(*h.events, "stop "+h.name)
+	return nil
+}
+
+func resource(name string, events *[]string, startErr error) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return &resourceHandler{ContextHandler: next, name: name, events: events, startErr: startErr}
+	}
+}
+
+func TestContextAdapterLifecycle(t *testing.T) {
+	var events []string
+	ca := New(resource("a", &events, nil), passThrough, resource("b", &events, nil)).ThenWithContext(context.Background(), echoBody)
+
+	assert.Nil(t, ca.Start(context.Background()))
+	assert.Nil(t, ca.Stop(context.Background()))
+	assert.Equal(t, events, []string{"start a", "start b", "stop b", "stop a"})
+}
+
+// stdHandler adapts an http.Handler; it is comparable unless
+// the handler it holds is a func.
+type stdHandler struct{ http.Handler }
+
+func (h stdHandler) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	h.ServeHTTP(w, r)
+}
+
+func TestContextAdapterLifecycleIncomparableHandler(t *testing.T) {
+	var events []string
+	final := stdHandler{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	ca := New(resource("a", &events, nil), passThrough).ThenWithContext(context.Background(), final)
+
+	assert.Nil(t, ca.Stop(context.Background()))
+	assert.Equal(t, events, []string{"stop a"})
+}
+
+func TestContextAdapterStartFailure(t *testing.T) {
+	var events []string
+	boom := errors.New("boom")
+	ca := New(resource("a", &events, nil), resource("b", &events, boom), resource("c", &events, nil)).ThenWithContext(context.Background(), echoBody)
+
+	assert.Equal(t, ca.Start(context.Background()), boom)
+	assert.Equal(t, events, []string{"start a", "start b", "stop a"})
+}
+
+func TestServerRunsLifecycle(t *testing.T) {
+	var events []string
+	ca := New(resource("a", &events, nil)).ThenWithContext(context.Background(), echoBody)
+	srv := &Server{
+		Addr:     "127.0.0.1:0",
+		Handler:  ca,
+		Bindings: []Binding{{Addr: "127.0.0.1:0", Handler: ca}, {Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Nil(t, srv.ListenAndServe(ctx))
+	assert.Equal(t, events, []string{"start a", "stop a"})
+}
@@ -0,0 +1,38 @@
+package alice
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// Logger is the minimal logging interface used by alice and its
+// built-in middleware. Arguments after msg are alternating keys and values.
+//
+// *slog.Logger implements Logger; the zapadapter, logrusadapter and
+// zerologadapter packages adapt other logging libraries.
+type Logger interface {
+	Debug(msg string, keysAndValues ...any)
+	Info(msg string, keysAndValues ...any)
+	Error(msg string, keysAndValues ...any)
+}
+
+type loggerBox struct {
+	Logger
+}
+
+var pkgLogger atomic.Value
+
+// SetLogger sets the Logger used by alice.
+// By default, alice logs to slog.Default().
+func SetLogger(l Logger) {
+	pkgLogger.Store(loggerBox{l})
+}
+
+// logger returns the Logger set by SetLogger,
+// falling back to the current slog.Default().
+func logger() Logger {
+	if box, ok := pkgLogger.Load().(loggerBox); ok && box.Logger != nil {
+		return box.Logger
+	}
+	return slog.Default()
+}
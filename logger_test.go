@@ -0,0 +1,33 @@
+package alice
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A Logger remembering the messages it was given.
+type memoryLogger struct {
+	lines []string
+}
+
+func (l *memoryLogger) log(level, msg string, kv []any) {
+	l.lines = append(l.lines, fmt.Sprint(level, " ", msg, kv))
+}
+
+func (l *memoryLogger) Debug(msg string, kv ...any) { l.log("DEBUG", msg, kv) }
+func (l *memoryLogger) Info(msg string, kv ...any)  { l.log("INFO", msg, kv) }
+func (l *memoryLogger) Error(msg string, kv ...any) { l.log("ERROR", msg, kv) }
+
+func TestSetLogger(t *testing.T) {
+	defer SetLogger(nil)
+	assert.Equal(t, logger(), slog.Default())
+
+	l := &memoryLogger{}
+	SetLogger(l)
+	logger().Info("hello", "k", "v")
+
+	assert.Equal(t, l.lines, []string{"INFO hello[k v]"})
+}
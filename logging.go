@@ -0,0 +1,80 @@
+package alice
+
+import (
+	"log/slog"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// LogAttrs extracts request-scoped attributes for the request logger,
+// such as the route or the authenticated user.
+type LogAttrs func(ctx context.Context, r *http.Request) []slog.Attr
+
+type loggerKey struct{}
+
+// Logging returns a Constructor that stores a request logger in the context,
+// derived from base (slog.Default() if nil) and enriched with
+// the request method, path, request ID (see RequestID), the client address
+// resolved by RealIP, or else through the ProxyConfig of the chain
+// or Server, the route (see WithRoute) and the given attrs.
+// Handlers retrieve it with LoggerFrom.
+func Logging(base *slog.Logger, attrs ...LogAttrs) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			logger := base
+			if logger == nil {
+				logger = slog.Default()
+			}
+			args := []any{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+			}
+			if id := requestIDOf(ctx, r); id != "" {
+				args = append(args, slog.String("request_id", id))
+			}
+			ip := ClientIP(ctx)
+			if cfg, ok := proxyConfig(ctx, r); ok && !ip.IsValid() {
+				ip = cfg.ClientIP(r)
+			}
+			if ip.IsValid() {
+				args = append(args, slog.String("client_ip", ip.String()))
+			}
+			if route := RouteLabel(ctx); route != "" {
+				args = append(args, slog.String("route", route))
+			}
+			for _, fn := range attrs {
+				for _, a := range fn(ctx, r) {
+					args = append(args, a)
+				}
+			}
+			ctx = WithLogger(ctx, logger.With(args...))
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+// WithLogger returns a context carrying logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// WithLogAttrs returns a context whose request logger
+// additionally carries attrs, so middleware running after Logging,
+// such as authentication, can enrich later log lines.
+func WithLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	args := make([]any, len(attrs))
+	for i, a := range attrs {
+		args[i] = a
+	}
+	return WithLogger(ctx, LoggerFrom(ctx).With(args...))
+}
+
+// LoggerFrom returns the request logger stored in ctx,
+// or slog.Default() if there is none.
+func LoggerFrom(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
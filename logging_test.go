@@ -0,0 +1,44 @@
+package alice
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestLoggingEnrichesRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewTextHandler(&buf, nil))
+	route := func(ctx context.Context, r *http.Request) []slog.Attr {
+		return []slog.Attr{slog.String("route", "/users/{id}")}
+	}
+	authenticate := func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTPContext(WithLogAttrs(ctx, slog.String("user", "alice")), w, r)
+		})
+	}
+	app := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		LoggerFrom(ctx).Info("hello")
+	})
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/users/1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("X-Request-Id", "abc")
+	New(Logging(base, route), authenticate).ThenWithContext(context.Background(), app).ServeHTTP(w, r)
+
+	line := buf.String()
+	assert.Contains(t, line, "msg=hello")
+	assert.Contains(t, line, "method=GET path=/users/1 request_id=abc route=/users/{id} user=alice")
+}
+
+func TestLoggerFromDefaultsToSlogDefault(t *testing.T) {
+	assert.Equal(t, LoggerFrom(context.Background()), slog.Default())
+}
@@ -0,0 +1,45 @@
+// Package logrusadapter adapts a logrus logger to alice.Logger.
+package logrusadapter
+
+import (
+	"fmt"
+
+	"github.com/SimiPro/alice"
+	"github.com/sirupsen/logrus"
+)
+
+type logger struct {
+	l logrus.FieldLogger
+}
+
+// New returns an alice.Logger writing to l,
+// which may be a *logrus.Logger or a *logrus.Entry.
+func New(l logrus.FieldLogger) alice.Logger {
+	return logger{l}
+}
+
+func (l logger) Debug(msg string, keysAndValues ...any) {
+	l.l.WithFields(fields(keysAndValues)).Debug(msg)
+}
+
+func (l logger) Info(msg string, keysAndValues ...any) {
+	l.l.WithFields(fields(keysAndValues)).Info(msg)
+}
+
+func (l logger) Error(msg string, keysAndValues ...any) {
+	l.l.WithFields(fields(keysAndValues)).Error(msg)
+}
+
+// fields converts alternating keys and values to logrus.Fields.
+// A trailing key without a value is logged under "!BADKEY".
+func fields(keysAndValues []any) logrus.Fields {
+	f := make(logrus.Fields, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			f["!BADKEY"] = keysAndValues[i]
+			break
+		}
+		f[fmt.Sprint(keysAndValues[i])] = keysAndValues[i+1]
+	}
+	return f
+}
@@ -0,0 +1,19 @@
+package logrusadapter
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	l, hook := test.NewNullLogger()
+	New(l).Error("failed", "status", 500, "dangling")
+
+	entry := hook.LastEntry()
+	assert.Equal(t, entry.Message, "failed")
+	assert.Equal(t, entry.Level, logrus.ErrorLevel)
+	assert.Equal(t, entry.Data, logrus.Fields{"status": 500, "!BADKEY": "dangling"})
+}
@@ -0,0 +1,120 @@
+package alice
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// LongPollSequenceHeader carries the sequence number of a LongPoll
+// value; clients send it back in the since query parameter.
+const LongPollSequenceHeader = "X-Poll-Sequence"
+
+// LongPoll holds the latest published value of type T and parks
+// clients waiting for a newer one. The zero value is not usable;
+// see NewLongPoll.
+type LongPoll[T any] struct {
+	mu      sync.Mutex
+	seq     uint64
+	value   T
+	changed chan struct{}
+}
+
+// NewLongPoll returns a LongPoll with no value published.
+func NewLongPoll[T any]() *LongPoll[T] {
+	return &LongPoll[T]{changed: make(chan struct{})}
+}
+
+// Publish stores v and wakes up every waiting client.
+// It returns the sequence number of v.
+func (lp *LongPoll[T]) Publish(v T) uint64 {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	lp.seq++
+	lp.value = v
+	close(lp.changed)
+	lp.changed = make(chan struct{})
+	return lp.seq
+}
+
+// Wait returns the latest value once its sequence number is past since,
+// or ctx's error if ctx is done first.
+func (lp *LongPoll[T]) Wait(ctx context.Context, since uint64) (T, uint64, error) {
+	for {
+		lp.mu.Lock()
+		v, seq, changed := lp.value, lp.seq, lp.changed
+		lp.mu.Unlock()
+		if seq > since {
+			return v, seq, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, seq, ctx.Err()
+		}
+	}
+}
+
+// Handler returns a handler long-polling lp. Requests wait for a value
+// newer than the since query parameter, which is then written as JSON
+// with its sequence number in the X-Poll-Sequence header. Requests
+// waiting longer than timeout, or past the context deadline if that
+// comes first, get 204 No Content and should poll again. A zero
+// timeout waits until the deadline or the client goes away.
+//
+//	updates := alice.NewLongPoll[Status]()
+//	mux.Handle("/status", chain.ThenWithContext(ctx, updates.Handler(30*time.Second)))
+func (lp *LongPoll[T]) Handler(timeout time.Duration) ContextHandler {
+	return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		var since uint64
+		if s := r.URL.Query().Get("since"); s != "" {
+			var err error
+			if since, err = strconv.ParseUint(s, 10, 64); err != nil {
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusBadRequest,
+					Detail: "invalid since parameter",
+					Err:    err,
+				})
+				return
+			}
+		}
+
+		var waitCtx context.Context
+		var cancel context.CancelFunc
+		if timeout > 0 {
+			waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		} else {
+			waitCtx, cancel = context.WithCancel(ctx)
+		}
+		defer cancel()
+		go func() {
+			select {
+			case <-r.Context().Done():
+				cancel()
+			case <-waitCtx.Done():
+			}
+		}()
+
+		v, seq, err := lp.Wait(waitCtx, since)
+		w.Header().Set(LongPollSequenceHeader, strconv.FormatUint(seq, 10))
+		w.Header().Set("Cache-Control", "no-store")
+		if err != nil {
+			if r.Context().Err() == nil {
+				w.WriteHeader(http.StatusNoContent)
+			}
+			return
+		}
+		body, err := json.Marshal(v)
+		if err != nil {
+			WriteError(ctx, w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(body, '\n'))
+	})
+}
@@ -0,0 +1,66 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestLongPollReturnsNewerValue(t *testing.T) {
+	lp := NewLongPoll[string]()
+	lp.Publish("a")
+
+	w := httptest.NewRecorder()
+	lp.Handler(time.Second).ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/?since=0", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), "\"a\"\n")
+	assert.Equal(t, w.Header().Get(LongPollSequenceHeader), "1")
+}
+
+func TestLongPollWakesWaitingClients(t *testing.T) {
+	lp := NewLongPoll[int]()
+	done := make(chan *httptest.ResponseRecorder)
+	for i := 0; i < 3; i++ {
+		go func() {
+			w := httptest.NewRecorder()
+			lp.Handler(0).ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/", nil))
+			done <- w
+		}()
+	}
+	// Publishing before the clients park is fine too: they see seq 1 > 0.
+	lp.Publish(42)
+	for i := 0; i < 3; i++ {
+		w := <-done
+		assert.Equal(t, w.Body.String(), "42\n")
+	}
+}
+
+func TestLongPollTimesOut(t *testing.T) {
+	lp := NewLongPoll[int]()
+	lp.Publish(1)
+
+	w := httptest.NewRecorder()
+	lp.Handler(10*time.Millisecond).ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/?since=1", nil))
+	assert.Equal(t, w.Code, http.StatusNoContent)
+	assert.Equal(t, w.Header().Get(LongPollSequenceHeader), "1")
+}
+
+func TestLongPollHonorsDeadline(t *testing.T) {
+	lp := NewLongPoll[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	w := httptest.NewRecorder()
+	lp.Handler(time.Hour).ServeHTTPContext(ctx, w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, w.Code, http.StatusNoContent)
+}
+
+func TestLongPollRejectsBadSince(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewLongPoll[int]().Handler(0).ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/?since=x", nil))
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
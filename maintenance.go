@@ -0,0 +1,115 @@
+package alice
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// MaintenanceSwitch turns maintenance mode on and off at runtime.
+// It serves itself for operators:
+//
+//	GET  /admin/maintenance                  {"enabled":false}
+//	POST /admin/maintenance?enabled=true     enter maintenance mode
+//	POST /admin/maintenance?enabled=false    leave it
+//
+// A new MaintenanceSwitch is off.
+type MaintenanceSwitch struct {
+	enabled atomic.Bool
+}
+
+// Enable enters maintenance mode.
+func (s *MaintenanceSwitch) Enable() { s.enabled.Store(true) }
+
+// Disable leaves maintenance mode.
+func (s *MaintenanceSwitch) Disable() { s.enabled.Store(false) }
+
+// Enabled reports whether maintenance mode is on.
+func (s *MaintenanceSwitch) Enabled() bool { return s.enabled.Load() }
+
+// ServeHTTPContext reports and sets the state of the switch.
+func (s *MaintenanceSwitch) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET", "HEAD":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Enabled bool `json:"enabled"`
+		}{s.Enabled()})
+	case "POST":
+		enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+		if err != nil {
+			WriteError(ctx, w, &HTTPError{Status: http.StatusBadRequest, Detail: "enabled must be true or false"})
+			return
+		}
+		s.enabled.Store(enabled)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		WriteError(ctx, w, &HTTPError{Status: http.StatusMethodNotAllowed})
+	}
+}
+
+// MaintenanceOptions configure Maintenance.
+type MaintenanceOptions struct {
+	// AllowIPs are the client networks still served, e.g. the office.
+	// Client IPs are resolved as by ProxyConfig.ClientIP.
+	AllowIPs []netip.Prefix
+	// AllowPaths are path prefixes still served, e.g. "/healthz"
+	// or the path of the switch itself. They match whole segments:
+	// "/healthz" allows "/healthz/db" but not "/healthzfoo".
+	AllowPaths []string
+	// Page is the HTML page served; if nil, a JSON error is.
+	Page []byte
+	// RetryAfter, if positive, is sent in the Retry-After header.
+	RetryAfter time.Duration
+}
+
+// Maintenance returns a Constructor answering requests with
+// 503 Service Unavailable while sw is on, except for allowed clients
+// and paths. Turning sw on and off needs neither a restart nor new chains.
+//
+//	var maintenance alice.MaintenanceSwitch
+//	chain := alice.New(alice.Maintenance(&maintenance, alice.MaintenanceOptions{
+//	    AllowPaths: []string{"/healthz"},
+//	    Page:       maintenancePage,
+//	}))
+//	admin.Handle("/admin/maintenance", alice.New().ThenWithContext(ctx, &maintenance))
+func Maintenance(sw *MaintenanceSwitch, opts MaintenanceOptions) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if !sw.Enabled() || maintenanceAllowed(ctx, r, opts) {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			if opts.RetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(max(opts.RetryAfter/time.Second, 1))))
+			}
+			if opts.Page == nil {
+				WriteError(ctx, w, &HTTPError{Status: http.StatusServiceUnavailable, Code: "maintenance"})
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write(opts.Page)
+		})
+	}
+}
+
+func maintenanceAllowed(ctx context.Context, r *http.Request, opts MaintenanceOptions) bool {
+	for _, p := range opts.AllowPaths {
+		if _, ok := cutPathPrefix(r.URL.Path, strings.TrimSuffix(p, "/")); ok {
+			return true
+		}
+	}
+	if len(opts.AllowIPs) == 0 {
+		return false
+	}
+	return trusted(requestIP(ctx, r), opts.AllowIPs)
+}
@@ -0,0 +1,68 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestMaintenance(t *testing.T) {
+	var sw MaintenanceSwitch
+	h := New(Maintenance(&sw, MaintenanceOptions{
+		AllowIPs:   []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")},
+		AllowPaths: []string{"/healthz"},
+		Page:       []byte("<h1>Back soon</h1>"),
+		RetryAfter: 5 * time.Minute,
+	})).ThenWithContext(context.Background(), testApp)
+	serve := func(path, addr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", path, nil)
+		r.RemoteAddr = addr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	assert.Equal(t, serve("/", "198.51.100.1:1234").Code, http.StatusOK)
+
+	sw.Enable()
+	w := serve("/", "198.51.100.1:1234")
+	assert.Equal(t, w.Code, http.StatusServiceUnavailable)
+	assert.Equal(t, w.Body.String(), "<h1>Back soon</h1>")
+	assert.Equal(t, w.Header().Get("Retry-After"), "300")
+	assert.Equal(t, serve("/healthz", "198.51.100.1:1234").Code, http.StatusOK)
+	assert.Equal(t, serve("/healthz/db", "198.51.100.1:1234").Code, http.StatusOK)
+	assert.Equal(t, serve("/healthzfoo", "198.51.100.1:1234").Code, http.StatusServiceUnavailable)
+	assert.Equal(t, serve("/", "192.0.2.7:1234").Code, http.StatusOK)
+
+	sw.Disable()
+	assert.Equal(t, serve("/", "198.51.100.1:1234").Code, http.StatusOK)
+}
+
+func TestMaintenanceSwitch(t *testing.T) {
+	var sw MaintenanceSwitch
+	h := New(Maintenance(&sw, MaintenanceOptions{})).ThenWithContext(context.Background(), testApp)
+	admin := New().ThenWithContext(context.Background(), &sw)
+
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("POST", "/admin/maintenance?enabled=true", nil))
+	assert.Equal(t, w.Code, http.StatusNoContent)
+	assert.True(t, sw.Enabled())
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("GET", "/admin/maintenance", nil))
+	assert.Equal(t, w.Body.String(), "{\"enabled\":true}\n")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, w.Code, http.StatusServiceUnavailable)
+	assert.Contains(t, w.Body.String(), `"code":"maintenance"`)
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("POST", "/admin/maintenance?enabled=maybe", nil))
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
@@ -0,0 +1,46 @@
+// Package maxmind provides an alice.GeoDB backed by
+// a MaxMind GeoIP2 or GeoLite2 City database.
+package maxmind
+
+import (
+	"net/netip"
+
+	"github.com/SimiPro/alice"
+	"github.com/oschwald/geoip2-golang"
+)
+
+// DB is an alice.GeoDB reading a MaxMind City database.
+type DB struct {
+	r *geoip2.Reader
+}
+
+// Open opens the database file at path,
+// e.g. "GeoLite2-City.mmdb".
+func Open(path string) (*DB, error) {
+	r, err := geoip2.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{r}, nil
+}
+
+// Lookup returns the location of ip.
+func (db *DB) Lookup(ip netip.Addr) (alice.GeoInfo, error) {
+	city, err := db.r.City(ip.AsSlice())
+	if err != nil {
+		return alice.GeoInfo{}, err
+	}
+	geo := alice.GeoInfo{
+		Country: city.Country.IsoCode,
+		City:    city.City.Names["en"],
+	}
+	if len(city.Subdivisions) > 0 {
+		geo.Region = city.Subdivisions[0].IsoCode
+	}
+	return geo, nil
+}
+
+// Close closes the database.
+func (db *DB) Close() error {
+	return db.r.Close()
+}
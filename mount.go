@@ -0,0 +1,83 @@
+package alice
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+type originalPathKey struct{}
+
+// Mount returns a Constructor dispatching requests under prefix
+// to h wrapped in chain, with the prefix stripped from the URL path.
+// Other requests continue down the chain it is part of,
+// so sub-applications compose without a router:
+//
+//	site := alice.New(
+//	    alice.Mount("/api", apiChain, api),
+//	    alice.Mount("/admin", adminChain, admin),
+//	).ThenWithContext(ctx, web)
+//
+// The unstripped path is stored in the context (see OriginalPath).
+// prefix matches whole path segments: "/api" matches "/api" and "/api/users",
+// but not "/apiary".
+func Mount(prefix string, chain Chain, h ContextHandler) Constructor {
+	prefix = "/" + strings.Trim(prefix, "/")
+	layers := chain.layers(h)
+	mounted := layers[0]
+
+	return func(next ContextHandler) ContextHandler {
+		serve := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			rest, ok := cutPathPrefix(r.URL.Path, prefix)
+			if !ok {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+
+			if _, ok := ctx.Value(originalPathKey{}).(string); !ok {
+				ctx = context.WithValue(ctx, originalPathKey{}, r.URL.Path)
+			}
+			r2 := new(http.Request)
+			*r2 = *r
+			u := *r.URL
+			u.Path = rest
+			if u.RawPath != "" {
+				if rawRest, ok := cutPathPrefix(u.RawPath, prefix); ok {
+					u.RawPath = rawRest
+				} else {
+					u.RawPath = ""
+				}
+			}
+			r2.URL = &u
+			mounted.ServeHTTPContext(ctx, w, r2)
+		})
+		return &branching{
+			ContextHandler: serve,
+			list:           []branch{{label: prefix, chain: chain, layers: layers}},
+		}
+	}
+}
+
+// cutPathPrefix strips prefix from path if it matches whole segments.
+// The result always starts with a slash.
+func cutPathPrefix(path, prefix string) (string, bool) {
+	if prefix == "/" {
+		return path, true
+	}
+	rest, ok := strings.CutPrefix(path, prefix)
+	if !ok || (rest != "" && rest[0] != '/') {
+		return "", false
+	}
+	if rest == "" {
+		rest = "/"
+	}
+	return rest, true
+}
+
+// OriginalPath returns the URL path of the request before Mount
+// stripped any prefixes from it, or "" if no Mount did.
+func OriginalPath(ctx context.Context) string {
+	p, _ := ctx.Value(originalPathKey{}).(string)
+	return p
+}
@@ -0,0 +1,54 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func writePath(tag string) ContextHandler {
+	return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(tag + " " + r.URL.Path + " " + OriginalPath(ctx)))
+	})
+}
+
+func TestMount(t *testing.T) {
+	site := New(
+		Mount("/api/", New(), writePath("api")),
+		Mount("/admin", New(), writePath("admin")),
+	).ThenWithContext(context.Background(), writePath("web"))
+
+	tests := map[string]string{
+		"/api":         "api / /api",
+		"/api/users/1": "api /users/1 /api/users/1",
+		"/admin/":      "admin / /admin/",
+		"/apiary":      "web /apiary ",
+		"/":            "web / ",
+	}
+	for path, want := range tests {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		site.ServeHTTP(w, r)
+		assert.Equal(t, w.Body.String(), want, path)
+	}
+}
+
+func TestMountNestedKeepsOriginalPath(t *testing.T) {
+	inner := New(Mount("/v1", New(), writePath("v1")))
+	outer := New(Mount("/api", inner, writePath("api")))
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/api/v1/users", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer.ThenWithContext(context.Background(), writePath("web")).ServeHTTP(w, r)
+
+	assert.Equal(t, w.Body.String(), "v1 /users /api/v1/users")
+}
@@ -0,0 +1,38 @@
+// Package msgpack provides an alice.Codec for MessagePack,
+// served as application/msgpack:
+//
+//	alice.RegisterCodec(msgpack.ContentType, msgpack.Codec{})
+//	chain := alice.New(alice.Negotiate("application/json", msgpack.ContentType))
+//
+// Struct fields are named by their msgpack tag, falling back to their json tag.
+package msgpack
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ContentType is the media type of MessagePack documents.
+const ContentType = "application/msgpack"
+
+// Codec marshals values as MessagePack.
+type Codec struct{}
+
+// Marshal encodes v.
+func (Codec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes data into v.
+func (Codec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
@@ -0,0 +1,56 @@
+package msgpack
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/SimiPro/alice/render"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type user struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	data, err := Codec{}.Marshal(user{"alice", 30})
+	assert.Nil(t, err)
+
+	var m map[string]any
+	assert.Nil(t, Codec{}.Unmarshal(data, &m))
+	assert.Equal(t, m["name"], "alice")
+
+	var u user
+	assert.Nil(t, Codec{}.Unmarshal(data, &u))
+	assert.Equal(t, u, user{"alice", 30})
+}
+
+func TestServesNegotiatedMsgpack(t *testing.T) {
+	alice.RegisterCodec(ContentType, Codec{})
+	h := alice.New(alice.Negotiate("application/json", ContentType)).ThenWithContext(context.Background(), alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		u, err := alice.Bind[user](ctx, r)
+		if err != nil {
+			alice.WriteError(ctx, w, err)
+			return
+		}
+		u.Age++
+		render.Respond(ctx, w, http.StatusOK, u)
+	}))
+
+	body, _ := Codec{}.Marshal(user{"alice", 30})
+	r := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	r.Header.Set("Content-Type", ContentType)
+	r.Header.Set("Accept", ContentType)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Header().Get("Content-Type"), ContentType)
+	var u user
+	assert.Nil(t, Codec{}.Unmarshal(w.Body.Bytes(), &u))
+	assert.Equal(t, u, user{"alice", 31})
+}
@@ -0,0 +1,106 @@
+package alice
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+type acceptedKey struct{}
+
+// Negotiate returns a Constructor choosing the media type of the response
+// among offers according to the request's Accept header.
+// The chosen type is stored in the context (see Accepted).
+// If none of the offers is acceptable, it responds 406 Not Acceptable.
+// Requests without an Accept header get the first offer.
+//
+//	alice.New(alice.Negotiate("application/json", "text/html"))
+func Negotiate(offers ...string) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept")
+			chosen := negotiate(r.Header.Get("Accept"), offers)
+			if chosen == "" {
+				WriteError(ctx, w, &HTTPError{Status: http.StatusNotAcceptable})
+				return
+			}
+			next.ServeHTTPContext(context.WithValue(ctx, acceptedKey{}, chosen), w, r)
+		})
+	}
+}
+
+// Accepted returns the media type chosen by Negotiate,
+// or "" if Negotiate did not run.
+func Accepted(ctx context.Context) string {
+	t, _ := ctx.Value(acceptedKey{}).(string)
+	return t
+}
+
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept parses the media ranges of an Accept header.
+func parseAccept(header string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		typ, subtype, _ := strings.Cut(strings.TrimSpace(params[0]), "/")
+		if typ == "" || subtype == "" {
+			continue
+		}
+		mr := mediaRange{typ: strings.ToLower(typ), subtype: strings.ToLower(subtype), q: 1}
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "q") {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					mr.q = q
+				}
+			}
+		}
+		ranges = append(ranges, mr)
+	}
+	return ranges
+}
+
+// negotiate returns the offer with the highest quality in header,
+// preferring earlier offers on ties, or "" if none is acceptable.
+// The quality of an offer is that of the most specific range matching it.
+func negotiate(header string, offers []string) string {
+	if strings.TrimSpace(header) == "" {
+		if len(offers) == 0 {
+			return ""
+		}
+		return offers[0]
+	}
+	ranges := parseAccept(header)
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(strings.ToLower(offer), "/")
+		q, specificity := 0.0, -1
+		for _, mr := range ranges {
+			var s int
+			switch {
+			case mr.typ == typ && mr.subtype == subtype:
+				s = 2
+			case mr.typ == typ && mr.subtype == "*":
+				s = 1
+			case mr.typ == "*" && mr.subtype == "*":
+				s = 0
+			default:
+				continue
+			}
+			if s > specificity {
+				q, specificity = mr.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
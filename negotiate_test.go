@@ -0,0 +1,65 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "text/html"}
+	tests := []struct {
+		accept, want string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"text/html", "text/html"},
+		{"text/*, application/json;q=0.5", "text/html"},
+		{"text/html;q=0.5, application/*;q=0.5", "application/json"},
+		{"text/*, text/html;q=0", ""},
+		{"text/html;q=0, */*", "application/json"},
+		{"image/png", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			assert.Equal(t, negotiate(tt.accept, offers), tt.want)
+		})
+	}
+}
+
+func TestNegotiateStoresChoice(t *testing.T) {
+	var accepted string
+	h := Negotiate("application/json", "text/html")(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		accepted = Accepted(ctx)
+	}))
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Accept", "text/html")
+	h.ServeHTTPContext(context.Background(), w, r)
+
+	assert.Equal(t, accepted, "text/html")
+	assert.Equal(t, w.Header().Get("Vary"), "Accept")
+}
+
+func TestNegotiateRejectsUnacceptable(t *testing.T) {
+	h := Negotiate("application/json")(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler called")
+	}))
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Accept", "image/png")
+	h.ServeHTTPContext(context.Background(), w, r)
+
+	assert.Equal(t, w.Code, http.StatusNotAcceptable)
+}
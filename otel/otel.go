@@ -0,0 +1,111 @@
+// Package otel provides OpenTelemetry tracing middleware for alice chains.
+package otel
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/SimiPro/alice"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+)
+
+const instrumentationName = "github.com/SimiPro/alice/otel"
+
+// Options configure the Trace middleware.
+// The zero value is ready to use.
+type Options struct {
+	// Propagator extracts the remote span context from request headers
+	// and injects the server span into response headers.
+	// It defaults to W3C Trace Context (traceparent, tracestate).
+	Propagator propagation.TextMapPropagator
+	// SpanName names the span of a request.
+	// It defaults to the request method, e.g. "GET".
+	SpanName func(*http.Request) string
+}
+
+// Trace returns a Constructor starting a server span for every request.
+// The span is stored in the context (see SpanFrom) and records
+// the response status code and any error reported by an ErrorHandlerFunc.
+// Once the route is set with alice.WithRoute, the span gets the
+// http.route attribute and, unless opts.SpanName is set, is named
+// after it, e.g. "GET /pets/{id}".
+func Trace(tp trace.TracerProvider, opts Options) alice.Constructor {
+	tracer := tp.Tracer(instrumentationName)
+	prop := opts.Propagator
+	if prop == nil {
+		prop = propagation.TraceContext{}
+	}
+	spanName := opts.SpanName
+	if spanName == nil {
+		spanName = func(r *http.Request) string {
+			return r.Method
+		}
+	}
+
+	return func(next alice.ContextHandler) alice.ContextHandler {
+		return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			ctx = prop.Extract(ctx, propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, spanName(r),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+			prop.Inject(ctx, propagation.HeaderCarrier(w.Header()))
+
+			ctx, lastErr := alice.TrackErrors(ctx)
+			ctx, route := alice.TrackRoute(ctx)
+			rec := alice.NewResponseRecorder(w)
+			next.ServeHTTPContext(ctx, rec, r)
+
+			if info, ok := route(); ok && info.Pattern != "" {
+				path := routePath(info.Pattern)
+				span.SetAttributes(attribute.String("http.route", path))
+				if opts.SpanName == nil {
+					span.SetName(r.Method + " " + path)
+				}
+			}
+
+			status := rec.Status()
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+			if err := lastErr(); err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
+
+// routePath strips the method from a route pattern such as "GET /pets/{id}".
+func routePath(pattern string) string {
+	if method, path, ok := strings.Cut(pattern, " "); ok && method != "" && !strings.Contains(method, "/") {
+		return strings.TrimSpace(path)
+	}
+	return pattern
+}
+
+// SpanFrom returns the span of the current request,
+// or a no-op span if the Trace middleware did not run.
+func SpanFrom(ctx context.Context) trace.Span {
+	return trace.SpanFromContext(ctx)
+}
+
+// Propagator returns an alice.Propagator injecting the span context
+// into outbound request headers, e.g. for alice.ReverseProxy.
+// A nil p defaults to W3C Trace Context.
+func Propagator(p propagation.TextMapPropagator) alice.Propagator {
+	if p == nil {
+		p = propagation.TraceContext{}
+	}
+	return func(ctx context.Context, h http.Header) {
+		p.Inject(ctx, propagation.HeaderCarrier(h))
+	}
+}
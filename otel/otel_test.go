@@ -0,0 +1,78 @@
+package otel
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"golang.org/x/net/context"
+)
+
+const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func serve(t *testing.T, h alice.ContextHandler) (*tracetest.SpanRecorder, *httptest.ResponseRecorder) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("traceparent", parent)
+
+	alice.New(Trace(tp, Options{})).ThenWithContext(context.Background(), h).ServeHTTP(w, r)
+	return sr, w
+}
+
+func TestTraceContinuesRemoteSpan(t *testing.T) {
+	var spanFound bool
+	sr, w := serve(t, alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		spanFound = SpanFrom(ctx).SpanContext().IsValid()
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	assert.True(t, spanFound)
+	spans := sr.Ended()
+	assert.Equal(t, len(spans), 1)
+	assert.Equal(t, spans[0].Parent().TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("http.response.status_code", http.StatusTeapot))
+	assert.Contains(t, w.Header().Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736")
+}
+
+func TestTraceRecordsErrors(t *testing.T) {
+	sr, _ := serve(t, alice.ErrorHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		return errors.New("failure")
+	}))
+
+	spans := sr.Ended()
+	assert.Equal(t, len(spans), 1)
+	assert.Equal(t, spans[0].Status().Code, codes.Error)
+	assert.Equal(t, spans[0].Status().Description, "failure")
+}
+
+func TestPropagatorInjectsSpan(t *testing.T) {
+	var hdr http.Header
+	serve(t, alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		hdr = http.Header{}
+		Propagator(nil)(ctx, hdr)
+	}))
+
+	assert.Contains(t, hdr.Get("traceparent"), "4bf92f3577b34da6a3ce929d0e0e4736")
+}
+
+func TestTraceNamesSpanAfterRoute(t *testing.T) {
+	route := alice.Route(alice.RouteInfo{Name: "getPet", Pattern: "GET /pets/{id}"})
+	sr, _ := serve(t, route(alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {})))
+
+	spans := sr.Ended()
+	assert.Equal(t, spans[0].Name(), "GET /pets/{id}")
+	assert.Contains(t, spans[0].Attributes(), attribute.String("http.route", "/pets/{id}"))
+}
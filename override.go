@@ -0,0 +1,76 @@
+package alice
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// MethodOverrideHeader is the header MethodOverride reads.
+const MethodOverrideHeader = "X-HTTP-Method-Override"
+
+type originalMethodKey struct{}
+
+// MethodOverride returns a Constructor letting POST requests ask to be
+// treated as PUT, PATCH or DELETE, for clients such as HTML forms that
+// cannot send those methods. The method is taken from the
+// X-HTTP-Method-Override header or, for form bodies, the _method field:
+//
+//	<form method="POST" action="/posts/1">
+//	    <input type="hidden" name="_method" value="DELETE">
+//
+// Requests with other methods are left alone, so a GET can never be
+// turned into a state-changing request. The original method is stored
+// in the context; see OriginalMethodFrom.
+func MethodOverride() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if r.Method != "POST" {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			method := strings.ToUpper(r.Header.Get(MethodOverrideHeader))
+			if method == "" {
+				method = strings.ToUpper(overrideField(ctx, r))
+			}
+			switch method {
+			case "PUT", "PATCH", "DELETE":
+			default:
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+
+			r2 := new(http.Request)
+			*r2 = *r
+			r2.Method = method
+			ctx = context.WithValue(ctx, originalMethodKey{}, r.Method)
+			next.ServeHTTPContext(ctx, w, r2)
+		})
+	}
+}
+
+// overrideField returns the _method form field, using the form
+// parsed by ParseForm if there is one. Otherwise only url-encoded
+// bodies are parsed.
+func overrideField(ctx context.Context, r *http.Request) string {
+	if form := FormFrom(ctx); form != nil {
+		return form.Get("_method")
+	}
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mt != "application/x-www-form-urlencoded" {
+		return ""
+	}
+	if err := r.ParseForm(); err != nil {
+		return ""
+	}
+	return r.PostForm.Get("_method")
+}
+
+// OriginalMethodFrom returns the method a request was sent with
+// if MethodOverride changed it.
+func OriginalMethodFrom(ctx context.Context) (string, bool) {
+	m, ok := ctx.Value(originalMethodKey{}).(string)
+	return m, ok
+}
@@ -0,0 +1,59 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func serveOverride(r *http.Request, chain Chain) (method, original, field string) {
+	h := chain.ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		original, _ = OriginalMethodFrom(ctx)
+		field = r.FormValue("field")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	return
+}
+
+func TestMethodOverrideHeader(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set(MethodOverrideHeader, "patch")
+	method, original, _ := serveOverride(r, New(MethodOverride()))
+	assert.Equal(t, method, "PATCH")
+	assert.Equal(t, original, "POST")
+}
+
+func TestMethodOverrideFormField(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader("_method=DELETE&field=x"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	method, _, field := serveOverride(r, New(MethodOverride()))
+	assert.Equal(t, method, "DELETE")
+	assert.Equal(t, field, "x")
+}
+
+func TestMethodOverrideUsesParsedForm(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader("_method=PUT"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	method, _, _ := serveOverride(r, New(ParseForm(1<<20), MethodOverride()))
+	assert.Equal(t, method, "PUT")
+}
+
+func TestMethodOverrideOnlyFromPOST(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(MethodOverrideHeader, "DELETE")
+	method, original, _ := serveOverride(r, New(MethodOverride()))
+	assert.Equal(t, method, "GET")
+	assert.Equal(t, original, "")
+}
+
+func TestMethodOverrideIgnoresOtherMethods(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set(MethodOverrideHeader, "CONNECT")
+	method, _, _ := serveOverride(r, New(MethodOverride()))
+	assert.Equal(t, method, "POST")
+}
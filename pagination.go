@@ -0,0 +1,187 @@
+package alice
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// PageOptions configure Pagination.
+type PageOptions struct {
+	// Limit is the page size if the request names none; it defaults to 20.
+	Limit int
+	// MaxLimit caps the requested page size; it defaults to 100.
+	MaxLimit int
+	// Sortable lists the fields requests may sort by.
+	// If empty, sort parameters are rejected.
+	Sortable []string
+	// Sort is the order used if the request names none,
+	// in the syntax of the sort parameter, e.g. "-created_at".
+	Sort string
+}
+
+// SortField is a field to sort by.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// Page is the page a request asks for.
+type Page struct {
+	// Number is the 1-based page number.
+	Number int
+	Limit  int
+	Sort   []SortField
+}
+
+// Offset returns the number of items before the page.
+func (p Page) Offset() int {
+	return (p.Number - 1) * p.Limit
+}
+
+type pageKey struct{}
+
+type pageRecord struct {
+	page  Page
+	total int
+}
+
+// Pagination returns a Constructor parsing the page, limit and sort
+// query parameters into a Page stored in the context (see PageFrom):
+//
+//	GET /users?page=3&limit=50&sort=-created_at,name
+//
+// Limits above defaults.MaxLimit are capped; malformed values and
+// fields not in defaults.Sortable get 400 Bad Request. An empty sort
+// parameter counts as none, leaving defaults.Sort in place.
+// If the handler reports the total number of items with SetTotal before
+// writing the response, the X-Total-Count header and a Link header
+// (RFC 8288) with first, prev, next and last pages are added.
+func Pagination(defaults PageOptions) Constructor {
+	if defaults.Limit <= 0 {
+		defaults.Limit = 20
+	}
+	if defaults.MaxLimit <= 0 {
+		defaults.MaxLimit = 100
+	}
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			page, fields := parsePage(r.URL.Query(), defaults)
+			if len(fields) > 0 {
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusBadRequest,
+					Code:   "invalid_pagination",
+					Fields: fields,
+				})
+				return
+			}
+			rec := &pageRecord{page: page, total: -1}
+			pw := &pageWriter{rec: rec, url: r.URL, h: w.Header()}
+			next.ServeHTTPContext(context.WithValue(ctx, pageKey{}, rec), NewHookWriter(w, pw.addHeaders), r)
+		})
+	}
+}
+
+func parsePage(q url.Values, defaults PageOptions) (Page, map[string]string) {
+	page := Page{Number: 1, Limit: defaults.Limit}
+	fields := make(map[string]string)
+	if s := q.Get("page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			fields["page"] = "must be a positive integer"
+		}
+		page.Number = n
+	}
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			fields["limit"] = "must be a positive integer"
+		}
+		page.Limit = min(n, defaults.MaxLimit)
+	}
+	if _, invalid := fields["page"]; !invalid && page.Limit > 0 && page.Number-1 > math.MaxInt/page.Limit {
+		// The offset would overflow.
+		fields["page"] = "is too large"
+	}
+	sort := defaults.Sort
+	if s := strings.Join(q["sort"], ","); strings.Trim(s, ", ") != "" {
+		sort = s
+		for _, f := range strings.Split(sort, ",") {
+			if f = strings.TrimSpace(f); f != "" && !contains(defaults.Sortable, strings.TrimPrefix(f, "-")) {
+				fields["sort"] = "must be a list of " + strings.Join(defaults.Sortable, ", ")
+			}
+		}
+	}
+	for _, f := range strings.Split(sort, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			page.Sort = append(page.Sort, SortField{Field: strings.TrimPrefix(f, "-"), Desc: f[0] == '-'})
+		}
+	}
+	return page, fields
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// PageFrom returns the page parsed by Pagination,
+// or the first page of 20 items if it did not run.
+func PageFrom(ctx context.Context) Page {
+	if rec, ok := ctx.Value(pageKey{}).(*pageRecord); ok {
+		return rec.page
+	}
+	return Page{Number: 1, Limit: 20}
+}
+
+// SetTotal reports the total number of items for the pagination headers.
+// It must be called before the response is written.
+func SetTotal(ctx context.Context, total int) {
+	if rec, ok := ctx.Value(pageKey{}).(*pageRecord); ok {
+		rec.total = total
+	}
+}
+
+// pageWriter adds the pagination headers before the response is written.
+type pageWriter struct {
+	rec *pageRecord
+	url *url.URL
+	h   http.Header
+}
+
+func (pw *pageWriter) addHeaders() {
+	total := pw.rec.total
+	if total < 0 {
+		return
+	}
+	page := pw.rec.page
+	last := max((total+page.Limit-1)/page.Limit, 1)
+
+	h := pw.h
+	h.Set("X-Total-Count", strconv.Itoa(total))
+	links := []string{pw.link(1, "first")}
+	if page.Number > 1 {
+		links = append(links, pw.link(min(page.Number-1, last), "prev"))
+	}
+	if page.Number < last {
+		links = append(links, pw.link(page.Number+1, "next"))
+	}
+	links = append(links, pw.link(last, "last"))
+	h.Add("Link", strings.Join(links, ", "))
+}
+
+func (pw *pageWriter) link(number int, rel string) string {
+	q := pw.url.Query()
+	q.Set("page", strconv.Itoa(number))
+	q.Set("limit", strconv.Itoa(pw.rec.page.Limit))
+	u := url.URL{Path: pw.url.Path, RawQuery: q.Encode()}
+	return "<" + u.String() + `>; rel="` + rel + `"`
+}
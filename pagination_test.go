@@ -0,0 +1,63 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func servePage(target string, total int) (*httptest.ResponseRecorder, Page) {
+	var page Page
+	h := New(Pagination(PageOptions{MaxLimit: 50, Sortable: []string{"name", "created_at"}, Sort: "name"})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		page = PageFrom(ctx)
+		if total >= 0 {
+			SetTotal(ctx, total)
+		}
+		w.Write([]byte("[]"))
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	return w, page
+}
+
+func TestPaginationParsesParams(t *testing.T) {
+	_, page := servePage("/users?page=3&limit=500&sort=-created_at,name", -1)
+	assert.Equal(t, page.Number, 3)
+	assert.Equal(t, page.Limit, 50)
+	assert.Equal(t, page.Offset(), 100)
+	assert.Equal(t, page.Sort, []SortField{{"created_at", true}, {"name", false}})
+
+	_, page = servePage("/users", -1)
+	assert.Equal(t, page, Page{Number: 1, Limit: 20, Sort: []SortField{{"name", false}}})
+
+	w, page := servePage("/users?sort=", -1)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, page.Sort, []SortField{{"name", false}})
+}
+
+func TestPaginationRejectsInvalidParams(t *testing.T) {
+	w, _ := servePage("/users?page=0&sort=password", -1)
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+	assert.Contains(t, w.Body.String(), `"page":"must be a positive integer"`)
+	assert.Contains(t, w.Body.String(), `"sort":"must be a list of name, created_at"`)
+
+	w, _ = servePage("/users?page=9223372036854775807", -1)
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+	assert.Contains(t, w.Body.String(), `"page":"is too large"`)
+}
+
+func TestPaginationWritesLinkHeaders(t *testing.T) {
+	w, _ := servePage("/users?page=2&limit=10&q=a", 35)
+	assert.Equal(t, w.Header().Get("X-Total-Count"), "35")
+	assert.Equal(t, w.Header().Get("Link"),
+		`</users?limit=10&page=1&q=a>; rel="first", `+
+			`</users?limit=10&page=1&q=a>; rel="prev", `+
+			`</users?limit=10&page=3&q=a>; rel="next", `+
+			`</users?limit=10&page=4&q=a>; rel="last"`)
+
+	w, _ = servePage("/users", -1)
+	assert.Equal(t, w.Header().Get("Link"), "")
+}
@@ -0,0 +1,33 @@
+package alice
+
+import (
+	"net/http"
+	"runtime/pprof"
+
+	"golang.org/x/net/context"
+)
+
+// ProfileLabels returns a Constructor running the rest of the chain
+// under pprof labels, so CPU and goroutine profiles can be sliced by
+// endpoint, e.g. with go tool pprof -tagfocus=method=POST.
+// The labels are "method" and, if known by then, "route" (see WithRoute)
+// and "tenant" (see Tenant). Raw paths are left out, as their IDs would
+// make a label value per request.
+// Goroutines started by the handler inherit them.
+// Put ProfileLabels after Tenant in the chain.
+func ProfileLabels() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			labels := []string{"method", r.Method}
+			if route := RouteLabel(ctx); route != "" {
+				labels = append(labels, "route", route)
+			}
+			if tenant, ok := TenantFrom(ctx); ok {
+				labels = append(labels, "tenant", tenant.ID)
+			}
+			pprof.Do(ctx, pprof.Labels(labels...), func(ctx context.Context) {
+				next.ServeHTTPContext(ctx, w, r)
+			})
+		})
+	}
+}
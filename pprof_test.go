@@ -0,0 +1,38 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestProfileLabels(t *testing.T) {
+	labels := map[string]string{}
+	resolver := func(ctx context.Context, r *http.Request) (TenantInfo, error) {
+		return TenantInfo{ID: "acme"}, nil
+	}
+	h := New(Tenant(resolver), ProfileLabels()).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/orders", nil))
+	assert.Equal(t, labels, map[string]string{"method": "POST", "tenant": "acme"})
+}
+
+func TestProfileLabelsRoute(t *testing.T) {
+	labels := map[string]string{}
+	h := New(Route(RouteInfo{Name: "listOrders"}), ProfileLabels()).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		pprof.ForLabels(ctx, func(key, value string) bool {
+			labels[key] = value
+			return true
+		})
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders", nil))
+	assert.Equal(t, labels["route"], "listOrders")
+}
@@ -0,0 +1,174 @@
+package alice
+
+import (
+	"container/heap"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// PriorityOptions configure Prioritize.
+type PriorityOptions struct {
+	// MaxConcurrent is the number of requests served at once.
+	MaxConcurrent int
+	// MaxQueue is the number of requests waiting for a slot.
+	// When it is full, the lowest priority request is shed.
+	MaxQueue int
+	// QueueTimeout bounds how long a request waits, zero meaning
+	// until its context is done.
+	QueueTimeout time.Duration
+}
+
+// Prioritize returns a Constructor limiting the requests served at once
+// to opts.MaxConcurrent. Under load, waiting requests are admitted
+// by priority, highest first as returned by classifier, and in arrival
+// order within a priority. Requests shed from a full queue or timing out
+// fail with 503 Service Unavailable; requests whose client goes away
+// leave the queue.
+//
+//	alice.Prioritize(func(ctx context.Context, r *http.Request) int {
+//	    if strings.HasPrefix(r.URL.Path, "/checkout") {
+//	        return 10
+//	    }
+//	    return 0
+//	}, alice.PriorityOptions{MaxConcurrent: 100, MaxQueue: 1000})
+func Prioritize(classifier func(context.Context, *http.Request) int, opts PriorityOptions) Constructor {
+	s := &scheduler{slots: max(opts.MaxConcurrent, 1), maxQueue: opts.MaxQueue}
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			wctx := ctx
+			if opts.QueueTimeout > 0 {
+				var cancel context.CancelFunc
+				wctx, cancel = context.WithTimeout(ctx, opts.QueueTimeout)
+				defer cancel()
+			}
+			if !s.acquire(wctx, r.Context().Done(), classifier(ctx, r)) {
+				w.Header().Set("Retry-After", "1")
+				WriteError(ctx, w, &HTTPError{Status: http.StatusServiceUnavailable, Code: "overloaded"})
+				return
+			}
+			defer s.release()
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
+
+// scheduler hands out slots to waiters by priority.
+type scheduler struct {
+	mu       sync.Mutex
+	slots    int
+	maxQueue int
+	queue    waitQueue
+	seq      uint64
+}
+
+type waiter struct {
+	priority int
+	seq      uint64
+	index    int
+	// ready receives true when the waiter is admitted, false when shed.
+	ready chan bool
+}
+
+// acquire waits for a slot until it is admitted or shed, or ctx
+// is done or gone is closed.
+func (s *scheduler) acquire(ctx context.Context, gone <-chan struct{}, priority int) bool {
+	s.mu.Lock()
+	if s.slots > 0 && len(s.queue) == 0 {
+		s.slots--
+		s.mu.Unlock()
+		return true
+	}
+	if len(s.queue) >= s.maxQueue {
+		lowest := s.queue.lowest()
+		if lowest == nil || lowest.priority >= priority {
+			s.mu.Unlock()
+			return false
+		}
+		heap.Remove(&s.queue, lowest.index)
+		lowest.ready <- false
+	}
+	s.seq++
+	wt := &waiter{priority: priority, seq: s.seq, ready: make(chan bool, 1)}
+	heap.Push(&s.queue, wt)
+	s.mu.Unlock()
+
+	select {
+	case ok := <-wt.ready:
+		return ok
+	case <-ctx.Done():
+	case <-gone:
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if wt.index >= 0 {
+		heap.Remove(&s.queue, wt.index)
+		return false
+	}
+	// Admitted or shed while giving up.
+	if <-wt.ready {
+		s.releaseLocked()
+	}
+	return false
+}
+
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+// releaseLocked passes a freed slot on to the highest priority waiter.
+func (s *scheduler) releaseLocked() {
+	if len(s.queue) > 0 {
+		heap.Pop(&s.queue).(*waiter).ready <- true
+		return
+	}
+	s.slots++
+}
+
+// waitQueue is a max-heap of waiters by priority, then arrival.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x any) {
+	wt := x.(*waiter)
+	wt.index = len(*q)
+	*q = append(*q, wt)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	wt := old[len(old)-1]
+	old[len(old)-1] = nil
+	wt.index = -1
+	*q = old[:len(old)-1]
+	return wt
+}
+
+// lowest returns the waiter that would be admitted last.
+func (q waitQueue) lowest() *waiter {
+	var low *waiter
+	for _, wt := range q {
+		if low == nil || wt.priority < low.priority || wt.priority == low.priority && wt.seq > low.seq {
+			low = wt
+		}
+	}
+	return low
+}
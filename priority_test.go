@@ -0,0 +1,127 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func queued(s *scheduler) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+func waitQueued(t *testing.T, s *scheduler, n int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for queued(s) != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d requests queued, want %d", queued(s), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerAdmitsByPriority(t *testing.T) {
+	s := &scheduler{slots: 1, maxQueue: 10}
+	ctx := context.Background()
+	assert.True(t, s.acquire(ctx, nil, 0))
+
+	admitted := make(chan int, 3)
+	for i, p := range []int{1, 5, 1} {
+		go func(id, p int) {
+			if s.acquire(ctx, nil, p) {
+				admitted <- id
+				s.release()
+			}
+		}(i, p)
+		waitQueued(t, s, i+1)
+	}
+	s.release()
+
+	assert.Equal(t, []int{<-admitted, <-admitted, <-admitted}, []int{1, 0, 2})
+}
+
+func TestSchedulerShedsLowestPriority(t *testing.T) {
+	s := &scheduler{slots: 1, maxQueue: 1}
+	ctx := context.Background()
+	assert.True(t, s.acquire(ctx, nil, 0))
+
+	shed := make(chan bool)
+	go func() {
+		shed <- !s.acquire(ctx, nil, 1)
+	}()
+	waitQueued(t, s, 1)
+
+	// An equal priority request is rejected, a higher one displaces it.
+	assert.False(t, s.acquire(ctx, nil, 1))
+	go s.acquire(ctx, nil, 2)
+	assert.True(t, <-shed)
+}
+
+func TestPrioritizeTimesOut(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	h := New(Prioritize(func(ctx context.Context, r *http.Request) int {
+		return 0
+	}, PriorityOptions{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	<-started
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	close(release)
+	assert.Equal(t, w.Code, http.StatusServiceUnavailable)
+	assert.Equal(t, w.Header().Get("Retry-After"), "1")
+}
+
+func TestSchedulerLeavesWhenGone(t *testing.T) {
+	s := &scheduler{slots: 1, maxQueue: 10}
+	ctx := context.Background()
+	assert.True(t, s.acquire(ctx, nil, 0))
+
+	gone := make(chan struct{})
+	admitted := make(chan bool)
+	go func() {
+		admitted <- s.acquire(ctx, gone, 0)
+	}()
+	waitQueued(t, s, 1)
+	close(gone)
+	assert.False(t, <-admitted)
+	assert.Equal(t, queued(s), 0)
+
+	s.release()
+	assert.True(t, s.acquire(ctx, nil, 0))
+}
+
+func TestPrioritizeLeavesWhenCanceled(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	h := New(Prioritize(func(ctx context.Context, r *http.Request) int {
+		return 0
+	}, PriorityOptions{MaxConcurrent: 1, MaxQueue: 1})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	defer close(release)
+
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	<-started
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	w := httptest.NewRecorder()
+	go func() {
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil).WithContext(reqCtx))
+		close(done)
+	}()
+	cancel()
+	<-done
+	assert.Equal(t, w.Code, http.StatusServiceUnavailable)
+}
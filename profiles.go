@@ -0,0 +1,57 @@
+package alice
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Profiles maps environment names to the constructors they append
+// to a base chain, so one setup serves every environment:
+//
+//	profiles := alice.Profiles{
+//	    "dev":  {alice.Capture(captureOpts)},
+//	    "prod": {prom.Middleware(promOpts)},
+//	}
+//	chain, err := profiles.FromEnv(base, "APP_ENV")
+type Profiles map[string][]Constructor
+
+// Chain returns base extended with the constructors of profile.
+// It fails for unknown profiles.
+func (p Profiles) Chain(base Chain, profile string) (Chain, error) {
+	constructors, ok := p[profile]
+	if !ok {
+		return Chain{}, fmt.Errorf("alice: unknown profile %q, want one of %s", profile, strings.Join(p.names(), ", "))
+	}
+	return base.Append(constructors...), nil
+}
+
+// FromEnv returns the Chain of the profile named by the environment
+// variable env. It fails if the variable is unset or empty.
+func (p Profiles) FromEnv(base Chain, env string) (Chain, error) {
+	profile := os.Getenv(env)
+	if profile == "" {
+		return Chain{}, fmt.Errorf("alice: profile variable %s is not set", env)
+	}
+	return p.Chain(base, profile)
+}
+
+// All returns the Chain of every profile, keyed by name,
+// for instance to check them all at startup or in tests.
+func (p Profiles) All(base Chain) map[string]Chain {
+	chains := make(map[string]Chain, len(p))
+	for name, constructors := range p {
+		chains[name] = base.Append(constructors...)
+	}
+	return chains
+}
+
+func (p Profiles) names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
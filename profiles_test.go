@@ -0,0 +1,45 @@
+package alice
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var testProfiles = Profiles{
+	"dev":  {tagMiddleware("dev\n")},
+	"prod": {tagMiddleware("metrics\n"), tagMiddleware("prod\n")},
+}
+
+func TestProfilesChain(t *testing.T) {
+	chain, err := testProfiles.Chain(New(tagMiddleware("base\n")), "prod")
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	chain.ThenWithContext(context.Background(), testApp).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, w.Body.String(), "base\nmetrics\nprod\napp\n")
+}
+
+func TestProfilesUnknown(t *testing.T) {
+	_, err := testProfiles.Chain(New(), "staging")
+	assert.EqualError(t, err, `alice: unknown profile "staging", want one of dev, prod`)
+}
+
+func TestProfilesFromEnv(t *testing.T) {
+	t.Setenv("APP_ENV", "dev")
+	chain, err := testProfiles.FromEnv(New(), "APP_ENV")
+	assert.Nil(t, err)
+	assert.Equal(t, len(chain.constructors), 1)
+
+	t.Setenv("APP_ENV", "")
+	_, err = testProfiles.FromEnv(New(), "APP_ENV")
+	assert.EqualError(t, err, "alice: profile variable APP_ENV is not set")
+}
+
+func TestProfilesAll(t *testing.T) {
+	chains := testProfiles.All(New(passThrough))
+	assert.Equal(t, len(chains["dev"].constructors), 2)
+	assert.Equal(t, len(chains["prod"].constructors), 3)
+}
@@ -0,0 +1,144 @@
+// Package prom provides Prometheus metrics middleware for alice chains.
+package prom
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+)
+
+// Options configure the Metrics middleware.
+// The zero value is ready to use.
+type Options struct {
+	// Namespace prefixes all metric names.
+	Namespace string
+	// Buckets are the duration histogram buckets in seconds.
+	// They default to prometheus.DefBuckets.
+	Buckets []float64
+	// Route, if set, adds a "route" label with the value it returns.
+	// It should return a low-cardinality name such as a route pattern,
+	// e.g. alice.RouteLabel for the route set with alice.WithRoute.
+	Route func(context.Context) string
+}
+
+// Metrics returns a Constructor exporting request count, duration,
+// response size and in-flight requests, labeled by method and status.
+// If alice.CollectStats runs before it, request sizes and the
+// durations added to the alice.Stats are exported too.
+// The collectors are registered with reg; like prometheus.MustRegister,
+// Metrics panics if that fails.
+func Metrics(reg prometheus.Registerer, opts Options) alice.Constructor {
+	labels := []string{"method", "status"}
+	if opts.Route != nil {
+		labels = append(labels, "route")
+	}
+	buckets := opts.Buckets
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: opts.Namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests served.",
+	}, labels)
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: opts.Namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time spent serving HTTP requests.",
+		Buckets:   buckets,
+	}, labels)
+	size := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: opts.Namespace,
+		Name:      "http_response_size_bytes",
+		Help:      "Size of HTTP response bodies.",
+		Buckets:   prometheus.ExponentialBuckets(100, 10, 7),
+	}, labels)
+	requestSize := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: opts.Namespace,
+		Name:      "http_request_size_bytes",
+		Help:      "Size of HTTP request bodies read, from alice.CollectStats.",
+		Buckets:   prometheus.ExponentialBuckets(100, 10, 7),
+	}, labels)
+	components := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: opts.Namespace,
+		Name:      "http_request_component_duration_seconds",
+		Help:      "Time spent on components of HTTP requests, such as the database, from alice.CollectStats.",
+		Buckets:   buckets,
+	}, []string{"component"})
+	inFlight := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: opts.Namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests being served.",
+	})
+	reg.MustRegister(requests, duration, size, requestSize, components, inFlight)
+
+	return func(next alice.ContextHandler) alice.ContextHandler {
+		return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			ctx, _ = alice.TrackRoute(ctx)
+			start := time.Now()
+			rec := alice.NewResponseRecorder(w)
+			next.ServeHTTPContext(ctx, rec, r)
+
+			values := []string{r.Method, strconv.Itoa(rec.Status())}
+			if opts.Route != nil {
+				values = append(values, opts.Route(ctx))
+			}
+			requests.WithLabelValues(values...).Inc()
+			duration.WithLabelValues(values...).Observe(time.Since(start).Seconds())
+			size.WithLabelValues(values...).Observe(float64(rec.BytesWritten()))
+			if stats := alice.StatsFrom(ctx); stats != nil {
+				requestSize.WithLabelValues(values...).Observe(float64(stats.BytesIn()))
+				for name, d := range stats.Durations() {
+					components.WithLabelValues(name).Observe(d.Seconds())
+				}
+			}
+		})
+	}
+}
+
+// connPoolCollector exports the statistics of an alice.ConnPool.
+type connPoolCollector struct {
+	pool                     *alice.ConnPool
+	inFlight, opened, reused *prometheus.Desc
+}
+
+// RegisterConnPool registers with reg the per-host statistics of
+// outbound connections collected by alice.TraceConns: requests in
+// flight, and requests sent over new and reused connections.
+// Like prometheus.MustRegister, it panics if that fails.
+// The connection timings are exported by Metrics when alice.CollectStats
+// runs before it, as the "upstream_*" components.
+func RegisterConnPool(reg prometheus.Registerer, pool *alice.ConnPool, opts Options) {
+	name := func(n string) string {
+		return prometheus.BuildFQName(opts.Namespace, "", n)
+	}
+	host := []string{"host"}
+	reg.MustRegister(&connPoolCollector{
+		pool:     pool,
+		inFlight: prometheus.NewDesc(name("http_client_requests_in_flight"), "Number of outbound HTTP requests in flight.", host, nil),
+		opened:   prometheus.NewDesc(name("http_client_connections_opened_total"), "Number of outbound HTTP requests sent over a new connection.", host, nil),
+		reused:   prometheus.NewDesc(name("http_client_connections_reused_total"), "Number of outbound HTTP requests sent over a reused connection.", host, nil),
+	})
+}
+
+func (c *connPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.inFlight
+	ch <- c.opened
+	ch <- c.reused
+}
+
+func (c *connPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, h := range c.pool.Stats() {
+		ch <- prometheus.MustNewConstMetric(c.inFlight, prometheus.GaugeValue, float64(h.InFlight), h.Host)
+		ch <- prometheus.MustNewConstMetric(c.opened, prometheus.CounterValue, float64(h.Opened), h.Host)
+		ch <- prometheus.MustNewConstMetric(c.reused, prometheus.CounterValue, float64(h.Reused), h.Host)
+	}
+}
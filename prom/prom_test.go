@@ -0,0 +1,129 @@
+package prom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var teapot = alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusTeapot)
+	w.Write([]byte("short and stout"))
+})
+
+func serve(t *testing.T, c alice.Constructor) {
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice.New(c).ThenWithContext(context.Background(), teapot).ServeHTTP(w, r)
+}
+
+func TestMetricsCountsRequests(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	serve(t, Metrics(reg, Options{}))
+
+	n, err := testutil.GatherAndCount(reg, "http_requests_total", "http_request_duration_seconds", "http_response_size_bytes")
+	assert.Nil(t, err)
+	assert.Equal(t, n, 3)
+
+	families, err := reg.Gather()
+	assert.Nil(t, err)
+	for _, f := range families {
+		if f.GetName() != "http_requests_total" {
+			continue
+		}
+		m := f.GetMetric()[0]
+		assert.Equal(t, m.GetCounter().GetValue(), float64(1))
+		assert.Equal(t, m.GetLabel()[0].GetValue(), "GET")
+		assert.Equal(t, m.GetLabel()[1].GetValue(), "418")
+	}
+}
+
+func TestMetricsRouteLabel(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	serve(t, Metrics(reg, Options{
+		Namespace: "app",
+		Route: func(ctx context.Context) string {
+			return "/teapot"
+		},
+	}))
+
+	families, err := reg.Gather()
+	assert.Nil(t, err)
+	for _, f := range families {
+		if f.GetName() != "app_http_requests_total" {
+			continue
+		}
+		labels := f.GetMetric()[0].GetLabel()
+		assert.Equal(t, len(labels), 3)
+		assert.Equal(t, labels[1].GetName(), "route")
+		assert.Equal(t, labels[1].GetValue(), "/teapot")
+	}
+}
+
+func TestMetricsRouteFromContext(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	w := httptest.NewRecorder()
+	alice.New(Metrics(reg, Options{Route: alice.RouteLabel}), alice.Route(alice.RouteInfo{Name: "brew"})).
+		ThenWithContext(context.Background(), teapot).
+		ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	n, err := testutil.GatherAndCount(reg, "http_requests_total")
+	assert.Nil(t, err)
+	assert.Equal(t, n, 1)
+	families, err := reg.Gather()
+	assert.Nil(t, err)
+	for _, f := range families {
+		if f.GetName() == "http_requests_total" {
+			assert.Equal(t, f.GetMetric()[0].GetLabel()[1].GetValue(), "brew")
+		}
+	}
+}
+
+func TestMetricsExportsStats(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	h := alice.New(alice.CollectStats(), Metrics(reg, Options{})).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		alice.StatsFrom(ctx).Add("db", 20*time.Millisecond)
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	n, err := testutil.GatherAndCount(reg, "http_request_size_bytes", "http_request_component_duration_seconds")
+	assert.Nil(t, err)
+	assert.Equal(t, n, 2)
+}
+
+func TestRegisterConnPool(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	pool := alice.NewConnPool()
+	reg := prometheus.NewRegistry()
+	RegisterConnPool(reg, pool, Options{Namespace: "app"})
+
+	client := &http.Client{Transport: alice.NewTransport(alice.TraceConns(alice.ConnTraceOptions{Pool: pool})).Then(nil)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if assert.Nil(t, err) {
+			resp.Body.Close()
+		}
+	}
+
+	n, err := testutil.GatherAndCount(reg, "app_http_client_requests_in_flight", "app_http_client_connections_opened_total", "app_http_client_connections_reused_total")
+	assert.Nil(t, err)
+	assert.Equal(t, n, 3)
+	families, err := reg.Gather()
+	assert.Nil(t, err)
+	for _, f := range families {
+		if f.GetName() == "app_http_client_connections_reused_total" {
+			assert.Equal(t, f.GetMetric()[0].GetCounter().GetValue(), float64(1))
+		}
+	}
+}
@@ -0,0 +1,47 @@
+// Package protobuf provides an alice.Codec for protocol buffers,
+// served as application/x-protobuf:
+//
+//	alice.RegisterCodec(protobuf.ContentType, protobuf.Codec{})
+//	chain := alice.New(alice.Negotiate("application/json", protobuf.ContentType))
+package protobuf
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentType is the media type of protocol buffer messages.
+const ContentType = "application/x-protobuf"
+
+// Codec marshals proto.Messages in the binary wire format.
+type Codec struct{}
+
+// Marshal encodes v, which must be a proto.Message.
+func (Codec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal decodes data into v, which must be a proto.Message
+// or a pointer to one, as alice.Bind passes for Bind[*pb.Message].
+// A nil message pointed to is allocated.
+func (Codec) Unmarshal(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, m)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Pointer {
+		if rv.Elem().IsNil() {
+			rv.Elem().Set(reflect.New(rv.Elem().Type().Elem()))
+		}
+		if m, ok := rv.Elem().Interface().(proto.Message); ok {
+			return proto.Unmarshal(data, m)
+		}
+	}
+	return fmt.Errorf("protobuf: %T is not a proto.Message", v)
+}
@@ -0,0 +1,29 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCodecRoundTrip(t *testing.T) {
+	data, err := Codec{}.Marshal(wrapperspb.String("alice"))
+	assert.Nil(t, err)
+
+	var m wrapperspb.StringValue
+	assert.Nil(t, Codec{}.Unmarshal(data, &m))
+	assert.Equal(t, m.GetValue(), "alice")
+
+	// As passed by alice.Bind[*wrapperspb.StringValue].
+	var p *wrapperspb.StringValue
+	assert.Nil(t, Codec{}.Unmarshal(data, &p))
+	assert.Equal(t, p.GetValue(), "alice")
+}
+
+func TestCodecRejectsNonMessages(t *testing.T) {
+	_, err := Codec{}.Marshal("alice")
+	assert.NotNil(t, err)
+	var s string
+	assert.NotNil(t, Codec{}.Unmarshal(nil, &s))
+}
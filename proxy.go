@@ -0,0 +1,152 @@
+package alice
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// A Propagator copies request-scoped values from the context,
+// such as trace or correlation IDs, into the headers of an outbound request.
+type Propagator func(ctx context.Context, h http.Header)
+
+// ReverseProxy is a ContextHandler forwarding requests to an upstream
+// chosen per request, built on httputil.ReverseProxy.
+// Upstream requests run with the chain's context, so they honor
+// its deadline and are cancelled when the client goes away.
+//
+//	proxy := &alice.ReverseProxy{
+//	    Target: func(ctx context.Context, r *http.Request) (*url.URL, error) {
+//	        return backendFor(alice.SubdomainFrom(ctx))
+//	    },
+//	    Propagators: []alice.Propagator{otel.Propagator(nil)},
+//	}
+type ReverseProxy struct {
+	// Target returns the upstream URL of a request.
+	// Its path is joined with the request path.
+	Target func(ctx context.Context, r *http.Request) (*url.URL, error)
+	// Propagators add headers from the context to upstream requests.
+	Propagators []Propagator
+	// Transport performs upstream requests.
+	// If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+	// Balancer, if set, chooses the upstream among its backends
+	// instead of Target, and learns from the outcome of the request.
+	Balancer *Balancer
+
+	once  sync.Once
+	proxy *httputil.ReverseProxy
+}
+
+// NewReverseProxy returns a ReverseProxy forwarding all requests to target.
+func NewReverseProxy(target *url.URL) *ReverseProxy {
+	return &ReverseProxy{
+		Target: func(context.Context, *http.Request) (*url.URL, error) {
+			return target, nil
+		},
+	}
+}
+
+type proxyTargetKey struct{}
+
+type proxyOutcomeKey struct{}
+
+// proxyOutcome records the upstream response or error of a request.
+type proxyOutcome struct {
+	resp *http.Response
+	err  error
+}
+
+// ServeHTTPContext forwards the request to its target.
+// If no target can be chosen, for instance because the Balancer
+// ejected every backend, or the upstream fails, it responds
+// 502 Bad Gateway, or 504 Gateway Timeout if the context deadline passed.
+func (p *ReverseProxy) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	p.once.Do(p.init)
+
+	target, err := p.target(ctx, r)
+	if err != nil {
+		WriteError(ctx, w, &HTTPError{Status: http.StatusBadGateway, Err: err})
+		return
+	}
+	if target.backend != nil {
+		stick(ctx, w, r, target.backend)
+		done := p.Balancer.start(target.backend)
+		o := &proxyOutcome{}
+		ctx = context.WithValue(ctx, proxyOutcomeKey{}, o)
+		defer func() { done(o.resp, o.err) }()
+	}
+
+	// The upstream request is canceled with the client's.
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-r.Context().Done():
+			cancel()
+		case <-cctx.Done():
+		}
+	}()
+	ctx = context.WithValue(cctx, proxyTargetKey{}, target.url)
+	p.proxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// proxyTarget is the upstream of a request,
+// and its backend if a Balancer chose it.
+type proxyTarget struct {
+	url     *url.URL
+	backend *Backend
+}
+
+func (p *ReverseProxy) target(ctx context.Context, r *http.Request) (proxyTarget, error) {
+	if p.Balancer == nil {
+		u, err := p.Target(ctx, r)
+		return proxyTarget{url: u}, err
+	}
+	be, err := p.Balancer.Pick(ctx, r)
+	if err != nil {
+		return proxyTarget{}, err
+	}
+	return proxyTarget{url: be.URL, backend: be}, nil
+}
+
+// outcome returns the proxyOutcome of a request, if it is tracked.
+func outcome(ctx context.Context) *proxyOutcome {
+	o, _ := ctx.Value(proxyOutcomeKey{}).(*proxyOutcome)
+	return o
+}
+
+func (p *ReverseProxy) init() {
+	p.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			ctx := pr.In.Context()
+			pr.SetURL(ctx.Value(proxyTargetKey{}).(*url.URL))
+			pr.SetXForwarded()
+			pr.Out.Host = pr.In.Host
+			for _, propagate := range p.Propagators {
+				propagate(ctx, pr.Out.Header)
+			}
+		},
+		Transport: p.Transport,
+		ModifyResponse: func(resp *http.Response) error {
+			if o := outcome(resp.Request.Context()); o != nil {
+				o.resp = resp
+			}
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if o := outcome(r.Context()); o != nil {
+				o.err = err
+			}
+			status := http.StatusBadGateway
+			if errors.Is(err, context.DeadlineExceeded) {
+				status = http.StatusGatewayTimeout
+			}
+			WriteError(r.Context(), w, &HTTPError{Status: status, Err: err})
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package alice
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestReverseProxyForwardsWithPropagators(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("X-Request-Id") + " " + r.Header.Get("X-Forwarded-For")))
+	}))
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL + "/base")
+
+	proxy := NewReverseProxy(target)
+	proxy.Propagators = []Propagator{func(ctx context.Context, h http.Header) {
+		h.Set("X-Request-Id", ctx.Value("request_id").(string))
+	}}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/users", nil)
+	proxy.ServeHTTPContext(context.WithValue(context.Background(), "request_id", "abc"), w, r)
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), "/base/users abc 192.0.2.1")
+}
+
+func TestReverseProxyHonorsDeadline(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer backend.Close()
+	target, _ := url.Parse(backend.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	NewReverseProxy(target).ServeHTTPContext(ctx, w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Code, http.StatusGatewayTimeout)
+}
+
+func TestReverseProxyTargetError(t *testing.T) {
+	proxy := &ReverseProxy{Target: func(context.Context, *http.Request) (*url.URL, error) {
+		return nil, errors.New("no backend")
+	}}
+
+	w := httptest.NewRecorder()
+	proxy.ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Code, http.StatusBadGateway)
+}
@@ -0,0 +1,65 @@
+package alice
+
+import (
+	"net/http"
+	"net/netip"
+
+	"golang.org/x/net/context"
+)
+
+// ProxyConfig describes the reverse proxies in front of the server,
+// configured once for all middleware trusting forwarding headers:
+// RealIP, IPFilter, RequireHTTPS and Logging use it when not given
+// trusted proxies of their own. Set it on the chain with
+// WithProxyConfig or on the Server.
+type ProxyConfig struct {
+	// TrustedProxies are the networks of proxies whose
+	// forwarding headers are believed.
+	TrustedProxies []netip.Prefix
+	// Headers are the headers carrying the client address, in order of
+	// preference, such as "X-Forwarded-For", "Forwarded" or "X-Real-IP".
+	// The first one present wins, so list only the ones the proxies set:
+	// clients can send the others. Without any, no forwarding header
+	// is believed.
+	Headers []string
+}
+
+type proxyConfigKey struct{}
+
+// WithProxyConfig returns a Constructor storing cfg in the context
+// for the middleware after it.
+func WithProxyConfig(cfg ProxyConfig) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTPContext(context.WithValue(ctx, proxyConfigKey{}, cfg), w, r)
+		})
+	}
+}
+
+// ProxyConfigFrom returns the ProxyConfig stored by WithProxyConfig.
+func ProxyConfigFrom(ctx context.Context) (ProxyConfig, bool) {
+	cfg, ok := ctx.Value(proxyConfigKey{}).(ProxyConfig)
+	return cfg, ok
+}
+
+// proxyConfig returns the ProxyConfig of the chain,
+// or else the one Server stored in the request context.
+func proxyConfig(ctx context.Context, r *http.Request) (ProxyConfig, bool) {
+	if cfg, ok := ProxyConfigFrom(ctx); ok {
+		return cfg, true
+	}
+	return ProxyConfigFrom(r.Context())
+}
+
+// ClientIP resolves the client address of r, believing the forwarding
+// headers only if r comes from a trusted proxy. Custom middleware, such
+// as rate limiters, use it to key clients consistently.
+func (cfg ProxyConfig) ClientIP(r *http.Request) netip.Addr {
+	return resolveClientIP(r, cfg.TrustedProxies, cfg.Headers)
+}
+
+// IsSecure reports whether r reached the server, or a trusted proxy,
+// over HTTPS.
+func (cfg ProxyConfig) IsSecure(r *http.Request) bool {
+	return isSecure(r, cfg.TrustedProxies)
+}
@@ -0,0 +1,56 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestProxyConfigOnChain(t *testing.T) {
+	cfg := ProxyConfig{TrustedProxies: proxies, Headers: []string{"X-Real-IP"}}
+	var ip netip.Addr
+	h := New(WithProxyConfig(cfg), RealIP(nil), RequireHTTPS(HTTPSOptions{})).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		ip = ClientIP(ctx)
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Real-IP", "203.0.113.9")
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	r.Header.Set("X-Forwarded-Proto", "https")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, ip, netip.MustParseAddr("203.0.113.9"))
+}
+
+func TestProxyConfigIgnoresUntrustedPeers(t *testing.T) {
+	cfg := ProxyConfig{TrustedProxies: proxies}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.9")
+	r.Header.Set("X-Forwarded-Proto", "https")
+
+	assert.Equal(t, cfg.ClientIP(r), netip.MustParseAddr("192.0.2.1"))
+	assert.False(t, cfg.IsSecure(r))
+}
+
+func TestServerProxyConfig(t *testing.T) {
+	var ip netip.Addr
+	h := New(RealIP(nil)).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		ip = ClientIP(ctx)
+	})
+	srv := &Server{Proxy: &ProxyConfig{TrustedProxies: proxies, Headers: []string{"X-Forwarded-For"}}}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-For", "203.0.113.9")
+	srv.withProxy(h).ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, ip, netip.MustParseAddr("203.0.113.9"))
+}
@@ -0,0 +1,99 @@
+package alice
+
+import (
+	"net/http"
+	"path"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+type pushKey struct{}
+
+type pushHints struct {
+	mu        sync.Mutex
+	resources []string
+}
+
+// ServerPush returns a Constructor issuing the push hints registered
+// with Push when the response header is written. Resources are pushed
+// with http.Pusher where the connection supports it (HTTP/2) and
+// announced as Link preload headers otherwise, which lets browsers
+// and CDNs that no longer accept pushes (or send 103 Early Hints)
+// fetch them early all the same.
+func ServerPush() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			hints := &pushHints{}
+			ctx = context.WithValue(ctx, pushKey{}, hints)
+			next.ServeHTTPContext(ctx, NewHookWriter(w, func() {
+				hints.mu.Lock()
+				resources := hints.resources
+				hints.mu.Unlock()
+				push(w, resources)
+			}), r)
+		})
+	}
+}
+
+// Push registers resources, given as absolute paths, to be pushed
+// with the response. Without ServerPush in the chain, they are pushed
+// right away. It must be called before the response is written.
+//
+//	alice.Push(ctx, w, "/static/app.css", "/static/app.js")
+func Push(ctx context.Context, w http.ResponseWriter, resources ...string) {
+	if hints, ok := ctx.Value(pushKey{}).(*pushHints); ok {
+		hints.mu.Lock()
+		hints.resources = append(hints.resources, resources...)
+		hints.mu.Unlock()
+		return
+	}
+	push(w, resources)
+}
+
+// push pushes resources, falling back to Link headers.
+func push(w http.ResponseWriter, resources []string) {
+	pusher := findPusher(w)
+	for _, res := range resources {
+		if pusher != nil && pusher.Push(res, nil) == nil {
+			continue
+		}
+		link := "<" + res + ">; rel=preload"
+		if as := preloadAs(res); as != "" {
+			link += "; as=" + as
+			if as == "font" {
+				link += "; crossorigin"
+			}
+		}
+		w.Header().Add("Link", link)
+	}
+}
+
+// findPusher looks for an http.Pusher through wrapping writers.
+func findPusher(w http.ResponseWriter) http.Pusher {
+	for {
+		if p, ok := w.(http.Pusher); ok {
+			return p
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return nil
+		}
+		w = u.Unwrap()
+	}
+}
+
+// preloadAs returns the preload destination for a resource path.
+func preloadAs(res string) string {
+	switch path.Ext(res) {
+	case ".css":
+		return "style"
+	case ".js", ".mjs":
+		return "script"
+	case ".woff", ".woff2", ".ttf", ".otf":
+		return "font"
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg", ".ico":
+		return "image"
+	}
+	return ""
+}
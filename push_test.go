@@ -0,0 +1,53 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type recordingPusher struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (p *recordingPusher) Push(target string, opts *http.PushOptions) error {
+	p.pushed = append(p.pushed, target)
+	return nil
+}
+
+func pushHandler(resources ...string) ContextHandler {
+	return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		Push(ctx, w, resources...)
+		w.Write([]byte("page"))
+	})
+}
+
+func TestServerPushUsesPusher(t *testing.T) {
+	h := New(ServerTiming(), ServerPush()).ThenWithContext(context.Background(), pushHandler("/app.css", "/app.js"))
+	w := &recordingPusher{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, w.pushed, []string{"/app.css", "/app.js"})
+	assert.Equal(t, w.Header().Get("Link"), "")
+}
+
+func TestServerPushFallsBackToLinkHeaders(t *testing.T) {
+	h := New(ServerPush()).ThenWithContext(context.Background(), pushHandler("/app.css", "/font.woff2", "/data"))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, w.Header()["Link"], []string{
+		"</app.css>; rel=preload; as=style",
+		"</font.woff2>; rel=preload; as=font; crossorigin",
+		"</data>; rel=preload",
+	})
+	assert.Equal(t, w.Body.String(), "page")
+}
+
+func TestPushWithoutMiddleware(t *testing.T) {
+	w := httptest.NewRecorder()
+	pushHandler("/app.js").ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, w.Header().Get("Link"), "</app.js>; rel=preload; as=script")
+}
@@ -0,0 +1,134 @@
+package alice
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// MaxRangeBodySize is the largest response Ranges buffers to serve
+// byte ranges of. Larger responses are sent whole.
+const MaxRangeBodySize = 32 << 20
+
+// Ranges returns a Constructor serving byte ranges of 200 OK responses
+// to GET requests, for handlers that do not support ranges themselves,
+// such as generated media or cached responses:
+//
+//	media := alice.New(alice.Compress(), alice.Ranges())
+//
+// Such responses advertise "Accept-Ranges: bytes". When a request has
+// a Range header, the response is buffered, then served as
+// http.ServeContent does: 206 Partial Content with the requested
+// ranges, 416 if none is satisfiable, or the whole response if
+// If-Range does not match the ETag or Last-Modified header the handler
+// set. Responses with a Content-Encoding, an Accept-Ranges header of
+// their own, or that are flushed or larger than MaxRangeBodySize are
+// sent unchanged. Put Ranges after Compress, which leaves partial
+// responses uncompressed.
+func Ranges() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if r.Method != "GET" && r.Method != "HEAD" {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			rw := &rangeWriter{ResponseWriter: w, r: r}
+			next.ServeHTTPContext(ctx, rw, r)
+			rw.finish()
+		})
+	}
+}
+
+// rangeWriter decides on the first write whether to buffer the response
+// to serve ranges of it.
+type rangeWriter struct {
+	http.ResponseWriter
+	r       *http.Request
+	buf     *bytes.Buffer
+	decided bool
+}
+
+func (rw *rangeWriter) WriteHeader(code int) {
+	if rw.decided {
+		if rw.buf == nil {
+			rw.ResponseWriter.WriteHeader(code)
+		}
+		return
+	}
+	rw.decided = true
+	h := rw.Header()
+	if code != http.StatusOK || h.Get("Content-Encoding") != "" || h.Get("Accept-Ranges") != "" {
+		rw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	h.Set("Accept-Ranges", "bytes")
+	if rw.r.Method == "GET" && rw.r.Header.Get("Range") != "" {
+		rw.buf = new(bytes.Buffer)
+		return
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *rangeWriter) Write(b []byte) (int, error) {
+	if !rw.decided {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.buf == nil {
+		return rw.ResponseWriter.Write(b)
+	}
+	if rw.buf.Len()+len(b) > MaxRangeBodySize {
+		if err := rw.release(); err != nil {
+			return 0, err
+		}
+		return rw.ResponseWriter.Write(b)
+	}
+	return rw.buf.Write(b)
+}
+
+func (rw *rangeWriter) Flush() {
+	if !rw.decided {
+		rw.WriteHeader(http.StatusOK)
+	}
+	if rw.buf != nil {
+		rw.release()
+	}
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *rangeWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (rw *rangeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.decided = true
+	}
+	return conn, brw, err
+}
+
+// release gives up serving ranges, sending the buffered response whole.
+func (rw *rangeWriter) release() error {
+	body := rw.buf.Bytes()
+	rw.buf = nil
+	rw.Header().Del("Accept-Ranges")
+	rw.ResponseWriter.WriteHeader(http.StatusOK)
+	_, err := rw.ResponseWriter.Write(body)
+	return err
+}
+
+// finish serves the requested ranges of a buffered response.
+func (rw *rangeWriter) finish() {
+	if rw.buf == nil {
+		return
+	}
+	h := rw.Header()
+	modtime, _ := http.ParseTime(h.Get("Last-Modified"))
+	h.Del("Content-Length")
+	http.ServeContent(rw.ResponseWriter, rw.r, "", modtime, bytes.NewReader(rw.buf.Bytes()))
+}
@@ -0,0 +1,101 @@
+package alice
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var alphabet = ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("ETag", `"v1"`)
+	io.WriteString(w, "abcdefghijklmnopqrstuvwxyz")
+})
+
+func serveRange(h ContextHandler, header map[string]string, cons ...Constructor) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/file", nil)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	New(append(cons, Ranges())...).ThenWithContext(context.Background(), h).ServeHTTP(w, r)
+	return w
+}
+
+func TestRangesServesPartialContent(t *testing.T) {
+	w := serveRange(alphabet, map[string]string{"Range": "bytes=2-5"})
+
+	assert.Equal(t, w.Code, http.StatusPartialContent)
+	assert.Equal(t, w.Body.String(), "cdef")
+	assert.Equal(t, w.Header().Get("Content-Range"), "bytes 2-5/26")
+	assert.Equal(t, w.Header().Get("Content-Length"), "4")
+	assert.Equal(t, w.Header().Get("ETag"), `"v1"`)
+}
+
+func TestRangesAdvertisesSupport(t *testing.T) {
+	w := serveRange(alphabet, nil)
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), "abcdefghijklmnopqrstuvwxyz")
+	assert.Equal(t, w.Header().Get("Accept-Ranges"), "bytes")
+}
+
+func TestRangesIfRange(t *testing.T) {
+	w := serveRange(alphabet, map[string]string{"Range": "bytes=0-2", "If-Range": `"v1"`})
+	assert.Equal(t, w.Code, http.StatusPartialContent)
+	assert.Equal(t, w.Body.String(), "abc")
+
+	w = serveRange(alphabet, map[string]string{"Range": "bytes=0-2", "If-Range": `"v0"`})
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), "abcdefghijklmnopqrstuvwxyz")
+}
+
+func TestRangesUnsatisfiable(t *testing.T) {
+	w := serveRange(alphabet, map[string]string{"Range": "bytes=30-40"})
+
+	assert.Equal(t, w.Code, http.StatusRequestedRangeNotSatisfiable)
+	assert.Equal(t, w.Header().Get("Content-Range"), "bytes */26")
+}
+
+func TestRangesMultipart(t *testing.T) {
+	w := serveRange(alphabet, map[string]string{"Range": "bytes=0-1,24-25"})
+
+	assert.Equal(t, w.Code, http.StatusPartialContent)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "multipart/byteranges; boundary="))
+	assert.Contains(t, w.Body.String(), "Content-Range: bytes 0-1/26\r\nContent-Type: text/plain\r\n\r\nab\r\n")
+	assert.Contains(t, w.Body.String(), "Content-Range: bytes 24-25/26\r\nContent-Type: text/plain\r\n\r\nyz\r\n")
+}
+
+func TestRangesLeavesOtherResponses(t *testing.T) {
+	for _, h := range []ContextHandler{
+		ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "missing", http.StatusNotFound)
+		}),
+		ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "gzip")
+			io.WriteString(w, "compressed")
+		}),
+		ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "streamed")
+			w.(http.Flusher).Flush()
+		}),
+	} {
+		w := serveRange(h, map[string]string{"Range": "bytes=0-1"})
+		assert.NotEqual(t, w.Code, http.StatusPartialContent)
+		assert.Equal(t, w.Header().Get("Accept-Ranges"), "")
+		assert.Equal(t, w.Header().Get("Content-Range"), "")
+	}
+}
+
+func TestRangesAfterCompress(t *testing.T) {
+	w := serveRange(alphabet, map[string]string{"Range": "bytes=2-5", "Accept-Encoding": "gzip"}, Compress())
+
+	assert.Equal(t, w.Code, http.StatusPartialContent)
+	assert.Equal(t, w.Header().Get("Content-Encoding"), "")
+	assert.Equal(t, w.Body.String(), "cdef")
+}
@@ -0,0 +1,163 @@
+package alice
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ResponseRecorder wraps an http.ResponseWriter,
+// recording the status code and the number of bytes written
+// so middleware can inspect the response after the handler returns.
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// NewResponseRecorder wraps w in a ResponseRecorder.
+// If w already is a ResponseRecorder, it is returned as is.
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	if rec, ok := w.(*ResponseRecorder); ok {
+		return rec
+	}
+	return &ResponseRecorder{ResponseWriter: w}
+}
+
+// WriteHeader records and writes the status code.
+func (rec *ResponseRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes b, recording an implicit 200 status
+// if WriteHeader has not been called yet.
+func (rec *ResponseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Status returns the status code written,
+// or http.StatusOK if nothing has been written yet.
+func (rec *ResponseRecorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+// Written reports whether the status code has been written.
+func (rec *ResponseRecorder) Written() bool {
+	return rec.status != 0
+}
+
+// BytesWritten returns the number of body bytes written.
+func (rec *ResponseRecorder) BytesWritten() int64 {
+	return rec.bytes
+}
+
+// Flush implements http.Flusher if the wrapped writer does.
+func (rec *ResponseRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter,
+// which lets http.ResponseController reach it.
+func (rec *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Hijack lets the handler take over the connection, as for WebSockets,
+// if the wrapped writer supports it.
+func (rec *ResponseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(rec.ResponseWriter).Hijack()
+}
+
+// SetReadDeadline sets the read deadline of the underlying connection,
+// as http.ResponseController does.
+func (rec *ResponseRecorder) SetReadDeadline(deadline time.Time) error {
+	return http.NewResponseController(rec.ResponseWriter).SetReadDeadline(deadline)
+}
+
+// SetWriteDeadline sets the write deadline of the underlying connection,
+// as http.ResponseController does.
+func (rec *ResponseRecorder) SetWriteDeadline(deadline time.Time) error {
+	return http.NewResponseController(rec.ResponseWriter).SetWriteDeadline(deadline)
+}
+
+// EnableFullDuplex lets the handler read the request body after
+// writing the response, as http.ResponseController does.
+func (rec *ResponseRecorder) EnableFullDuplex() error {
+	return http.NewResponseController(rec.ResponseWriter).EnableFullDuplex()
+}
+
+// HookWriter wraps an http.ResponseWriter, calling a hook once just
+// before the status code is written, so middleware can add headers
+// depending on what the handler did, such as Server-Timing.
+// Hijacking the connection cancels the hook.
+type HookWriter struct {
+	http.ResponseWriter
+	hook func()
+	done bool
+}
+
+// NewHookWriter wraps w in a HookWriter calling hook.
+func NewHookWriter(w http.ResponseWriter, hook func()) *HookWriter {
+	return &HookWriter{ResponseWriter: w, hook: hook}
+}
+
+// Run calls the hook unless it already ran or was canceled,
+// as middleware do when the handler returns without writing.
+func (hw *HookWriter) Run() {
+	if !hw.done {
+		hw.done = true
+		hw.hook()
+	}
+}
+
+// WriteHeader runs the hook and writes the status code.
+func (hw *HookWriter) WriteHeader(code int) {
+	hw.Run()
+	hw.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes b, running the hook first if nothing was written yet.
+func (hw *HookWriter) Write(b []byte) (int, error) {
+	hw.Run()
+	return hw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher if the wrapped writer does.
+func (hw *HookWriter) Flush() {
+	hw.Run()
+	if f, ok := hw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter.
+func (hw *HookWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
+// Hijack lets the handler take over the connection
+// if the wrapped writer supports it.
+func (hw *HookWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := http.NewResponseController(hw.ResponseWriter).Hijack()
+	if err == nil {
+		hw.done = true
+	}
+	return conn, brw, err
+}
@@ -0,0 +1,93 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestResponseRecorderRecordsStatusAndBytes(t *testing.T) {
+	rec := NewResponseRecorder(httptest.NewRecorder())
+	assert.False(t, rec.Written())
+	assert.Equal(t, rec.Status(), http.StatusOK)
+
+	rec.WriteHeader(http.StatusTeapot)
+	rec.Write([]byte("short and stout"))
+
+	assert.True(t, rec.Written())
+	assert.Equal(t, rec.Status(), http.StatusTeapot)
+	assert.Equal(t, rec.BytesWritten(), int64(15))
+}
+
+func TestResponseRecorderImplicitStatus(t *testing.T) {
+	rec := NewResponseRecorder(httptest.NewRecorder())
+	rec.Write([]byte("app\n"))
+
+	assert.True(t, rec.Written())
+	assert.Equal(t, rec.Status(), http.StatusOK)
+}
+
+func TestNewResponseRecorderDoesNotDoubleWrap(t *testing.T) {
+	rec := NewResponseRecorder(httptest.NewRecorder())
+	assert.True(t, NewResponseRecorder(rec) == rec)
+}
+
+func TestResponseRecorderDeadlines(t *testing.T) {
+	rec := NewResponseRecorder(httptest.NewRecorder())
+	assert.ErrorIs(t, rec.SetReadDeadline(time.Now()), http.ErrNotSupported)
+	assert.ErrorIs(t, rec.SetWriteDeadline(time.Now()), http.ErrNotSupported)
+	assert.ErrorIs(t, rec.EnableFullDuplex(), http.ErrNotSupported)
+}
+
+func TestHookWriterRunsOnce(t *testing.T) {
+	for name, write := range map[string]func(w http.ResponseWriter){
+		"WriteHeader": func(w http.ResponseWriter) { w.WriteHeader(http.StatusCreated) },
+		"Write":       func(w http.ResponseWriter) { w.Write([]byte("body")) },
+		"Flush":       func(w http.ResponseWriter) { w.(http.Flusher).Flush() },
+	} {
+		rec := httptest.NewRecorder()
+		runs := 0
+		hw := NewHookWriter(rec, func() {
+			runs++
+			rec.Header().Set("X-Hook", "ran")
+		})
+		write(hw)
+		hw.Write([]byte("more"))
+		hw.Run()
+		assert.Equal(t, runs, 1, name)
+		assert.Equal(t, rec.Result().Header.Get("X-Hook"), "ran", name)
+	}
+}
+
+func TestWrappersExposeResponseController(t *testing.T) {
+	var errs []error
+	h := New(
+		Recover(RecoverOptions{}),
+		CollectStats(),
+		ServerTiming(),
+		ServerPush(),
+		Pagination(PageOptions{}),
+		Compress(),
+	).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		errs = append(errs,
+			rc.SetReadDeadline(time.Now().Add(time.Minute)),
+			rc.SetWriteDeadline(time.Now().Add(time.Minute)),
+			rc.EnableFullDuplex(),
+			rc.Flush(),
+		)
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	assert.Equal(t, errs, []error{nil, nil, nil, nil})
+}
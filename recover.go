@@ -0,0 +1,75 @@
+package alice
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"golang.org/x/net/context"
+)
+
+// PanicError is a panic recovered by Recover.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error,
+// so errors.Is and errors.As see through the panic.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// RecoverOptions configure Recover.
+type RecoverOptions struct {
+	// AsErrors turns panics into *PanicError errors reported to
+	// middleware tracking errors (see TrackErrors) and rendered with
+	// WriteError, so the ErrorMapper (see MapErrors) handles them
+	// like errors returned by an ErrorHandlerFunc. MapErrors and
+	// TrackErrors must come before Recover in the chain, so their
+	// context reaches it.
+	// Otherwise a plain 500 Internal Server Error is written.
+	AsErrors bool
+}
+
+// Recover returns a Constructor recovering panics further down the
+// chain, logging them with their stack and answering with 500 Internal
+// Server Error unless the response was already started.
+// Panics with http.ErrAbortHandler are passed on, to abort the response.
+//
+//	alice.New(alice.MapErrors(mapper), alice.Recover(alice.RecoverOptions{AsErrors: true}))
+func Recover(opts RecoverOptions) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			rec := NewResponseRecorder(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(v)
+				}
+				pe := &PanicError{Value: v, Stack: debug.Stack()}
+				logger().Error("alice: handler panicked", "error", fmt.Sprint(v), "path", r.URL.Path, "stack", string(pe.Stack))
+				if opts.AsErrors {
+					ReportError(ctx, pe)
+					WriteError(ctx, rec, pe)
+					return
+				}
+				if !rec.Written() {
+					http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTPContext(ctx, rec, r)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package alice
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var errOutOfStock = errors.New("out of stock")
+
+func panicking(v any) ContextHandler {
+	return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		panic(v)
+	})
+}
+
+func TestRecover(t *testing.T) {
+	l := &memoryLogger{}
+	SetLogger(l)
+	defer SetLogger(nil)
+
+	w := httptest.NewRecorder()
+	New(Recover(RecoverOptions{})).ThenWithContext(context.Background(), panicking("boom")).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	assert.Equal(t, w.Body.String(), "Internal Server Error\n")
+	assert.Equal(t, len(l.lines), 1)
+	assert.True(t, strings.HasPrefix(l.lines[0], "ERROR alice: handler panicked[error boom path / stack goroutine"))
+}
+
+func TestRecoverAsErrors(t *testing.T) {
+	SetLogger(&memoryLogger{})
+	defer SetLogger(nil)
+
+	mapper := func(err error) *HTTPError {
+		if errors.Is(err, errOutOfStock) {
+			return &HTTPError{Status: http.StatusConflict, Code: "out_of_stock"}
+		}
+		return nil
+	}
+	var reported error
+	track := func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			ctx, lastErr := TrackErrors(ctx)
+			next.ServeHTTPContext(ctx, w, r)
+			reported = lastErr()
+		})
+	}
+	h := New(track, MapErrors(mapper), Recover(RecoverOptions{AsErrors: true})).ThenWithContext(context.Background(), panicking(errOutOfStock))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Code, http.StatusConflict)
+	assert.Contains(t, w.Body.String(), `"code":"out_of_stock"`)
+	var pe *PanicError
+	assert.True(t, errors.As(reported, &pe))
+	assert.Equal(t, pe.Value, errOutOfStock)
+	assert.Contains(t, string(pe.Stack), "panicking")
+	assert.Equal(t, pe.Error(), "panic: out of stock")
+}
+
+func TestRecoverAfterWrite(t *testing.T) {
+	SetLogger(&memoryLogger{})
+	defer SetLogger(nil)
+
+	h := New(Recover(RecoverOptions{AsErrors: true})).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late")
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, w.Code, http.StatusAccepted)
+	assert.Equal(t, w.Body.Len(), 0)
+}
+
+func TestRecoverPassesAbort(t *testing.T) {
+	h := New(Recover(RecoverOptions{})).ThenWithContext(context.Background(), panicking(http.ErrAbortHandler))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
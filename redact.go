@@ -0,0 +1,188 @@
+package alice
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultRedactionMask replaces redacted values.
+const DefaultRedactionMask = "[REDACTED]"
+
+// A Redactor masks sensitive headers and JSON body fields
+// before requests and responses are logged or stored.
+type Redactor struct {
+	// Mask replaces redacted values, DefaultRedactionMask if empty.
+	Mask string
+
+	headers map[string]bool
+	fields  map[string]bool
+}
+
+// NewRedactor returns a Redactor masking the given headers
+// and JSON fields, both matched case-insensitively.
+func NewRedactor(headers, fields []string) *Redactor {
+	rd := &Redactor{
+		headers: make(map[string]bool, len(headers)),
+		fields:  make(map[string]bool, len(fields)),
+	}
+	for _, h := range headers {
+		rd.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, f := range fields {
+		rd.fields[strings.ToLower(f)] = true
+	}
+	return rd
+}
+
+// DefaultRedactor masks credentials commonly found in headers and bodies.
+// LogHeaders and Capture use it unless configured otherwise.
+var DefaultRedactor = NewRedactor(
+	[]string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+	[]string{"password", "token", "access_token", "refresh_token", "secret", "client_secret", "api_key", "apikey"},
+)
+
+func (rd *Redactor) mask() string {
+	if rd.Mask == "" {
+		return DefaultRedactionMask
+	}
+	return rd.Mask
+}
+
+// Header returns a copy of h with the configured headers masked.
+func (rd *Redactor) Header(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, vs := range h {
+		if rd.headers[http.CanonicalHeaderKey(k)] {
+			masked := make([]string, len(vs))
+			for i := range vs {
+				masked[i] = rd.mask()
+			}
+			out[k] = masked
+			continue
+		}
+		out[k] = append([]string(nil), vs...)
+	}
+	return out
+}
+
+// URL returns u as a string with the values of the query parameters
+// named like a configured field or header masked.
+func (rd *Redactor) URL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	u2 := *u
+	u2.RawQuery = rd.query(u.RawQuery)
+	return u2.String()
+}
+
+// Form returns an application/x-www-form-urlencoded body with the values
+// of the fields named like a configured field or header masked.
+func (rd *Redactor) Form(body []byte) []byte {
+	return []byte(rd.query(string(body)))
+}
+
+// query masks the values of the sensitive parameters of a URL-encoded query.
+func (rd *Redactor) query(raw string) string {
+	params := strings.Split(raw, "&")
+	for i, param := range params {
+		k, _, _ := strings.Cut(param, "=")
+		name, err := url.QueryUnescape(k)
+		if err != nil {
+			name = k
+		}
+		if rd.fields[strings.ToLower(name)] || rd.headers[http.CanonicalHeaderKey(name)] {
+			params[i] = k + "=" + rd.mask()
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// JSON returns body with the configured fields masked at any depth.
+// Bodies that are not valid JSON are returned unchanged.
+func (rd *Redactor) JSON(body []byte) []byte {
+	var v any
+	if len(rd.fields) == 0 || json.Unmarshal(body, &v) != nil {
+		return body
+	}
+	if !rd.redactValue(v) {
+		return body
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return b
+}
+
+// redactValue masks fields in v in place
+// and reports whether anything was masked.
+func (rd *Redactor) redactValue(v any) bool {
+	masked := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			if rd.fields[strings.ToLower(k)] {
+				v[k] = rd.mask()
+				masked = true
+			} else if rd.redactValue(e) {
+				masked = true
+			}
+		}
+	case []any:
+		for _, e := range v {
+			if rd.redactValue(e) {
+				masked = true
+			}
+		}
+	}
+	return masked
+}
+
+// ReplaceAttr masks log attributes named like a configured field or header.
+// Use it as slog.HandlerOptions.ReplaceAttr so nothing logged through
+// the handler leaks them:
+//
+//	h := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+//	    ReplaceAttr: alice.DefaultRedactor.ReplaceAttr,
+//	})
+func (rd *Redactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if rd.fields[strings.ToLower(a.Key)] || rd.headers[http.CanonicalHeaderKey(a.Key)] {
+		return slog.String(a.Key, rd.mask())
+	}
+	return a
+}
+
+// LogHeaders returns LogAttrs adding the named request headers
+// (all of them if none are named) to the request logger
+// as a "headers" group, redacted by rd (DefaultRedactor if nil).
+func LogHeaders(rd *Redactor, names ...string) LogAttrs {
+	if rd == nil {
+		rd = DefaultRedactor
+	}
+	return func(ctx context.Context, r *http.Request) []slog.Attr {
+		h := rd.Header(r.Header)
+		if len(names) > 0 {
+			picked := make(http.Header, len(names))
+			for _, name := range names {
+				if vs, ok := h[http.CanonicalHeaderKey(name)]; ok {
+					picked[http.CanonicalHeaderKey(name)] = vs
+				}
+			}
+			h = picked
+		}
+		if len(h) == 0 {
+			return nil
+		}
+		args := make([]any, 0, len(h))
+		for k, vs := range h {
+			args = append(args, slog.String(k, strings.Join(vs, ", ")))
+		}
+		return []slog.Attr{slog.Group("headers", args...)}
+	}
+}
@@ -0,0 +1,71 @@
+package alice
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRedactorHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer secret")
+	h.Set("Accept", "text/plain")
+
+	out := DefaultRedactor.Header(h)
+	assert.Equal(t, out.Get("Authorization"), DefaultRedactionMask)
+	assert.Equal(t, out.Get("Accept"), "text/plain")
+	assert.Equal(t, h.Get("Authorization"), "Bearer secret")
+}
+
+func TestRedactorJSON(t *testing.T) {
+	rd := NewRedactor(nil, []string{"password", "token"})
+	rd.Mask = "***"
+
+	body := `{"user":"alice","Password":"hunter2","sessions":[{"token":"abc","id":1}]}`
+	assert.Equal(t, string(rd.JSON([]byte(body))), `{"Password":"***","sessions":[{"id":1,"token":"***"}],"user":"alice"}`)
+	assert.Equal(t, string(rd.JSON([]byte("password=hunter2"))), "password=hunter2")
+	assert.Equal(t, string(rd.JSON([]byte(`{"user":"alice"}`))), `{"user":"alice"}`)
+}
+
+func TestRedactorURL(t *testing.T) {
+	u, _ := url.Parse("/reports?api_key=s3cret&page=2&X-Api-Key=other&access%5Ftoken=abc")
+	assert.Equal(t, DefaultRedactor.URL(u), "/reports?api_key=[REDACTED]&page=2&X-Api-Key=[REDACTED]&access%5Ftoken=[REDACTED]")
+	assert.Equal(t, u.RawQuery, "api_key=s3cret&page=2&X-Api-Key=other&access%5Ftoken=abc")
+
+	u, _ = url.Parse("/reports")
+	assert.Equal(t, DefaultRedactor.URL(u), "/reports")
+}
+
+func TestRedactorForm(t *testing.T) {
+	assert.Equal(t, string(DefaultRedactor.Form([]byte("user=alice&Password=hunter2&token"))), "user=alice&Password=[REDACTED]&token=[REDACTED]")
+}
+
+func TestRedactorReplaceAttr(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: DefaultRedactor.ReplaceAttr}))
+	logger.Info("login", "user", "alice", "password", "hunter2")
+
+	assert.Contains(t, buf.String(), "user=alice password=[REDACTED]")
+}
+
+func TestLogHeadersRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	base := slog.New(slog.NewTextHandler(&buf, nil))
+	app := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		LoggerFrom(ctx).Info("hello")
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Cookie", "session=abc")
+	r.Header.Set("User-Agent", "test")
+	New(Logging(base, LogHeaders(nil, "Cookie"))).ThenWithContext(context.Background(), app).ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Contains(t, buf.String(), "headers.Cookie=[REDACTED]")
+	assert.NotContains(t, buf.String(), "User-Agent")
+}
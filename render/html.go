@@ -0,0 +1,162 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// DataFunc adds request-scoped values from the context to the data
+// of every template, such as the CSRF token, the user or the locale.
+type DataFunc func(ctx context.Context, data map[string]any)
+
+// HTMLOptions configure a Renderer.
+type HTMLOptions struct {
+	// FS holds the templates, e.g. an embed.FS or, for hot-reload,
+	// os.DirFS("templates").
+	FS fs.FS
+	// Pages is the directory of the page templates, rendered by name:
+	// "pages/users/show.html" is the page "users/show".
+	// It defaults to "pages".
+	Pages string
+	// Layouts and Partials are glob patterns of templates
+	// available to every page. They default to "layouts/*.html"
+	// and "partials/*.html".
+	Layouts, Partials string
+	// Layout is the template pages are rendered through if it exists;
+	// it includes the page with {{template "content" .}}.
+	// It defaults to "layout".
+	Layout string
+	// Funcs are available to all templates.
+	Funcs template.FuncMap
+	// Data enrich the data of every template.
+	Data []DataFunc
+	// Dev re-parses the templates on every render,
+	// so edits show up without a restart.
+	Dev bool
+}
+
+// Renderer renders HTML pages composed of layouts, partials and pages.
+//
+//	rd, err := render.NewRenderer(render.HTMLOptions{
+//	    FS: templates,
+//	    Data: []render.DataFunc{func(ctx context.Context, data map[string]any) {
+//	        data["Locale"] = i18n.LocaleFrom(ctx)
+//	    }},
+//	})
+//	...
+//	rd.Render(ctx, w, "users/show", user)
+//
+// Templates see the handler's data as .Data next to the values added
+// by the DataFuncs, e.g. {{.Data.Name}} and {{.Locale}}.
+type Renderer struct {
+	opts HTMLOptions
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// NewRenderer parses the templates described by opts.
+func NewRenderer(opts HTMLOptions) (*Renderer, error) {
+	if opts.Pages == "" {
+		opts.Pages = "pages"
+	}
+	if opts.Layouts == "" {
+		opts.Layouts = "layouts/*.html"
+	}
+	if opts.Partials == "" {
+		opts.Partials = "partials/*.html"
+	}
+	if opts.Layout == "" {
+		opts.Layout = "layout"
+	}
+	rd := &Renderer{opts: opts}
+	pages, err := rd.parse()
+	if err != nil {
+		return nil, err
+	}
+	rd.pages = pages
+	return rd, nil
+}
+
+// parse parses every page together with the layouts and partials.
+func (rd *Renderer) parse() (map[string]*template.Template, error) {
+	var shared []string
+	for _, pattern := range []string{rd.opts.Layouts, rd.opts.Partials} {
+		matches, err := fs.Glob(rd.opts.FS, pattern)
+		if err != nil {
+			return nil, err
+		}
+		shared = append(shared, matches...)
+	}
+
+	pages := make(map[string]*template.Template)
+	err := fs.WalkDir(rd.opts.FS, rd.opts.Pages, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(p, rd.opts.Pages+"/"), path.Ext(p))
+		t := template.New(path.Base(p)).Funcs(rd.opts.Funcs)
+		t, err = t.ParseFS(rd.opts.FS, append(append([]string(nil), shared...), p)...)
+		if err != nil {
+			return err
+		}
+		pages[name] = t
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// Render renders the page name with data as text/html.
+// Failures, including unknown pages, are written with alice.WriteError
+// before anything of the page is sent.
+func (rd *Renderer) Render(ctx context.Context, w http.ResponseWriter, name string, data any) {
+	rd.RenderStatus(ctx, w, http.StatusOK, name, data)
+}
+
+// RenderStatus works like Render with the given status code.
+func (rd *Renderer) RenderStatus(ctx context.Context, w http.ResponseWriter, status int, name string, data any) {
+	if rd.opts.Dev {
+		pages, err := rd.parse()
+		if err != nil {
+			alice.WriteError(ctx, w, err)
+			return
+		}
+		rd.mu.Lock()
+		rd.pages = pages
+		rd.mu.Unlock()
+	}
+	rd.mu.RLock()
+	t, ok := rd.pages[name]
+	rd.mu.RUnlock()
+	if !ok {
+		alice.WriteError(ctx, w, fmt.Errorf("render: no page %q", name))
+		return
+	}
+
+	view := map[string]any{"Data": data}
+	for _, fn := range rd.opts.Data {
+		fn(ctx, view)
+	}
+	entry := path.Base(t.Name())
+	if t.Lookup(rd.opts.Layout) != nil {
+		entry = rd.opts.Layout
+	}
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, entry, view); err != nil {
+		alice.WriteError(ctx, w, err)
+		return
+	}
+	write(w, status, "text/html; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,65 @@
+package render
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type csrfKey struct{}
+
+func templates() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.html":    {Data: []byte(`{{define "layout"}}<html>{{template "content" .}}</html>{{end}}`)},
+		"partials/csrf.html":   {Data: []byte(`{{define "csrf"}}<input name="csrf" value="{{.CSRF}}">{{end}}`)},
+		"pages/users/new.html": {Data: []byte(`{{define "content"}}<h1>{{.Data.Title}}</h1>{{template "csrf" .}}{{end}}`)},
+	}
+}
+
+func TestRendererRendersPagesInLayout(t *testing.T) {
+	rd, err := NewRenderer(HTMLOptions{
+		FS: templates(),
+		Data: []DataFunc{func(ctx context.Context, data map[string]any) {
+			data["CSRF"] = ctx.Value(csrfKey{})
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.WithValue(context.Background(), csrfKey{}, "t0ken")
+	w := httptest.NewRecorder()
+	rd.Render(ctx, w, "users/new", map[string]string{"Title": "<New user>"})
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("Content-Type"), "text/html; charset=utf-8")
+	assert.Equal(t, w.Body.String(), `<html><h1>&lt;New user&gt;</h1><input name="csrf" value="t0ken"></html>`)
+}
+
+func TestRendererUnknownPage(t *testing.T) {
+	rd, _ := NewRenderer(HTMLOptions{FS: templates()})
+	w := httptest.NewRecorder()
+	rd.Render(context.Background(), w, "users/missing", nil)
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+}
+
+func TestRendererDevReloads(t *testing.T) {
+	fsys := templates()
+	rd, _ := NewRenderer(HTMLOptions{FS: fsys, Dev: true})
+
+	fsys["pages/users/new.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}changed{{end}}`)}
+	w := httptest.NewRecorder()
+	rd.Render(context.Background(), w, "users/new", nil)
+	assert.Equal(t, w.Body.String(), "<html>changed</html>")
+}
+
+func TestNewRendererReportsParseErrors(t *testing.T) {
+	fsys := templates()
+	fsys["pages/broken.html"] = &fstest.MapFile{Data: []byte(`{{if}}`)}
+	_, err := NewRenderer(HTMLOptions{FS: fsys})
+	assert.NotNil(t, err)
+}
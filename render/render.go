@@ -0,0 +1,120 @@
+// Package render writes handler responses, routing failures through
+// the chain's error mapper (see alice.MapErrors).
+//
+// Respond writes data in the content type negotiated by alice.Negotiate,
+// with JSON and XML for handlers serving a single one:
+//
+//	chain := alice.New(alice.Negotiate("application/json", "application/xml"))
+//	h := chain.ThenFuncWithContext(ctx, func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+//	    render.Respond(ctx, w, http.StatusOK, user)
+//	})
+//
+// A Renderer writes HTML pages from templates composed of layouts,
+// partials and pages.
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// JSON writes v as JSON. The content type is the negotiated one
+// if it is a JSON type, such as application/vnd.api+json,
+// application/json otherwise.
+func JSON(ctx context.Context, w http.ResponseWriter, status int, v any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		alice.WriteError(ctx, w, err)
+		return
+	}
+	write(w, status, negotiated(ctx, "application/json", "+json"), buf.Bytes())
+}
+
+// XML writes v as XML, preceded by the XML declaration.
+// The content type is the negotiated one if it is an XML type,
+// application/xml otherwise.
+func XML(ctx context.Context, w http.ResponseWriter, status int, v any) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(v); err != nil {
+		alice.WriteError(ctx, w, err)
+		return
+	}
+	write(w, status, negotiated(ctx, "application/xml", "+xml")+"; charset=utf-8", buf.Bytes())
+}
+
+// Text writes s as text/plain.
+func Text(ctx context.Context, w http.ResponseWriter, status int, s string) {
+	write(w, status, "text/plain; charset=utf-8", []byte(s))
+}
+
+// Encode writes v encoded with c as contentType.
+func Encode(ctx context.Context, w http.ResponseWriter, status int, contentType string, c alice.Codec, v any) {
+	body, err := c.Marshal(v)
+	if err != nil {
+		alice.WriteError(ctx, w, err)
+		return
+	}
+	write(w, status, contentType, body)
+}
+
+// NoContent writes a 204 No Content response.
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Respond writes v in the type negotiated by alice.Negotiate:
+// XML for XML types, v formatted with fmt.Sprint for text/plain,
+// the registered Codec for types registered with alice.RegisterCodec
+// and JSON for anything else, including when Negotiate did not run.
+// An error v is written with alice.WriteError instead.
+func Respond(ctx context.Context, w http.ResponseWriter, status int, v any) {
+	if err, ok := v.(error); ok {
+		alice.WriteError(ctx, w, err)
+		return
+	}
+	t := alice.Accepted(ctx)
+	if c, ok := alice.CodecFor(t); ok {
+		Encode(ctx, w, status, t, c, v)
+		return
+	}
+	switch {
+	case isXML(t):
+		XML(ctx, w, status, v)
+	case t == "text/plain":
+		Text(ctx, w, status, fmt.Sprint(v))
+	default:
+		JSON(ctx, w, status, v)
+	}
+}
+
+func isXML(t string) bool {
+	return t == "application/xml" || t == "text/xml" || strings.HasSuffix(t, "+xml")
+}
+
+// negotiated returns the negotiated type if it is fallback or has suffix,
+// fallback otherwise.
+func negotiated(ctx context.Context, fallback, suffix string) string {
+	t := alice.Accepted(ctx)
+	if t == fallback || strings.HasSuffix(t, suffix) || suffix == "+xml" && t == "text/xml" {
+		return t
+	}
+	return fallback
+}
+
+func write(w http.ResponseWriter, status int, contentType string, body []byte) {
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Length", strconv.Itoa(len(body)))
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	w.Write(body)
+}
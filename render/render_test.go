@@ -0,0 +1,59 @@
+package render
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type user struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func (u user) String() string { return u.Name }
+
+func respond(accept string, v any) *httptest.ResponseRecorder {
+	h := alice.New(alice.Negotiate("application/json", "application/xml", "text/plain")).ThenWithContext(context.Background(), alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		Respond(ctx, w, http.StatusCreated, v)
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", accept)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestRespondNegotiates(t *testing.T) {
+	w := respond("application/json", user{"alice"})
+	assert.Equal(t, w.Code, http.StatusCreated)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Equal(t, w.Body.String(), "{\"name\":\"alice\"}\n")
+
+	w = respond("application/xml", user{"alice"})
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/xml; charset=utf-8")
+	assert.Equal(t, w.Body.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n<user><name>alice</name></user>")
+
+	w = respond("text/plain", user{"alice"})
+	assert.Equal(t, w.Header().Get("Content-Type"), "text/plain; charset=utf-8")
+	assert.Equal(t, w.Body.String(), "alice")
+}
+
+func TestRespondRoutesErrorsThroughMapper(t *testing.T) {
+	w := respond("application/json", errors.New("boom"))
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/problem+json")
+
+	w = respond("application/json", func() {})
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+}
+
+func TestNoContent(t *testing.T) {
+	w := httptest.NewRecorder()
+	NoContent(w)
+	assert.Equal(t, w.Code, http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package alice
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// ReplayResult is the outcome of replaying a captured exchange.
+type ReplayResult struct {
+	// Exchange is the captured exchange that was replayed.
+	Exchange CapturedExchange
+	// Status, Header and Body are the new response.
+	Status int
+	Header http.Header
+	Body   string
+	// Err is set if the request could not be rebuilt from the exchange,
+	// for instance because of a malformed method or URL.
+	Err error
+}
+
+// Matches reports whether the new response has the captured status
+// and, unless the captured body was truncated, the captured body.
+func (res ReplayResult) Matches() bool {
+	if res.Err != nil || res.Status != res.Exchange.Status {
+		return false
+	}
+	return res.Exchange.ResponseTruncated || res.Body == res.Exchange.ResponseBody
+}
+
+// Replay re-issues the captured requests against h, typically
+// a chain-composed *ContextAdapter, in order and records the responses
+// for regression comparison in tests:
+//
+//	f, _ := os.Open("testdata/checkout.har")
+//	exchanges, _ := alice.ReadHAR(f)
+//	for _, res := range alice.Replay(chain.ThenWithContext(ctx, app), exchanges) {
+//	    if !res.Matches() {
+//	        t.Errorf("%s %s: got %d %q", res.Exchange.Method, res.Exchange.URL, res.Status, res.Body)
+//	    }
+//	}
+//
+// Redacted headers and bodies are replayed as they were captured.
+func Replay(h http.Handler, exchanges []CapturedExchange) []ReplayResult {
+	results := make([]ReplayResult, len(exchanges))
+	for i, ex := range exchanges {
+		r, err := http.NewRequestWithContext(context.Background(), ex.Method, ex.URL, strings.NewReader(ex.RequestBody))
+		if err != nil {
+			results[i] = ReplayResult{Exchange: ex, Err: err}
+			continue
+		}
+		r.RequestURI = r.URL.RequestURI()
+		r.Header = ex.RequestHeader.Clone()
+		if r.Header == nil {
+			r.Header = http.Header{}
+		}
+		if ex.Host != "" {
+			r.Host = ex.Host
+		}
+		r.RemoteAddr = ex.RemoteAddr
+		w := &replayWriter{header: http.Header{}}
+		h.ServeHTTP(w, r)
+		w.WriteHeader(http.StatusOK)
+		results[i] = ReplayResult{
+			Exchange: ex,
+			Status:   w.status,
+			Header:   w.header,
+			Body:     w.body.String(),
+		}
+	}
+	return results
+}
+
+// replayWriter records a replayed response.
+type replayWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *replayWriter) Header() http.Header { return w.header }
+
+func (w *replayWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *replayWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
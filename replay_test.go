@@ -0,0 +1,42 @@
+package alice
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestReplayComparesResponses(t *testing.T) {
+	captured := captureTraffic(t).Exchanges()
+
+	same := New().ThenWithContext(context.Background(), echoBody)
+	for _, res := range Replay(same, captured) {
+		assert.True(t, res.Matches())
+	}
+
+	broken := New().ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("changed"))
+	}))
+	results := Replay(broken, captured)
+	assert.Equal(t, len(results), 2)
+	assert.False(t, results[0].Matches())
+	assert.Equal(t, results[0].Body, "changed")
+}
+
+func TestReplayReportsMalformedExchanges(t *testing.T) {
+	captured := []CapturedExchange{
+		{Method: "BAD METHOD", URL: "/", Status: http.StatusOK},
+		{Method: "GET", URL: "%zz", Status: http.StatusOK},
+		{Method: "GET", URL: "/ok?q=1", Status: http.StatusOK},
+	}
+	results := Replay(New().ThenWithContext(context.Background(), echoBody), captured)
+	if assert.Len(t, results, 3) {
+		assert.Error(t, results[0].Err)
+		assert.Error(t, results[1].Err)
+		assert.False(t, results[1].Matches())
+		assert.NoError(t, results[2].Err)
+		assert.Equal(t, results[2].Status, http.StatusOK)
+	}
+}
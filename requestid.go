@@ -0,0 +1,51 @@
+package alice
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// RequestIDHeader is the header carrying request IDs.
+const RequestIDHeader = "X-Request-Id"
+
+type requestIDKey struct{}
+
+// RequestID returns a Constructor storing the ID of the request
+// in the context (see RequestIDFrom) and echoing it in the response.
+// The ID is taken from the X-Request-Id header, or generated if absent.
+func RequestID() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" || len(id) > 200 {
+				id = randomHex(16)
+			}
+			w.Header().Set(RequestIDHeader, id)
+			next.ServeHTTPContext(context.WithValue(ctx, requestIDKey{}, id), w, r)
+		})
+	}
+}
+
+// RequestIDFrom returns the request ID stored by RequestID, or "".
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// InjectRequestID is a Propagator setting the X-Request-Id header
+// from the request ID in ctx.
+func InjectRequestID(ctx context.Context, h http.Header) {
+	if id := RequestIDFrom(ctx); id != "" {
+		h.Set(RequestIDHeader, id)
+	}
+}
+
+// requestIDOf returns the stored request ID,
+// falling back to the header if RequestID did not run.
+func requestIDOf(ctx context.Context, r *http.Request) string {
+	if id := RequestIDFrom(ctx); id != "" {
+		return id
+	}
+	return r.Header.Get(RequestIDHeader)
+}
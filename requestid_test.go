@@ -0,0 +1,29 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRequestID(t *testing.T) {
+	var id string
+	h := New(RequestID()).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		id = RequestIDFrom(ctx)
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, id, "abc")
+	assert.Equal(t, w.Header().Get("X-Request-Id"), "abc")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, len(id), 32)
+	assert.Equal(t, w.Header().Get("X-Request-Id"), id)
+}
@@ -0,0 +1,156 @@
+package alice
+
+import (
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// A Backoff returns how long to wait before retry number attempt (1-based).
+type Backoff func(attempt int) time.Duration
+
+// ConstantBackoff waits d before every retry.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff waits a random duration up to base*2^(attempt-1),
+// capped at max ("full jitter"), spreading out retries of many clients.
+func ExponentialBackoff(base, max time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := max
+		if attempt < 32 {
+			d = min(base<<(attempt-1), max)
+		}
+		if d <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(d) + 1))
+	}
+}
+
+// RetryPolicy configures Retry.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one.
+	// It defaults to 3.
+	MaxAttempts int
+	// Backoff defaults to ExponentialBackoff(100ms, 5s).
+	Backoff Backoff
+	// Retryable decides whether an attempt should be retried.
+	// It defaults to DefaultRetryable.
+	Retryable func(*http.Response, error) bool
+	// MaxRetryAfter is the longest wait a Retry-After header may ask for;
+	// responses asking for more are returned rather than retried.
+	// It defaults to 30s.
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryable retries transport errors and
+// 429, 502, 503 and 504 responses.
+func DefaultRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Retry returns a TransportConstructor retrying failed outbound requests
+// according to policy. Only idempotent requests (GET, HEAD, OPTIONS,
+// TRACE, PUT, DELETE or carrying an Idempotency-Key header) whose body
+// can be replayed are retried. A Retry-After header lengthens the wait,
+// up to policy.MaxRetryAfter.
+// Waiting stops as soon as the request context is done,
+// returning its error.
+//
+//	transport := alice.NewTransport(alice.Retry(alice.RetryPolicy{
+//	    MaxAttempts: 4,
+//	    Backoff:     alice.ExponentialBackoff(50*time.Millisecond, time.Second),
+//	}))
+func Retry(policy RetryPolicy) TransportConstructor {
+	attempts := policy.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	backoff := policy.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff(100*time.Millisecond, 5*time.Second)
+	}
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	maxRetryAfter := policy.MaxRetryAfter
+	if maxRetryAfter <= 0 {
+		maxRetryAfter = 30 * time.Second
+	}
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !canRetry(req) {
+				return next.RoundTrip(req)
+			}
+			ctx := req.Context()
+			for attempt := 1; ; attempt++ {
+				if attempt > 1 && req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					req = req.Clone(ctx)
+					req.Body = body
+				}
+				resp, err := next.RoundTrip(req)
+				if attempt == attempts || ctx.Err() != nil || !retryable(resp, err) {
+					return resp, err
+				}
+
+				wait := backoff(attempt)
+				if resp != nil {
+					ra := retryAfter(resp)
+					if ra > maxRetryAfter {
+						return resp, err
+					}
+					wait = max(wait, ra)
+					// Drain the body so the connection can be reused.
+					io.CopyN(io.Discard, resp.Body, 64<<10)
+					resp.Body.Close()
+				}
+				t := time.NewTimer(wait)
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					t.Stop()
+					return nil, ctx.Err()
+				}
+			}
+		})
+	}
+}
+
+func canRetry(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "PUT", "DELETE":
+		return true
+	}
+	return !isUnsafe(req.Method) || req.Header.Get("Idempotency-Key") != ""
+}
+
+// retryAfter returns the wait a Retry-After header in seconds asks for.
+func retryAfter(resp *http.Response) time.Duration {
+	secs, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || secs < 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
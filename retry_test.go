@@ -0,0 +1,89 @@
+package alice
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// flakyTransport fails with status until it has been called fails times.
+func flakyTransport(fails int, status int, bodies *[]string) http.RoundTripper {
+	calls := 0
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if req.Body != nil && bodies != nil {
+			b, _ := io.ReadAll(req.Body)
+			*bodies = append(*bodies, string(b))
+		}
+		code := http.StatusOK
+		if calls <= fails {
+			code = status
+		}
+		return &http.Response{StatusCode: code, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+}
+
+func TestRetryRetriesIdempotentRequests(t *testing.T) {
+	var bodies []string
+	rt := NewTransport(Retry(RetryPolicy{Backoff: ConstantBackoff(0)})).Then(flakyTransport(2, http.StatusServiceUnavailable, &bodies))
+
+	req, _ := http.NewRequest("PUT", "http://example.com/", strings.NewReader("data"))
+	resp, err := rt.RoundTrip(req)
+	assert.Nil(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+	assert.Equal(t, bodies, []string{"data", "data", "data"})
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	rt := NewTransport(Retry(RetryPolicy{MaxAttempts: 2, Backoff: ConstantBackoff(0)})).Then(flakyTransport(5, http.StatusBadGateway, nil))
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	resp, _ := rt.RoundTrip(req)
+	assert.Equal(t, resp.StatusCode, http.StatusBadGateway)
+}
+
+func TestRetrySkipsUnsafeRequests(t *testing.T) {
+	rt := NewTransport(Retry(RetryPolicy{Backoff: ConstantBackoff(0)})).Then(flakyTransport(1, http.StatusServiceUnavailable, nil))
+
+	req, _ := http.NewRequest("POST", "http://example.com/", strings.NewReader("data"))
+	resp, _ := rt.RoundTrip(req)
+	assert.Equal(t, resp.StatusCode, http.StatusServiceUnavailable)
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	rt := NewTransport(Retry(RetryPolicy{Backoff: ConstantBackoff(time.Hour)})).Then(flakyTransport(5, http.StatusServiceUnavailable, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com/", nil)
+	_, err := rt.RoundTrip(req)
+	assert.Equal(t, err, context.DeadlineExceeded)
+}
+
+func TestRetryCapsRetryAfter(t *testing.T) {
+	calls := 0
+	rt := NewTransport(Retry(RetryPolicy{Backoff: ConstantBackoff(0), MaxRetryAfter: time.Minute})).Then(RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		header := http.Header{"Retry-After": {"86400"}}
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: header, Body: http.NoBody}, nil
+	}))
+
+	req, _ := http.NewRequest("GET", "http://example.com/", nil)
+	resp, err := rt.RoundTrip(req)
+	assert.Nil(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusServiceUnavailable)
+	assert.Equal(t, calls, 1)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for attempt := 1; attempt < 40; attempt++ {
+		d := b(attempt)
+		assert.True(t, d >= 0 && d <= 50*time.Millisecond)
+	}
+}
@@ -0,0 +1,98 @@
+package alice
+
+import (
+	"log/slog"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// RouteInfo describes the route a request matched.
+type RouteInfo struct {
+	// Name names the route, e.g. the OpenAPI operationId "getPet".
+	Name string
+	// Pattern is the route pattern, e.g. "GET /pets/{id}".
+	Pattern string
+}
+
+type routeKey struct{}
+
+// routeRecord receives the route set further down the chain.
+type routeRecord struct {
+	info RouteInfo
+	set  bool
+}
+
+// WithRoute returns a copy of ctx carrying the route of the request.
+// Routers call it once the route is known; the Route constructor does
+// for chains built per route. Observability middleware agree on the
+// route through RouteFrom and RouteLabel: the request logger (see
+// Logging) gets a "route" attribute, and middleware running before the
+// route was known see it through TrackRoute.
+func WithRoute(ctx context.Context, info RouteInfo) context.Context {
+	if rec, ok := ctx.Value(routeKey{}).(*routeRecord); ok {
+		rec.info, rec.set = info, true
+	}
+	if _, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		ctx = WithLogAttrs(ctx, slog.String("route", routeLabel(info)))
+	}
+	return context.WithValue(ctx, routeKey{}, &routeRecord{info: info, set: true})
+}
+
+// RouteFrom returns the route of the request.
+func RouteFrom(ctx context.Context) (RouteInfo, bool) {
+	rec, ok := ctx.Value(routeKey{}).(*routeRecord)
+	if !ok || !rec.set {
+		return RouteInfo{}, false
+	}
+	return rec.info, true
+}
+
+// RouteLabel returns a low-cardinality label for the route of the
+// request, its name or else its pattern, for metrics and traces.
+// It returns "" if the route is unknown.
+func RouteLabel(ctx context.Context) string {
+	info, _ := RouteFrom(ctx)
+	return routeLabel(info)
+}
+
+func routeLabel(info RouteInfo) string {
+	if info.Name != "" {
+		return info.Name
+	}
+	return info.Pattern
+}
+
+// TrackRoute returns a context in which the route set further down the
+// chain is recorded, for RouteFrom, and a function returning it.
+// Middleware labeling requests after serving them call it before
+// passing the request on:
+//
+//	ctx, route := alice.TrackRoute(ctx)
+//	next.ServeHTTPContext(ctx, w, r)
+//	info, ok := route()
+func TrackRoute(ctx context.Context) (context.Context, func() (RouteInfo, bool)) {
+	rec, ok := ctx.Value(routeKey{}).(*routeRecord)
+	if !ok {
+		rec = &routeRecord{}
+		ctx = context.WithValue(ctx, routeKey{}, rec)
+	}
+	return ctx, func() (RouteInfo, bool) {
+		return rec.info, rec.set
+	}
+}
+
+// Route returns a Constructor setting the route of requests to info,
+// for chains built per route:
+//
+//	mux.Handle("GET /pets/{id}", api.ThenWith(ctx, getPet, alice.Route(alice.RouteInfo{
+//	    Name:    "getPet",
+//	    Pattern: "GET /pets/{id}",
+//	})))
+func Route(info RouteInfo) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTPContext(WithRoute(ctx, info), w, r)
+		})
+	}
+}
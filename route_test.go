@@ -0,0 +1,61 @@
+package alice
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRoute(t *testing.T) {
+	var info RouteInfo
+	var label string
+	var tracked func() (RouteInfo, bool)
+	track := func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			ctx, tracked = TrackRoute(ctx)
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+	h := New(track).ThenWith(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		info, _ = RouteFrom(ctx)
+		label = RouteLabel(ctx)
+	}), Route(RouteInfo{Name: "getPet", Pattern: "GET /pets/{id}"}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/pets/1", nil))
+
+	assert.Equal(t, info, RouteInfo{Name: "getPet", Pattern: "GET /pets/{id}"})
+	assert.Equal(t, label, "getPet")
+	outer, ok := tracked()
+	assert.True(t, ok)
+	assert.Equal(t, outer, info)
+}
+
+func TestRouteUnknown(t *testing.T) {
+	ctx, tracked := TrackRoute(context.Background())
+	_, ok := RouteFrom(ctx)
+	assert.False(t, ok)
+	_, ok = tracked()
+	assert.False(t, ok)
+	assert.Equal(t, RouteLabel(ctx), "")
+	assert.Equal(t, RouteLabel(WithRoute(ctx, RouteInfo{Pattern: "/pets"})), "/pets")
+}
+
+func TestRouteEnrichesLogger(t *testing.T) {
+	var buf strings.Builder
+	base := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}}))
+	h := New(Logging(base), Route(RouteInfo{Pattern: "GET /pets/{id}"})).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		LoggerFrom(ctx).Info("found")
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/pets/1", nil))
+
+	assert.Contains(t, buf.String(), `route="GET /pets/{id}"`)
+}
@@ -0,0 +1,65 @@
+// Package rpc terminates alice chains with calls on RPC-style backends,
+// gRPC-gateway style: REST+JSON requests are translated into request
+// messages, and the response messages and errors of the backend into
+// responses, so the same middleware serves in front of HTTP handlers
+// and RPC services alike.
+//
+//	type Users interface {
+//	    GetUser(ctx context.Context, req *GetUserRequest) (*User, error)
+//	    CreateUser(ctx context.Context, req *CreateUserRequest) (*User, error)
+//	}
+//
+//	mux.Handle("GET /users/{id}", chain.ThenWithContext(ctx, rpc.Method(users.GetUser)))
+//	mux.Handle("POST /users", chain.ThenWithContext(ctx, rpc.MethodWith(users.CreateUser, rpc.Options{Status: http.StatusCreated})))
+package rpc
+
+import (
+	"net/http"
+
+	"github.com/SimiPro/alice"
+	"github.com/SimiPro/alice/render"
+	"golang.org/x/net/context"
+)
+
+// Options configure MethodWith.
+type Options struct {
+	// Status is the status of successful responses.
+	// It defaults to 200 OK.
+	Status int
+}
+
+// Method returns a ContextHandler calling fn with the chain's context.
+// The request message is bound from the request with alice.BindRequest:
+// the body if any, the path wildcards of the route and the query
+// parameters. The response message is written with render.Respond, in
+// the type negotiated by alice.Negotiate, and a nil one as 204 No Content.
+// Binding and backend errors are reported (see alice.ReportError) and
+// written with alice.WriteError, so the chain's ErrorMapper (see
+// alice.MapErrors) turns backend errors into statuses.
+func Method[Req, Resp any](fn func(context.Context, *Req) (*Resp, error)) alice.ContextHandler {
+	return MethodWith(fn, Options{})
+}
+
+// MethodWith works like Method, with options.
+func MethodWith[Req, Resp any](fn func(context.Context, *Req) (*Resp, error), opts Options) alice.ContextHandler {
+	status := opts.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return alice.ErrorHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+		req, err := alice.BindRequest[Req](ctx, r)
+		if err != nil {
+			return err
+		}
+		resp, err := fn(ctx, &req)
+		if err != nil {
+			return err
+		}
+		if resp == nil {
+			render.NoContent(w)
+			return nil
+		}
+		render.Respond(ctx, w, status, resp)
+		return nil
+	})
+}
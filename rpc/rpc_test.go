@@ -0,0 +1,105 @@
+package rpc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type getUser struct {
+	ID    int64 `json:"id" validate:"required"`
+	Admin bool  `json:"admin"`
+}
+
+type createUser struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type user struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+var errNotFound = errors.New("not found")
+
+type users struct{}
+
+func (users) GetUser(ctx context.Context, req *getUser) (*user, error) {
+	if req.ID != 42 {
+		return nil, errNotFound
+	}
+	return &user{ID: req.ID, Name: ctx.Value("tenant").(string)}, nil
+}
+
+func (users) CreateUser(ctx context.Context, req *createUser) (*user, error) {
+	return &user{ID: 7, Name: req.Name}, nil
+}
+
+func (users) DeleteUser(ctx context.Context, req *getUser) (*user, error) {
+	return nil, nil
+}
+
+func serve(r *http.Request) *httptest.ResponseRecorder {
+	var svc users
+	ctx := context.WithValue(context.Background(), "tenant", "acme")
+	chain := alice.New(alice.MapErrors(func(err error) *alice.HTTPError {
+		if errors.Is(err, errNotFound) {
+			return &alice.HTTPError{Status: http.StatusNotFound, Code: "user_not_found"}
+		}
+		return alice.DefaultErrorMapper(err)
+	}))
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", chain.ThenWithContext(ctx, Method(svc.GetUser)))
+	mux.Handle("POST /users", chain.ThenWithContext(ctx, MethodWith(svc.CreateUser, Options{Status: http.StatusCreated})))
+	mux.Handle("DELETE /users/{id}", chain.ThenWithContext(ctx, Method(svc.DeleteUser)))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	return w
+}
+
+func TestMethodBindsPathAndResponds(t *testing.T) {
+	w := serve(httptest.NewRequest("GET", "/users/42", nil))
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Equal(t, w.Body.String(), `{"id":42,"name":"acme"}`+"\n")
+}
+
+func TestMethodBindsBody(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"bob"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := serve(r)
+
+	assert.Equal(t, w.Code, http.StatusCreated)
+	assert.Equal(t, w.Body.String(), `{"id":7,"name":"bob"}`+"\n")
+}
+
+func TestMethodNoContent(t *testing.T) {
+	w := serve(httptest.NewRequest("DELETE", "/users/42", nil))
+
+	assert.Equal(t, w.Code, http.StatusNoContent)
+	assert.Equal(t, w.Body.String(), "")
+}
+
+func TestMethodErrors(t *testing.T) {
+	w := serve(httptest.NewRequest("GET", "/users/1", nil))
+	assert.Equal(t, w.Code, http.StatusNotFound)
+	assert.Contains(t, w.Body.String(), `"code":"user_not_found"`)
+
+	w = serve(httptest.NewRequest("GET", "/users/abc", nil))
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_parameter"`)
+
+	r := httptest.NewRequest("POST", "/users", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/json")
+	w = serve(r)
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+	assert.Contains(t, w.Body.String(), `"name":"is required"`)
+}
@@ -0,0 +1,93 @@
+package alice
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// Scope holds request-scoped services keyed by type,
+// replacing ad-hoc context keys for things like database transactions
+// or repositories bound to the current user.
+// Middleware register services with Provide, handlers look them up with Resolve:
+//
+//	func withTx(next alice.ContextHandler) alice.ContextHandler {
+//	    return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+//	        tx, _ := db.BeginTx(ctx, nil)
+//	        defer tx.Rollback()
+//	        alice.Provide(ctx, tx)
+//	        next.ServeHTTPContext(ctx, w, r)
+//	    })
+//	}
+//
+//	tx := alice.MustResolve[*sql.Tx](ctx)
+type Scope struct {
+	mu       sync.RWMutex
+	services map[reflect.Type]any
+}
+
+type scopeKey struct{}
+
+// Scoped returns a Constructor giving every request a fresh Scope.
+func Scoped() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTPContext(WithScope(ctx), w, r)
+		})
+	}
+}
+
+// WithScope returns a context carrying a new, empty Scope.
+func WithScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &Scope{services: make(map[reflect.Type]any)})
+}
+
+// ScopeFrom returns the Scope of ctx, or nil if there is none.
+func ScopeFrom(ctx context.Context) *Scope {
+	s, _ := ctx.Value(scopeKey{}).(*Scope)
+	return s
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Provide registers v as the service of type T in the Scope of ctx,
+// replacing any previous one. T may be an interface type.
+// It panics if ctx has no Scope, which means Scoped is missing from the chain.
+func Provide[T any](ctx context.Context, v T) {
+	s := ScopeFrom(ctx)
+	if s == nil {
+		panic("alice: Provide called without a Scope; add alice.Scoped() to the chain")
+	}
+	s.mu.Lock()
+	s.services[typeOf[T]()] = v
+	s.mu.Unlock()
+}
+
+// Resolve returns the service of type T registered in the Scope of ctx.
+func Resolve[T any](ctx context.Context) (T, bool) {
+	var zero T
+	s := ScopeFrom(ctx)
+	if s == nil {
+		return zero, false
+	}
+	s.mu.RLock()
+	v, ok := s.services[typeOf[T]()]
+	s.mu.RUnlock()
+	if !ok {
+		return zero, false
+	}
+	return v.(T), true
+}
+
+// MustResolve is like Resolve, but panics if no service of type T is registered.
+func MustResolve[T any](ctx context.Context) T {
+	v, ok := Resolve[T](ctx)
+	if !ok {
+		panic("alice: no " + typeOf[T]().String() + " registered in the request Scope")
+	}
+	return v
+}
@@ -0,0 +1,50 @@
+package alice
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type userRepo struct {
+	user string
+}
+
+func TestScopeResolvesProvidedServices(t *testing.T) {
+	provide := func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			Provide(ctx, &userRepo{user: "alice"})
+			Provide[fmt.Stringer](ctx, time.Second)
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+	var repo *userRepo
+	app := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		repo = MustResolve[*userRepo](ctx)
+		_, ok := Resolve[fmt.Stringer](ctx)
+		assert.True(t, ok)
+		_, ok = Resolve[string](ctx)
+		assert.False(t, ok)
+	})
+
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	New(Scoped(), provide).ThenWithContext(context.Background(), app).ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, repo.user, "alice")
+}
+
+func TestProvideWithoutScopePanics(t *testing.T) {
+	assert.Panics(t, func() {
+		Provide(context.Background(), 1)
+	})
+	_, ok := Resolve[int](context.Background())
+	assert.False(t, ok)
+}
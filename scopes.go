@@ -0,0 +1,86 @@
+package alice
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// Principal is the authenticated caller of a request,
+// stored in the context by authentication middleware.
+type Principal struct {
+	// Subject identifies the caller, e.g. a user or client ID.
+	Subject string
+	// Scopes are the scopes granted to the caller, e.g. "pets:write".
+	Scopes []string
+	// Roles are the roles of the caller, e.g. "admin".
+	Roles []string
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+// Authentication middleware calls it once the caller is known.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal stored with WithPrincipal.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// RequireScopes returns a Constructor letting through only requests
+// whose principal (see PrincipalFrom) was granted all of scopes.
+// Requests without a principal get 401 Unauthorized; those missing
+// scopes get 403 Forbidden with the code "insufficient_scope".
+// It is meant to be appended per route after authentication:
+//
+//	api := alice.New(auth)
+//	mux.Handle("GET /pets", api.Append(alice.RequireScopes("pets:read")).ThenWithContext(ctx, listPets))
+//	mux.Handle("POST /pets", api.Append(alice.RequireScopes("pets:write")).ThenWithContext(ctx, createPet))
+func RequireScopes(scopes ...string) Constructor {
+	return nameConstructor(requireGrants("insufficient_scope", "missing scopes: ", scopes, func(p Principal) []string {
+		return p.Scopes
+	}), funcName(RequireScopes))
+}
+
+// RequireRoles is like RequireScopes, for the roles of the principal.
+// Errors have the code "insufficient_role".
+func RequireRoles(roles ...string) Constructor {
+	return nameConstructor(requireGrants("insufficient_role", "missing roles: ", roles, func(p Principal) []string {
+		return p.Roles
+	}), funcName(RequireRoles))
+}
+
+func requireGrants(code, detail string, required []string, granted func(Principal) []string) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			p, ok := PrincipalFrom(ctx)
+			if !ok {
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusUnauthorized,
+					Code:   "unauthenticated",
+				})
+				return
+			}
+			var missing []string
+			for _, s := range required {
+				if !contains(granted(p), s) {
+					missing = append(missing, s)
+				}
+			}
+			if len(missing) > 0 {
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusForbidden,
+					Code:   code,
+					Detail: detail + strings.Join(missing, ", "),
+				})
+				return
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+}
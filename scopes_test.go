@@ -0,0 +1,59 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestRequireScopes(t *testing.T) {
+	authenticate := func(p *Principal) Constructor {
+		return func(next ContextHandler) ContextHandler {
+			return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+				if p != nil {
+					ctx = WithPrincipal(ctx, *p)
+				}
+				next.ServeHTTPContext(ctx, w, r)
+			})
+		}
+	}
+	tests := []struct {
+		principal *Principal
+		status    int
+		body      string
+	}{
+		{nil, http.StatusUnauthorized, `"code":"unauthenticated"`},
+		{&Principal{Subject: "bob", Scopes: []string{"pets:read"}}, http.StatusForbidden, `"detail":"missing scopes: pets:write"`},
+		{&Principal{Subject: "ann", Scopes: []string{"pets:read", "pets:write"}}, http.StatusOK, "app\n"},
+	}
+	for _, tt := range tests {
+		h := New(authenticate(tt.principal), RequireScopes("pets:read", "pets:write")).ThenWithContext(context.Background(), testApp)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/pets", nil))
+
+		assert.Equal(t, w.Code, tt.status)
+		assert.Contains(t, w.Body.String(), tt.body)
+	}
+}
+
+func TestRequireRoles(t *testing.T) {
+	ctx := WithPrincipal(context.Background(), Principal{Subject: "ann", Roles: []string{"admin"}})
+	for role, status := range map[string]int{"admin": http.StatusOK, "owner": http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		New(RequireRoles(role)).ThenWithContext(ctx, testApp).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, w.Code, status)
+	}
+}
+
+func TestPrincipalFrom(t *testing.T) {
+	_, ok := PrincipalFrom(context.Background())
+	assert.False(t, ok)
+
+	p, ok := PrincipalFrom(WithPrincipal(context.Background(), Principal{Subject: "ann"}))
+	assert.True(t, ok)
+	assert.Equal(t, p.Subject, "ann")
+}
@@ -0,0 +1,297 @@
+package alice
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// AltServer is an additional protocol server run next to the TCP listener,
+// such as an HTTP/3 (QUIC) server.
+type AltServer interface {
+	// ListenAndServe serves h until Shutdown is called.
+	ListenAndServe(h http.Handler) error
+	// SetAltSvc advertises the server on the given response headers.
+	SetAltSvc(hdr http.Header) error
+	// Shutdown gracefully stops the server.
+	Shutdown(ctx context.Context) error
+}
+
+// Server serves a chain-composed handler until its context is done,
+// then shuts down gracefully.
+//
+//	srv := &alice.Server{
+//	    Addr:    ":8443",
+//	    Handler: alice.New(m1, m2).ThenWithContext(ctx, h),
+//	}
+//	err := srv.ListenAndServe(ctx)
+type Server struct {
+	// Addr is the TCP address to listen on.
+	// If empty, ":http" or ":https" is used.
+	Addr string
+	// Listener, if set, is used instead of listening on Addr.
+	// See ListenUnix and SystemdListeners.
+	Listener net.Listener
+	// Handler is the handler to serve, typically a *ContextAdapter.
+	Handler http.Handler
+
+	// CertFile and KeyFile, if set, make the server use TLS.
+	CertFile, KeyFile string
+	TLSConfig         *tls.Config
+
+	// Bindings are additional listeners served alongside Addr.
+	// All of them are shut down together.
+	Bindings []Binding
+
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout
+	// configure the http.Server of every binding. Chains needing other
+	// read or write timeouts, such as uploads or streams, set them
+	// with IOTimeouts.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// ShutdownTimeout bounds the graceful shutdown.
+	// Zero means waiting for all connections to finish.
+	ShutdownTimeout time.Duration
+
+	// HTTP3, if set, additionally serves Handler over HTTP/3.
+	// Responses served over TCP advertise it through the Alt-Svc header.
+	HTTP3 AltServer
+
+	// Proxy, if set, is the ProxyConfig of all handlers,
+	// unless a chain sets its own with WithProxyConfig.
+	Proxy *ProxyConfig
+
+	// Tasks, if set, is shut down after the listeners, so background
+	// work started by handlers can finish within ShutdownTimeout.
+	Tasks *Tasks
+}
+
+// A Binding is an additional listener of a Server.
+//
+//	srv := &alice.Server{
+//	    Addr:     ":443",
+//	    Handler:  chain.ThenWithContext(ctx, h),
+//	    CertFile: "cert.pem",
+//	    KeyFile:  "key.pem",
+//	    Bindings: []alice.Binding{
+//	        {Addr: ":80", Handler: alice.HTTPSRedirect("")},
+//	    },
+//	}
+type Binding struct {
+	// Addr and Listener work like their Server counterparts.
+	Addr     string
+	Listener net.Listener
+	// Handler, if set, is served instead of the Server's Handler.
+	Handler http.Handler
+
+	// CertFile and KeyFile, if set, make the binding use TLS.
+	CertFile, KeyFile string
+	TLSConfig         *tls.Config
+}
+
+// ListenAndServe starts the handlers (see Starter), listens on all
+// configured addresses and serves until ctx is done or a listener
+// fails, after which every listener is shut down.
+// It returns nil after a graceful shutdown caused by ctx.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	bindings := append([]Binding{{
+		Addr:      s.Addr,
+		Listener:  s.Listener,
+		CertFile:  s.CertFile,
+		KeyFile:   s.KeyFile,
+		TLSConfig: s.TLSConfig,
+	}}, s.Bindings...)
+
+	if err := s.start(ctx); err != nil {
+		return err
+	}
+	errs := make(chan error, len(bindings)+1)
+	servers := make([]*http.Server, len(bindings))
+	for i, b := range bindings {
+		h := b.Handler
+		if h == nil {
+			h = s.Handler
+		}
+		hs := &http.Server{
+			Addr:              b.Addr,
+			Handler:           s.advertise(s.withProxy(h)),
+			TLSConfig:         b.TLSConfig,
+			ReadHeaderTimeout: s.ReadHeaderTimeout,
+			ReadTimeout:       s.ReadTimeout,
+			WriteTimeout:      s.WriteTimeout,
+			IdleTimeout:       s.IdleTimeout,
+		}
+		servers[i] = hs
+		go func(b Binding) {
+			errs <- b.serve(hs)
+		}(b)
+	}
+	if s.HTTP3 != nil {
+		go func() {
+			errs <- s.HTTP3.ListenAndServe(s.withProxy(s.Handler))
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+	if serr := s.shutdown(servers); err == nil || err == http.ErrServerClosed {
+		err = serr
+	}
+	return err
+}
+
+// shutdown gracefully stops all servers in parallel, then waits
+// for background tasks and stops the handlers (see Stopper),
+// all sharing one ShutdownTimeout.
+func (s *Server) shutdown(servers []*http.Server) error {
+	ctx := context.Background()
+	if s.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.ShutdownTimeout)
+		defer cancel()
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(servers)+3)
+	for _, hs := range servers {
+		wg.Add(1)
+		go func(hs *http.Server) {
+			defer wg.Done()
+			errs <- hs.Shutdown(ctx)
+		}(hs)
+	}
+	if s.HTTP3 != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.HTTP3.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+	if s.Tasks != nil {
+		errs <- s.Tasks.Shutdown(ctx)
+	}
+	errs <- s.stop(ctx, s.lifecycleHandlers())
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serve runs hs on the binding's listener.
+func (b Binding) serve(hs *http.Server) error {
+	useTLS := b.CertFile != "" || b.TLSConfig != nil
+	switch {
+	case b.Listener != nil && useTLS:
+		return hs.ServeTLS(b.Listener, b.CertFile, b.KeyFile)
+	case b.Listener != nil:
+		return hs.Serve(b.Listener)
+	case useTLS:
+		return hs.ListenAndServeTLS(b.CertFile, b.KeyFile)
+	default:
+		return hs.ListenAndServe()
+	}
+}
+
+// advertise wraps a handler served over TCP
+// to advertise HTTP/3 when it is enabled.
+func (s *Server) advertise(h http.Handler) http.Handler {
+	if s.HTTP3 == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.HTTP3.SetAltSvc(w.Header())
+		h.ServeHTTP(w, r)
+	})
+}
+
+// withProxy stores the server's ProxyConfig in the request context.
+func (s *Server) withProxy(h http.Handler) http.Handler {
+	if s.Proxy == nil {
+		return h
+	}
+	cfg := *s.Proxy
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), proxyConfigKey{}, cfg)))
+	})
+}
+
+// HTTPSRedirect returns a handler that permanently redirects requests
+// to the same URL over HTTPS on the given port ("" for the default port).
+func HTTPSRedirect(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
+
+// ListenUnix listens on the unix domain socket at path,
+// removing a stale socket file left behind by a previous run.
+func ListenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// The first file descriptor passed by systemd socket activation.
+const systemdFirstFD = 3
+
+// SystemdListeners returns the listeners passed by systemd socket activation
+// (LISTEN_PID and LISTEN_FDS) in the order they were configured.
+// It returns no listeners if the process was not socket activated.
+// The activation variables are unset so child processes do not inherit them.
+func SystemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 0 {
+		return nil, errors.New("alice: invalid LISTEN_FDS")
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, n)
+	for fd := systemdFirstFD; fd < systemdFirstFD+n; fd++ {
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("alice: systemd fd %d: %v", fd, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
@@ -0,0 +1,177 @@
+package alice
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// An AltServer that only advertises itself.
+type fakeAltServer struct {
+	done chan struct{}
+}
+
+func newFakeAltServer() *fakeAltServer {
+	return &fakeAltServer{done: make(chan struct{})}
+}
+
+func (f *fakeAltServer) ListenAndServe(h http.Handler) error {
+	<-f.done
+	return nil
+}
+
+func (f *fakeAltServer) SetAltSvc(hdr http.Header) error {
+	hdr.Set("Alt-Svc", `h3=":443"; ma=2592000`)
+	return nil
+}
+
+func (f *fakeAltServer) Shutdown(ctx context.Context) error {
+	close(f.done)
+	return nil
+}
+
+func TestServerShutsDownWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &Server{
+		Addr:    "127.0.0.1:0",
+		Handler: http.NotFoundHandler(),
+		HTTP3:   newFakeAltServer(),
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- srv.ListenAndServe(ctx)
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
+
+func TestServerAdvertisesHTTP3(t *testing.T) {
+	srv := &Server{
+		Handler: http.NotFoundHandler(),
+		HTTP3:   newFakeAltServer(),
+	}
+
+	w := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.advertise(srv.Handler).ServeHTTP(w, r)
+
+	assert.Equal(t, w.Header().Get("Alt-Svc"), `h3=":443"; ma=2592000`)
+}
+
+func TestServerServesOnUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alice.sock")
+	ln, err := ListenUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := &Server{
+		Listener: ln,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("app\n"))
+		}),
+	}
+	go srv.ListenAndServe(ctx)
+
+	client := &http.Client{Transport: &http.Transport{
+		Dial: func(network, addr string) (net.Conn, error) {
+			return net.Dial("unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusOK)
+}
+
+func TestListenUnixRemovesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alice.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Leave the socket file behind, as a crashed process would.
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+
+	ln, err = ListenUnix(path)
+	assert.Nil(t, err)
+	ln.Close()
+}
+
+func TestSystemdListenersWithoutActivation(t *testing.T) {
+	os.Setenv("LISTEN_PID", "1")
+	os.Setenv("LISTEN_FDS", "1")
+	defer os.Unsetenv("LISTEN_PID")
+	defer os.Unsetenv("LISTEN_FDS")
+
+	listeners, err := SystemdListeners()
+	assert.Nil(t, err)
+	assert.Equal(t, len(listeners), 0)
+}
+
+func TestServerServesAllBindings(t *testing.T) {
+	main, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	redirect, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &Server{
+		Listener: main,
+		Handler:  http.NotFoundHandler(),
+		Bindings: []Binding{
+			{Listener: redirect, Handler: HTTPSRedirect("8443")},
+		},
+	}
+	done := make(chan error)
+	go func() {
+		done <- srv.ListenAndServe(ctx)
+	}()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get("http://" + main.Addr().String() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusNotFound)
+
+	resp, err = client.Get("http://" + redirect.Addr().String() + "/a?b=c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusMovedPermanently)
+	assert.Equal(t, resp.Header.Get("Location"), "https://127.0.0.1:8443/a?b=c")
+
+	cancel()
+	assert.Nil(t, <-done)
+}
@@ -0,0 +1,38 @@
+package alice
+
+// Subtract returns a new chain of the constructors of c not in other,
+// in c's order. It derives stacks from related ones:
+//
+//	public := api.Subtract(alice.New(auth, alice.RequireScopes("admin")))
+//
+// Constructors are identified by their name on the debug endpoint,
+// that of the function that built them: the constructor returned by
+// alice.RequestID() matches any other returned by alice.RequestID(),
+// whatever its options, but not one returned by another factory, even
+// when both share a helper, as RequireScopes and RequireRoles do.
+// Constructors wrapped by Skippable, Builder.UseWhen or Chain.Debug
+// are identified as the constructor they wrap.
+func (c Chain) Subtract(other Chain) Chain {
+	return c.filter(other, false)
+}
+
+// Intersect returns a new chain of the constructors of c also in other,
+// in c's order, such as the middleware two stacks share.
+// Constructors are identified as by Subtract.
+func (c Chain) Intersect(other Chain) Chain {
+	return c.filter(other, true)
+}
+
+func (c Chain) filter(other Chain, keep bool) Chain {
+	names := make(map[string]bool, len(other.constructors))
+	for _, name := range other.names() {
+		names[name] = true
+	}
+	var cons []Constructor
+	for _, con := range c.constructors {
+		if names[constructorName(con)] == keep {
+			cons = append(cons, con)
+		}
+	}
+	return Chain{constructors: cons}
+}
@@ -0,0 +1,42 @@
+package alice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func logIn(h ContextHandler) ContextHandler { return h }
+
+func TestSubtract(t *testing.T) {
+	base := New(passThrough, RequestID(), logIn, denyAll)
+	chain := base.Subtract(New(denyAll, logIn))
+
+	assert.Equal(t, chain.names(), New(passThrough, RequestID()).names())
+	assert.Equal(t, len(base.constructors), 4)
+	assert.Equal(t, len(base.Subtract(New()).constructors), 4)
+}
+
+func TestSubtractMatchesByFactory(t *testing.T) {
+	chain := New(RequestID(), passThrough).Subtract(New(RequestID()))
+
+	assert.Equal(t, chain.names(), New(passThrough).names())
+}
+
+func TestSubtractTellsSharedHelpersApart(t *testing.T) {
+	chain := New(RequireScopes("admin"), RequireRoles("admin")).Subtract(New(RequireScopes("read")))
+	assert.Equal(t, chain.names(), []string{"github.com/SimiPro/alice.RequireRoles"})
+
+	chain = New(Skippable("deny", denyAll), Skippable("log", logIn)).Subtract(New(logIn))
+	assert.Equal(t, chain.names(), New(denyAll).names())
+
+	chain = New(denyAll, logIn).Debug(nil).Intersect(New(logIn))
+	assert.Equal(t, chain.names(), New(logIn).names())
+}
+
+func TestIntersect(t *testing.T) {
+	chain := New(passThrough, RequestID(), logIn).Intersect(New(logIn, denyAll, passThrough))
+
+	assert.Equal(t, chain.names(), New(passThrough, logIn).names())
+	assert.Equal(t, len(New(logIn).Intersect(New()).constructors), 0)
+}
@@ -0,0 +1,83 @@
+package alice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// MaxShadowBodySize is the largest request body Shadow copies.
+// Requests with larger bodies are not shadowed.
+const MaxShadowBodySize = 1 << 20
+
+// Shadow returns a Constructor replaying a copy of the requests sampler
+// selects (all of them if sampler is nil) to target in the background,
+// for testing a new implementation against production traffic.
+//
+// The request is served by the rest of the chain first, unaffected;
+// its body is copied as it is read. The copy is then sent to target with
+// a context keeping the request's values but not its cancellation,
+// and target's response is discarded. Panics in target are logged.
+func Shadow(target ContextHandler, sampler func(*http.Request) bool) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if sampler != nil && !sampler(r) {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+
+			var body bytes.Buffer
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = &teeBody{ReadCloser: r.Body, copy: &body}
+			}
+			next.ServeHTTPContext(ctx, w, r)
+
+			if r.Body != nil && r.Body != http.NoBody {
+				// Read what the handler left, so the shadow gets the whole body.
+				io.CopyN(io.Discard, r.Body, MaxShadowBodySize+1-int64(body.Len()))
+				if body.Len() > MaxShadowBodySize {
+					return
+				}
+			}
+
+			shadowCtx := Detach(ctx)
+			shadowReq := r.Clone(shadowCtx)
+			shadowReq.Body = io.NopCloser(bytes.NewReader(body.Bytes()))
+			go func() {
+				defer func() {
+					if err := recover(); err != nil {
+						logger().Error("alice: shadow handler panicked", "error", fmt.Sprint(err), "path", r.URL.Path)
+					}
+				}()
+				target.ServeHTTPContext(shadowCtx, &discardWriter{header: make(http.Header)}, shadowReq)
+			}()
+		})
+	}
+}
+
+// teeBody copies what is read from a request body, up to one byte
+// past MaxShadowBodySize so oversized bodies can be detected.
+type teeBody struct {
+	io.ReadCloser
+	copy *bytes.Buffer
+}
+
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	if room := MaxShadowBodySize + 1 - t.copy.Len(); room > 0 {
+		t.copy.Write(p[:min(n, room)])
+	}
+	return n, err
+}
+
+// discardWriter is a ResponseWriter throwing the response away.
+type discardWriter struct {
+	header http.Header
+}
+
+func (d *discardWriter) Header() http.Header         { return d.header }
+func (d *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (d *discardWriter) WriteHeader(int)             {}
@@ -0,0 +1,63 @@
+package alice
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestShadowReplaysRequests(t *testing.T) {
+	type shadowed struct {
+		body, value string
+		err         error
+	}
+	got := make(chan shadowed, 1)
+	target := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- shadowed{string(body), ctx.Value("key").(string), ctx.Err()}
+		w.Write([]byte("ignored"))
+	})
+	app := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		// Only read part of the body.
+		buf := make([]byte, 5)
+		io.ReadFull(r.Body, buf)
+		w.Write(buf)
+	})
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "key", "value"))
+	w := httptest.NewRecorder()
+	Shadow(target, nil)(app).ServeHTTPContext(ctx, w, httptest.NewRequest("POST", "/", strings.NewReader("hello world")))
+	cancel()
+
+	assert.Equal(t, w.Body.String(), "hello")
+	select {
+	case s := <-got:
+		assert.Equal(t, s.body, "hello world")
+		assert.Equal(t, s.value, "value")
+		assert.Nil(t, s.err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not shadowed")
+	}
+}
+
+func TestShadowSampler(t *testing.T) {
+	shadowed := make(chan struct{}, 1)
+	target := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		shadowed <- struct{}{}
+	})
+	never := func(*http.Request) bool { return false }
+
+	Shadow(target, never)(writeTag("app")).ServeHTTPContext(context.Background(), httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	select {
+	case <-shadowed:
+		t.Fatal("request was shadowed")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -0,0 +1,50 @@
+package alice
+
+import (
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+type skipKey struct{}
+
+// SkipMiddleware returns a copy of ctx telling the middleware
+// registered with Skippable under the given names to let the request
+// through untouched. Earlier middleware uses it to turn off expensive
+// later middleware for some requests:
+//
+//	func healthChecks(next alice.ContextHandler) alice.ContextHandler {
+//	    return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+//	        if r.URL.Path == "/healthz" {
+//	            ctx = alice.SkipMiddleware(ctx, "auth", "metrics")
+//	        }
+//	        next.ServeHTTPContext(ctx, w, r)
+//	    })
+//	}
+//
+//	chain := alice.New(healthChecks, alice.Skippable("auth", auth), alice.Skippable("metrics", metrics))
+func SkipMiddleware(ctx context.Context, names ...string) context.Context {
+	prev, _ := ctx.Value(skipKey{}).(map[string]bool)
+	skip := make(map[string]bool, len(prev)+len(names))
+	for name := range prev {
+		skip[name] = true
+	}
+	for _, name := range names {
+		skip[name] = true
+	}
+	return context.WithValue(ctx, skipKey{}, skip)
+}
+
+// Skipped reports whether SkipMiddleware was called for name.
+func Skipped(ctx context.Context, name string) bool {
+	skip, _ := ctx.Value(skipKey{}).(map[string]bool)
+	return skip[name]
+}
+
+// Skippable returns cons under a name requests can skip it by,
+// see SkipMiddleware.
+func Skippable(name string, cons Constructor) Constructor {
+	return when(func(ctx context.Context, r *http.Request) bool {
+		return !Skipped(ctx, name)
+	}, cons)
+}
@@ -0,0 +1,37 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestSkipMiddleware(t *testing.T) {
+	health := func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/healthz" {
+				ctx = SkipMiddleware(ctx, "auth")
+				ctx = SkipMiddleware(ctx, "metrics")
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+	h := New(
+		health,
+		Skippable("auth", tagMiddleware("auth\n")),
+		Skippable("metrics", tagMiddleware("metrics\n")),
+		Skippable("other", tagMiddleware("other\n")),
+	).ThenWithContext(context.Background(), testApp)
+
+	for path, want := range map[string]string{
+		"/":        "auth\nmetrics\nother\napp\n",
+		"/healthz": "other\napp\n",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, w.Body.String(), want)
+	}
+}
@@ -0,0 +1,178 @@
+// Package sse serves server-sent events (text/event-stream)
+// at the end of a chain.
+//
+//	h := chain.ThenWithContext(ctx, sse.EventStream(sse.Options{Heartbeat: 15 * time.Second},
+//	    func(ctx context.Context, s *sse.Stream) error {
+//	        for msg := range subscribe(ctx, s.LastEventID()) {
+//	            if err := s.Send(sse.Event{ID: msg.ID, Data: msg.Text}); err != nil {
+//	                return err
+//	            }
+//	        }
+//	        return nil
+//	    }))
+package sse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// Event is a server-sent event.
+type Event struct {
+	// ID, if set, is sent back by reconnecting clients
+	// in the Last-Event-ID header; see Stream.LastEventID.
+	ID string
+	// Event is the event type, "message" if empty.
+	Event string
+	// Data is the payload. Multi-line data is sent as several data lines.
+	Data string
+	// Retry, if set, changes the client's reconnection delay.
+	Retry time.Duration
+}
+
+// Options configure EventStream.
+type Options struct {
+	// Heartbeat is the interval of the comments sent to keep idle
+	// connections from being closed by proxies. Zero disables them.
+	Heartbeat time.Duration
+	// Retry, if set, is sent as the client's reconnection delay
+	// when the stream starts.
+	Retry time.Duration
+}
+
+// Producer produces the events of a stream until ctx is done,
+// which happens when the client goes away.
+type Producer func(ctx context.Context, s *Stream) error
+
+// ErrClosed is returned by Stream.Send once the stream has ended.
+var ErrClosed = errors.New("sse: stream closed")
+
+// Stream writes events to a client. It is safe for concurrent use.
+type Stream struct {
+	mu          sync.Mutex
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	lastEventID string
+	closed      bool
+}
+
+// LastEventID returns the ID of the last event a reconnecting
+// client received, so the producer can resume after it.
+func (s *Stream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Send writes ev and flushes it to the client.
+func (s *Stream) Send(ev Event) error {
+	var b strings.Builder
+	if ev.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", oneLine(ev.ID))
+	}
+	if ev.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", oneLine(ev.Event))
+	}
+	if ev.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", ev.Retry.Milliseconds())
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(ev.Data, "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+// Comment writes a comment, which clients ignore.
+func (s *Stream) Comment(text string) error {
+	return s.write(": " + oneLine(text) + "\n\n")
+}
+
+func (s *Stream) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	if _, err := s.w.Write([]byte(msg)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func (s *Stream) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+}
+
+// oneLine keeps field values from breaking the framing.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// EventStream returns a handler streaming the events of produce.
+// The producer's context is canceled when the client disconnects,
+// and the stream is closed when the producer returns; as the response
+// has started by then, a returned error only ends the stream.
+// Writers that cannot flush get 500 Internal Server Error.
+// The write deadline of the server (see alice.Server.WriteTimeout)
+// is lifted for the stream.
+func EventStream(opts Options, produce Producer) alice.ContextHandler {
+	return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+		if err := rc.Flush(); err != nil {
+			h.Del("Content-Type")
+			alice.WriteError(ctx, w, err)
+			return
+		}
+
+		// Streams outlive the server's WriteTimeout.
+		rc.SetWriteDeadline(time.Time{})
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-r.Context().Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		s := &Stream{w: w, rc: rc, lastEventID: r.Header.Get("Last-Event-ID")}
+		defer s.close()
+		if opts.Retry > 0 {
+			s.write("retry: " + strconv.FormatInt(opts.Retry.Milliseconds(), 10) + "\n\n")
+		}
+		if opts.Heartbeat > 0 {
+			go heartbeat(ctx, s, opts.Heartbeat)
+		}
+		produce(ctx, s)
+	})
+}
+
+func heartbeat(ctx context.Context, s *Stream, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if s.Comment("heartbeat") != nil {
+				return
+			}
+		}
+	}
+}
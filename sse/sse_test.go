@@ -0,0 +1,80 @@
+package sse
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestEventStream(t *testing.T) {
+	h := EventStream(Options{Retry: 3 * time.Second}, func(ctx context.Context, s *Stream) error {
+		s.Send(Event{ID: "2", Data: "hello\nworld"})
+		return s.Send(Event{Event: "done", Data: s.LastEventID()})
+	})
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Last-Event-ID", "1")
+	w := httptest.NewRecorder()
+	h.ServeHTTPContext(context.Background(), w, r)
+
+	assert.Equal(t, w.Header().Get("Content-Type"), "text/event-stream")
+	assert.Equal(t, w.Body.String(), "retry: 3000\n\nid: 2\ndata: hello\ndata: world\n\nevent: done\ndata: 1\n\n")
+	assert.True(t, w.Flushed)
+}
+
+func TestEventStreamCancelsProducer(t *testing.T) {
+	stopped := make(chan error, 1)
+	h := EventStream(Options{Heartbeat: 10 * time.Millisecond}, func(ctx context.Context, s *Stream) error {
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return nil
+	})
+	srv := httptest.NewServer(alice.New().ThenWithContext(context.Background(), h))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+	assert.Equal(t, line, ": heartbeat\n")
+	resp.Body.Close()
+
+	select {
+	case err := <-stopped:
+		assert.Equal(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("producer was not canceled")
+	}
+}
+
+func TestSendAfterClose(t *testing.T) {
+	var stream *Stream
+	h := EventStream(Options{}, func(ctx context.Context, s *Stream) error {
+		stream = s
+		return nil
+	})
+	h.ServeHTTPContext(context.Background(), httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, stream.Send(Event{Data: "late"}), ErrClosed)
+}
+
+type noFlusher struct {
+	http.ResponseWriter
+}
+
+func TestEventStreamRequiresFlusher(t *testing.T) {
+	h := EventStream(Options{}, func(ctx context.Context, s *Stream) error {
+		t.Error("producer called")
+		return nil
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTPContext(context.Background(), noFlusher{w}, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "application/problem+json"))
+}
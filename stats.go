@@ -0,0 +1,176 @@
+package alice
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type statsKey struct{}
+
+// Stats measures a request for the middleware reporting on it:
+// the bytes read from the request body and written to the response,
+// the time spent so far, and durations recorded by application code,
+// such as time spent in the database.
+// A nil *Stats, as returned by StatsFrom outside of CollectStats,
+// discards everything. Stats are safe for concurrent use.
+type Stats struct {
+	start    time.Time
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+
+	mu        sync.Mutex
+	durations map[string]time.Duration
+}
+
+// CollectStats returns a Constructor giving every request Stats
+// (see StatsFrom). Put it first in the chain, so the middleware
+// consuming the stats, such as prom.Metrics, see them once the rest
+// of the chain returns, and log them with the request logger:
+//
+//	alice.New(alice.CollectStats(), prom.Metrics(reg, promOpts), alice.Logging(nil))
+//
+//	alice.StatsFrom(ctx).Add("db", time.Since(start))
+//	...
+//	alice.LoggerFrom(ctx).Info("done", "stats", alice.StatsFrom(ctx))
+func CollectStats() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			s := &Stats{start: time.Now()}
+			if r.Body != nil && r.Body != http.NoBody {
+				r2 := new(http.Request)
+				*r2 = *r
+				r2.Body = &statsBody{ReadCloser: r.Body, stats: s}
+				r = r2
+			}
+			sw := &statsWriter{ResponseWriter: w, stats: s}
+			next.ServeHTTPContext(context.WithValue(ctx, statsKey{}, s), sw, r)
+		})
+	}
+}
+
+// StatsFrom returns the Stats of the request,
+// or nil if CollectStats is not in the chain.
+func StatsFrom(ctx context.Context) *Stats {
+	s, _ := ctx.Value(statsKey{}).(*Stats)
+	return s
+}
+
+// Add adds d to the time spent on name, e.g. "db" or "cache".
+func (s *Stats) Add(name string, d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.durations == nil {
+		s.durations = make(map[string]time.Duration)
+	}
+	s.durations[name] += d
+}
+
+// Durations returns a copy of the durations added with Add.
+func (s *Stats) Durations() map[string]time.Duration {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]time.Duration, len(s.durations))
+	for name, d := range s.durations {
+		out[name] = d
+	}
+	return out
+}
+
+// BytesIn returns the number of request body bytes read so far.
+func (s *Stats) BytesIn() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.bytesIn.Load()
+}
+
+// BytesOut returns the number of response body bytes written so far.
+func (s *Stats) BytesOut() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.bytesOut.Load()
+}
+
+// Elapsed returns the time since the request reached CollectStats.
+func (s *Stats) Elapsed() time.Duration {
+	if s == nil {
+		return 0
+	}
+	return time.Since(s.start)
+}
+
+// LogValue implements slog.LogValuer, logging the stats as a group.
+func (s *Stats) LogValue() slog.Value {
+	if s == nil {
+		return slog.GroupValue()
+	}
+	attrs := []slog.Attr{
+		slog.Int64("bytes_in", s.BytesIn()),
+		slog.Int64("bytes_out", s.BytesOut()),
+		slog.Duration("elapsed", s.Elapsed()),
+	}
+	durations := s.Durations()
+	names := make([]string, 0, len(durations))
+	for name := range durations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		attrs = append(attrs, slog.Duration(name, durations[name]))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// statsBody counts the bytes read from a request body.
+type statsBody struct {
+	io.ReadCloser
+	stats *Stats
+}
+
+func (b *statsBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.stats.bytesIn.Add(int64(n))
+	return n, err
+}
+
+// statsWriter counts the bytes written to the response.
+type statsWriter struct {
+	http.ResponseWriter
+	stats *Stats
+}
+
+func (sw *statsWriter) Write(b []byte) (int, error) {
+	n, err := sw.ResponseWriter.Write(b)
+	sw.stats.bytesOut.Add(int64(n))
+	return n, err
+}
+
+func (sw *statsWriter) Flush() {
+	if f, ok := sw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (sw *statsWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+func (sw *statsWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(sw.ResponseWriter).Hijack()
+}
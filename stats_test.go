@@ -0,0 +1,48 @@
+package alice
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestCollectStats(t *testing.T) {
+	var stats *Stats
+	h := New(CollectStats()).ThenFuncWithContext(context.Background(), func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		stats = StatsFrom(ctx)
+		io.Copy(w, r.Body)
+		stats.Add("db", 2*time.Millisecond)
+		stats.Add("db", 3*time.Millisecond)
+		stats.Add("cache", time.Millisecond)
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader("hello")))
+
+	assert.Equal(t, w.Body.String(), "hello")
+	assert.Equal(t, stats.BytesIn(), int64(5))
+	assert.Equal(t, stats.BytesOut(), int64(5))
+	assert.Equal(t, stats.Durations(), map[string]time.Duration{"db": 5 * time.Millisecond, "cache": time.Millisecond})
+	assert.True(t, stats.Elapsed() > 0)
+
+	var buf bytes.Buffer
+	slog.New(slog.NewTextHandler(&buf, nil)).Info("done", "stats", stats)
+	assert.Contains(t, buf.String(), "stats.bytes_in=5 stats.bytes_out=5 stats.elapsed=")
+	assert.Contains(t, buf.String(), "stats.cache=1ms stats.db=5ms")
+}
+
+func TestStatsWithoutMiddleware(t *testing.T) {
+	stats := StatsFrom(context.Background())
+	assert.Nil(t, stats)
+	stats.Add("db", time.Second)
+	assert.Equal(t, len(stats.Durations()), 0)
+	assert.Equal(t, stats.BytesOut(), int64(0))
+	assert.Equal(t, stats.LogValue().Kind(), slog.KindGroup)
+}
@@ -0,0 +1,96 @@
+package alice
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// DefaultStickyCookie is the cookie Sticky keeps the backend of clients in.
+const DefaultStickyCookie = "alice_backend"
+
+// StickyOptions configure Sticky.
+type StickyOptions struct {
+	// Header, if set, names a request header, such as X-Session-Id,
+	// whose value pins requests to a backend as ConsistentHash does.
+	// Requests without it fall back to the cookie.
+	Header string
+	// Cookie names the cookie remembering the backend of clients.
+	// It defaults to DefaultStickyCookie.
+	Cookie string
+	// MaxAge is the lifetime of the cookie.
+	// Zero makes it a session cookie.
+	MaxAge time.Duration
+}
+
+type stickyKey struct{}
+
+// Sticky returns a Constructor pinning the requests of a client to one
+// backend of the Balancer of a ReverseProxy later in the chain, for
+// stateful upstreams. The backend is remembered in a cookie, or chosen
+// from a request header identifying the session. If the backend is
+// ejected, the client moves to the one the Strategy picks:
+//
+//	proxy := &alice.ReverseProxy{Balancer: alice.NewBalancer(nil, app1, app2)}
+//	mux.Handle("/app/", alice.New(alice.Sticky(alice.StickyOptions{})).ThenWithContext(ctx, proxy))
+//	mux.Handle("/api/", alice.New().ThenWithContext(ctx, proxy))
+func Sticky(opts StickyOptions) Constructor {
+	if opts.Cookie == "" {
+		opts.Cookie = DefaultStickyCookie
+	}
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTPContext(context.WithValue(ctx, stickyKey{}, opts), w, r)
+		})
+	}
+}
+
+func stickyFrom(ctx context.Context) (StickyOptions, bool) {
+	opts, ok := ctx.Value(stickyKey{}).(StickyOptions)
+	return opts, ok
+}
+
+// pinned returns the healthy backend r is pinned to, or nil.
+func (opts StickyOptions) pinned(r *http.Request, healthy []*Backend) *Backend {
+	if key := opts.headerKey(r); key != "" {
+		return rendezvous(key, healthy)
+	}
+	c, err := r.Cookie(opts.Cookie)
+	if err != nil {
+		return nil
+	}
+	for _, be := range healthy {
+		if be.ID() == c.Value {
+			return be
+		}
+	}
+	return nil
+}
+
+func (opts StickyOptions) headerKey(r *http.Request) string {
+	if opts.Header == "" {
+		return ""
+	}
+	return r.Header.Get(opts.Header)
+}
+
+// stick sets the cookie pinning the client of r to be,
+// unless it already is or a header pins it.
+func stick(ctx context.Context, w http.ResponseWriter, r *http.Request, be *Backend) {
+	opts, ok := stickyFrom(ctx)
+	if !ok || opts.headerKey(r) != "" {
+		return
+	}
+	if c, err := r.Cookie(opts.Cookie); err == nil && c.Value == be.ID() {
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     opts.Cookie,
+		Value:    be.ID(),
+		Path:     "/",
+		MaxAge:   int(opts.MaxAge / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
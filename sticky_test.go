@@ -0,0 +1,99 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestStickyCookie(t *testing.T) {
+	var servers []string
+	var urls []*url.URL
+	for _, name := range []string{"one", "two", "three"} {
+		name := name
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name))
+		}))
+		defer srv.Close()
+		u, _ := url.Parse(srv.URL)
+		urls = append(urls, u)
+		servers = append(servers, name)
+	}
+	proxy := &ReverseProxy{Balancer: NewBalancer(nil, urls...)}
+	h := New(Sticky(StickyOptions{MaxAge: time.Hour})).ThenWithContext(context.Background(), proxy)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	first := w.Body.String()
+	cookies := w.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.Equal(t, cookies[0].Name, DefaultStickyCookie)
+	assert.Equal(t, cookies[0].MaxAge, 3600)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.AddCookie(cookies[0])
+		h.ServeHTTP(w, r)
+		assert.Equal(t, w.Body.String(), first)
+		assert.Empty(t, w.Result().Cookies())
+	}
+
+	// An ejected backend loses its clients.
+	for _, be := range proxy.Balancer.Backends {
+		if be.ID() == cookies[0].Value {
+			be.ejectedUntil = time.Now().Add(time.Hour)
+		}
+	}
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(cookies[0])
+	h.ServeHTTP(w, r)
+	assert.NotEqual(t, w.Body.String(), first)
+	assert.Contains(t, servers, w.Body.String())
+	if assert.Len(t, w.Result().Cookies(), 1) {
+		assert.NotEqual(t, w.Result().Cookies()[0].Value, cookies[0].Value)
+	}
+}
+
+func TestStickyHeader(t *testing.T) {
+	b := &Balancer{Backends: backends("a", "b", "c", "d")}
+	sticky := Sticky(StickyOptions{Header: "X-Session-Id"})
+	var hosts []string
+	h := sticky(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		be, err := b.Pick(ctx, r)
+		if assert.NoError(t, err) {
+			hosts = append(hosts, be.URL.Host)
+		}
+	}))
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Session-Id", "s3ss10n")
+		h.ServeHTTPContext(context.Background(), httptest.NewRecorder(), r)
+	}
+	if assert.Len(t, hosts, 3) {
+		assert.Equal(t, hosts, []string{hosts[0], hosts[0], hosts[0]})
+	}
+}
+
+func TestStickyOnlyPerChain(t *testing.T) {
+	b := &Balancer{Backends: backends("a", "b")}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: DefaultStickyCookie, Value: b.Backends[1].ID()})
+
+	be, err := b.Pick(context.Background(), r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, be.URL.Host, "a")
+	}
+	be, err = b.Pick(context.WithValue(context.Background(), stickyKey{}, StickyOptions{Cookie: DefaultStickyCookie}), r)
+	if assert.NoError(t, err) {
+		assert.Equal(t, be.URL.Host, "b")
+	}
+}
@@ -0,0 +1,87 @@
+package alice
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type abuseKey struct{}
+
+// FlagAbuse returns a copy of ctx flagging the client as abusive for
+// reason, e.g. "rate limit exceeded", so that Tarpit slows it down.
+// Rate limiters call it instead of rejecting requests outright.
+func FlagAbuse(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, abuseKey{}, reason)
+}
+
+// AbuseFrom returns the reason given to FlagAbuse.
+func AbuseFrom(ctx context.Context) (string, bool) {
+	reason, ok := ctx.Value(abuseKey{}).(string)
+	return reason, ok
+}
+
+// TarpitPolicy configures Tarpit.
+type TarpitPolicy struct {
+	// Match selects the requests to slow down. It defaults to requests
+	// flagged with FlagAbuse or classified as BadBot by BotDetect.
+	Match func(context.Context, *http.Request) bool
+	// Delay is how long matched requests are held; it defaults to 30s.
+	Delay time.Duration
+	// Status is the status finally sent; it defaults to
+	// 429 Too Many Requests.
+	Status int
+	// MaxHeld caps the requests held at once, so the tarpit cannot
+	// exhaust the server; beyond it, requests are answered at once.
+	// It defaults to 1000.
+	MaxHeld int
+}
+
+// Tarpit returns a Constructor holding matched requests for
+// policy.Delay before answering them with an error, slowing down
+// scrapers and brute-forcers who would otherwise retry immediately.
+// A held request costs a goroutine and a timer; it is released early
+// if the client goes away.
+//
+//	alice.New(alice.BotDetect(alice.BotOptions{}), alice.Tarpit(alice.TarpitPolicy{}))
+func Tarpit(policy TarpitPolicy) Constructor {
+	if policy.Match == nil {
+		policy.Match = func(ctx context.Context, r *http.Request) bool {
+			_, abusive := AbuseFrom(ctx)
+			bot, _ := BotFrom(ctx)
+			return abusive || bot.Class == BadBot
+		}
+	}
+	if policy.Delay <= 0 {
+		policy.Delay = 30 * time.Second
+	}
+	if policy.Status == 0 {
+		policy.Status = http.StatusTooManyRequests
+	}
+	if policy.MaxHeld <= 0 {
+		policy.MaxHeld = 1000
+	}
+	var held atomic.Int64
+
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if !policy.Match(ctx, r) {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			if held.Add(1) <= int64(policy.MaxHeld) {
+				t := time.NewTimer(policy.Delay)
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+				case <-r.Context().Done():
+				}
+				t.Stop()
+			}
+			held.Add(-1)
+			WriteError(ctx, w, &HTTPError{Status: policy.Status, Code: "tarpit"})
+		})
+	}
+}
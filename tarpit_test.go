@@ -0,0 +1,78 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestTarpit(t *testing.T) {
+	flag := func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/login" {
+				ctx = FlagAbuse(ctx, "rate limit exceeded")
+			}
+			next.ServeHTTPContext(ctx, w, r)
+		})
+	}
+	h := New(flag, Tarpit(TarpitPolicy{Delay: 50 * time.Millisecond})).ThenWithContext(context.Background(), testApp)
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/login", nil))
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, w.Code, http.StatusOK)
+}
+
+func TestTarpitBadBot(t *testing.T) {
+	h := New(BotDetect(BotOptions{}), Tarpit(TarpitPolicy{Delay: time.Millisecond, Status: http.StatusServiceUnavailable})).ThenWithContext(context.Background(), testApp)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("User-Agent", "masscan/1.3")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Code, http.StatusServiceUnavailable)
+}
+
+func TestTarpitReleasesOnCancel(t *testing.T) {
+	h := New(Tarpit(TarpitPolicy{Match: func(context.Context, *http.Request) bool { return true }, Delay: time.Hour})).ThenWithContext(context.Background(), testApp)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+}
+
+func TestTarpitMaxHeld(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := New(Tarpit(TarpitPolicy{Match: func(context.Context, *http.Request) bool { return true }, Delay: time.Hour, MaxHeld: 1})).ThenWithContext(context.Background(), testApp)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, w.Code, http.StatusTooManyRequests)
+	assert.True(t, time.Since(start) < time.Second)
+
+	cancel()
+	wg.Wait()
+	_, ok := AbuseFrom(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,137 @@
+package alice
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/context"
+)
+
+// ErrTasksClosed is returned by Tasks.Go once shutdown has begun.
+var ErrTasksClosed = errors.New("alice: tasks closed")
+
+// Tasks tracks background work started by handlers so that a graceful
+// shutdown can wait for it instead of killing it. The zero value is
+// ready to use.
+//
+//	tasks := new(alice.Tasks)
+//	srv := &alice.Server{
+//	    Handler: alice.New(alice.BackgroundTasks(tasks)).ThenWithContext(ctx, h),
+//	    Tasks:   tasks,
+//	}
+//
+// Handlers then use the package-level Go:
+//
+//	alice.Go(ctx, func(ctx context.Context) {
+//	    sendReceipt(ctx, order)
+//	})
+type Tasks struct {
+	once    sync.Once
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	closed  bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pending int
+}
+
+func (t *Tasks) init() {
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+}
+
+// Go runs fn in a new goroutine. Its context has the values of ctx,
+// but is only canceled when a shutdown gives up waiting for it.
+// Panics in fn are logged. Go returns ErrTasksClosed, without running
+// fn, once Shutdown has been called.
+func (t *Tasks) Go(ctx context.Context, fn func(ctx context.Context)) error {
+	t.once.Do(t.init)
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return ErrTasksClosed
+	}
+	t.wg.Add(1)
+	t.pending++
+	t.mu.Unlock()
+
+	taskCtx := taskContext{Context: t.ctx, values: ctx}
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				logger().Error("alice: background task panicked", "error", fmt.Sprint(err))
+			}
+			t.mu.Lock()
+			t.pending--
+			t.mu.Unlock()
+			t.wg.Done()
+		}()
+		fn(taskCtx)
+	}()
+	return nil
+}
+
+// Pending returns the number of tasks still running.
+func (t *Tasks) Pending() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pending
+}
+
+// Shutdown stops accepting tasks and waits for the running ones.
+// If ctx is done first, their contexts are canceled and ctx's error
+// is returned without waiting further.
+func (t *Tasks) Shutdown(ctx context.Context) error {
+	t.once.Do(t.init)
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.cancel()
+		return nil
+	case <-ctx.Done():
+		t.cancel()
+		return ctx.Err()
+	}
+}
+
+// taskContext has the values of a request context
+// and the cancellation of its Tasks.
+type taskContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c taskContext) Value(key any) any {
+	return c.values.Value(key)
+}
+
+type tasksKey struct{}
+
+// BackgroundTasks returns a Constructor making tasks available to Go.
+func BackgroundTasks(tasks *Tasks) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTPContext(context.WithValue(ctx, tasksKey{}, tasks), w, r)
+		})
+	}
+}
+
+// Go runs fn in the background with the Tasks of ctx, see Tasks.Go.
+// Without BackgroundTasks in the chain, fn runs in a plain goroutine
+// with a detached context (see Detach), which a shutdown does not wait for.
+func Go(ctx context.Context, fn func(ctx context.Context)) error {
+	if tasks, ok := ctx.Value(tasksKey{}).(*Tasks); ok {
+		return tasks.Go(ctx, fn)
+	}
+	go fn(Detach(ctx))
+	return nil
+}
@@ -0,0 +1,83 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestTasksShutdownWaits(t *testing.T) {
+	tasks := new(Tasks)
+	release := make(chan struct{})
+	finished := false
+	h := New(RequestID(), BackgroundTasks(tasks)).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		Go(ctx, func(ctx context.Context) {
+			<-release
+			finished = RequestIDFrom(ctx) == "req-1"
+		})
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	assert.Equal(t, tasks.Pending(), 1)
+
+	close(release)
+	assert.Nil(t, tasks.Shutdown(context.Background()))
+	assert.True(t, finished)
+	assert.Equal(t, tasks.Go(context.Background(), func(context.Context) {}), ErrTasksClosed)
+}
+
+func TestTasksShutdownIsBounded(t *testing.T) {
+	tasks := new(Tasks)
+	canceled := make(chan struct{})
+	tasks.Go(context.Background(), func(ctx context.Context) {
+		<-ctx.Done()
+		close(canceled)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, tasks.Shutdown(ctx), context.DeadlineExceeded)
+	select {
+	case <-canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not canceled")
+	}
+}
+
+func TestTasksRecoverPanics(t *testing.T) {
+	tasks := new(Tasks)
+	tasks.Go(context.Background(), func(context.Context) { panic("boom") })
+	assert.Nil(t, tasks.Shutdown(context.Background()))
+}
+
+func TestGoWithoutTasks(t *testing.T) {
+	done := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	Go(ctx, func(ctx context.Context) { done <- ctx.Err() })
+	assert.Nil(t, <-done)
+}
+
+func TestServerWaitsForTasks(t *testing.T) {
+	tasks := new(Tasks)
+	finished := make(chan struct{})
+	tasks.Go(context.Background(), func(context.Context) {
+		time.Sleep(10 * time.Millisecond)
+		close(finished)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := &Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler(), Tasks: tasks}
+	cancel()
+	assert.Nil(t, srv.ListenAndServe(ctx))
+	select {
+	case <-finished:
+	default:
+		t.Fatal("server did not wait for the task")
+	}
+}
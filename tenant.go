@@ -0,0 +1,60 @@
+package alice
+
+import (
+	"errors"
+	"net/http"
+
+	"golang.org/x/net/context"
+)
+
+// TenantInfo describes the tenant a request belongs to.
+type TenantInfo struct {
+	ID   string
+	Name string
+	// Meta holds application-specific attributes, such as the plan.
+	Meta map[string]string
+}
+
+var (
+	// ErrTenantNotFound is returned by tenant resolvers
+	// when the request names no known tenant. Tenant responds 404.
+	ErrTenantNotFound = errors.New("alice: tenant not found")
+	// ErrTenantForbidden is returned by tenant resolvers
+	// when the request may not access the tenant. Tenant responds 403.
+	ErrTenantForbidden = errors.New("alice: tenant forbidden")
+)
+
+type tenantKey struct{}
+
+// Tenant returns a Constructor resolving the tenant of every request,
+// e.g. from a header, the subdomain or a token, and storing it in the context
+// (see TenantFrom). Requests the resolver fails for do not reach
+// the rest of the chain: ErrTenantNotFound is answered with 404,
+// ErrTenantForbidden with 403 and other errors are written with WriteError.
+//
+//	alice.New(alice.Subdomain("example.com"), alice.Tenant(func(ctx context.Context, r *http.Request) (alice.TenantInfo, error) {
+//	    return tenants.Lookup(ctx, alice.SubdomainFrom(ctx))
+//	}))
+func Tenant(resolver func(context.Context, *http.Request) (TenantInfo, error)) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			tenant, err := resolver(ctx, r)
+			switch {
+			case errors.Is(err, ErrTenantNotFound):
+				WriteError(ctx, w, &HTTPError{Status: http.StatusNotFound, Err: err})
+			case errors.Is(err, ErrTenantForbidden):
+				WriteError(ctx, w, &HTTPError{Status: http.StatusForbidden, Err: err})
+			case err != nil:
+				WriteError(ctx, w, err)
+			default:
+				next.ServeHTTPContext(context.WithValue(ctx, tenantKey{}, tenant), w, r)
+			}
+		})
+	}
+}
+
+// TenantFrom returns the tenant resolved by Tenant.
+func TenantFrom(ctx context.Context) (TenantInfo, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(TenantInfo)
+	return tenant, ok
+}
@@ -0,0 +1,46 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestTenant(t *testing.T) {
+	resolver := func(ctx context.Context, r *http.Request) (TenantInfo, error) {
+		switch id := r.Header.Get("X-Tenant"); id {
+		case "acme":
+			return TenantInfo{ID: id, Name: "Acme"}, nil
+		case "locked":
+			return TenantInfo{}, ErrTenantForbidden
+		default:
+			return TenantInfo{}, ErrTenantNotFound
+		}
+	}
+	h := Tenant(resolver)(ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		tenant, ok := TenantFrom(ctx)
+		assert.True(t, ok)
+		w.Write([]byte(tenant.Name))
+	}))
+
+	tests := map[string]int{
+		"acme":    http.StatusOK,
+		"locked":  http.StatusForbidden,
+		"unknown": http.StatusNotFound,
+	}
+	for id, code := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Tenant", id)
+		h.ServeHTTPContext(context.Background(), w, r)
+		assert.Equal(t, w.Code, code, id)
+	}
+}
+
+func TestTenantFromWithoutTenant(t *testing.T) {
+	_, ok := TenantFrom(context.Background())
+	assert.False(t, ok)
+}
@@ -0,0 +1,99 @@
+package alice
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+type timingKey struct{}
+
+// Timings collects the metrics reported in the Server-Timing header.
+// A nil *Timings, as returned by Timing outside of ServerTiming,
+// discards everything.
+type Timings struct {
+	mu      sync.Mutex
+	metrics []timingMetric
+}
+
+type timingMetric struct {
+	name, desc string
+	dur        time.Duration
+}
+
+// ServerTiming returns a Constructor emitting a Server-Timing header
+// with the metrics recorded through Timing(ctx), plus the total time
+// spent in the rest of the chain, so they show up in the browser's
+// developer tools:
+//
+//	stop := alice.Timing(ctx).Start("db")
+//	rows, err := db.QueryContext(ctx, q)
+//	stop()
+//
+// Only metrics finished before the response header is written are
+// included. Metric names must be valid header tokens.
+// The header exposes internal timings, so consider only enabling
+// ServerTiming for trusted clients.
+func ServerTiming() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			timings, start := &Timings{}, time.Now()
+			hw := NewHookWriter(w, func() {
+				w.Header().Set("Server-Timing", timings.header(time.Since(start)))
+			})
+			next.ServeHTTPContext(context.WithValue(ctx, timingKey{}, timings), hw, r)
+			hw.Run()
+		})
+	}
+}
+
+// Timing returns the Timings of the request,
+// or nil if ServerTiming is not in the chain.
+func Timing(ctx context.Context) *Timings {
+	t, _ := ctx.Value(timingKey{}).(*Timings)
+	return t
+}
+
+// Start starts timing name, returning the function recording it.
+func (t *Timings) Start(name string) (stop func()) {
+	if t == nil {
+		return func() {}
+	}
+	start := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.Add(name, "", time.Since(start))
+		})
+	}
+}
+
+// Add records a metric measured elsewhere.
+// desc is an optional human readable description.
+func (t *Timings) Add(name, desc string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.metrics = append(t.metrics, timingMetric{name: name, desc: desc, dur: d})
+	t.mu.Unlock()
+}
+
+// header formats the metrics, followed by total.
+func (t *Timings) header(total time.Duration) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parts := make([]string, 0, len(t.metrics)+1)
+	for _, m := range append(t.metrics, timingMetric{name: "total", dur: total}) {
+		s := m.name + ";dur=" + strconv.FormatFloat(float64(m.dur)/float64(time.Millisecond), 'f', -1, 64)
+		if m.desc != "" {
+			s += ";desc=" + strconv.Quote(m.desc)
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, ", ")
+}
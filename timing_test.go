@@ -0,0 +1,41 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestServerTiming(t *testing.T) {
+	h := New(ServerTiming()).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		stop := Timing(ctx).Start("db")
+		stop()
+		stop()
+		Timing(ctx).Add("cache", "Cache Read", 1500*time.Microsecond)
+		w.Write([]byte("ok"))
+		Timing(ctx).Add("late", "", time.Second)
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Regexp(t, regexp.MustCompile(`^db;dur=[0-9.]+, cache;dur=1.5;desc="Cache Read", total;dur=[0-9.]+$`), w.Header().Get("Server-Timing"))
+}
+
+func TestServerTimingWithoutBody(t *testing.T) {
+	h := New(ServerTiming()).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Regexp(t, regexp.MustCompile(`^total;dur=`), w.Header().Get("Server-Timing"))
+}
+
+func TestTimingWithoutMiddleware(t *testing.T) {
+	timings := Timing(context.Background())
+	assert.Nil(t, timings)
+	timings.Start("db")()
+	timings.Add("db", "", time.Second)
+}
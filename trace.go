@@ -0,0 +1,167 @@
+package alice
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// TraceContext identifies the current request within a distributed trace,
+// as carried by W3C Trace Context or B3 headers.
+// IDs are lowercase hex: 32 digits for TraceID, 16 for span IDs.
+type TraceContext struct {
+	TraceID string
+	// SpanID identifies the request in this service.
+	SpanID string
+	// ParentSpanID identifies the calling span, if any.
+	ParentSpanID string
+	Sampled      bool
+	// State is the W3C tracestate of the trace.
+	State string
+}
+
+type traceKey struct{}
+
+// PropagateTrace returns a Constructor reading the trace of a request
+// from its traceparent and tracestate headers, or from B3 headers
+// (b3, or X-B3-TraceId, X-B3-SpanId and X-B3-Sampled) otherwise.
+// The request gets a new span ID, child of the incoming one;
+// requests without a valid trace start a new sampled one.
+// The result is stored in the context, see TraceFrom, and can be
+// forwarded with InjectTraceContext or InjectB3.
+//
+// It is meant for services correlating requests without full tracing;
+// see package otel for OpenTelemetry.
+func PropagateTrace() Constructor {
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			tc, ok := parseTraceparent(r.Header)
+			if !ok {
+				tc, ok = parseB3(r.Header)
+			}
+			if ok {
+				tc.ParentSpanID = tc.SpanID
+			} else {
+				tc = TraceContext{TraceID: randomHex(16), Sampled: true}
+			}
+			tc.SpanID = randomHex(8)
+			next.ServeHTTPContext(context.WithValue(ctx, traceKey{}, tc), w, r)
+		})
+	}
+}
+
+// TraceFrom returns the trace stored by PropagateTrace.
+func TraceFrom(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey{}).(TraceContext)
+	return tc, ok
+}
+
+// InjectTraceContext is a Propagator setting the W3C traceparent
+// and tracestate headers from the trace in ctx.
+func InjectTraceContext(ctx context.Context, h http.Header) {
+	tc, ok := TraceFrom(ctx)
+	if !ok {
+		return
+	}
+	flags := "00"
+	if tc.Sampled {
+		flags = "01"
+	}
+	h.Set("Traceparent", "00-"+tc.TraceID+"-"+tc.SpanID+"-"+flags)
+	if tc.State != "" {
+		h.Set("Tracestate", tc.State)
+	}
+}
+
+// InjectB3 is a Propagator setting the single b3 header
+// from the trace in ctx.
+func InjectB3(ctx context.Context, h http.Header) {
+	tc, ok := TraceFrom(ctx)
+	if !ok {
+		return
+	}
+	sampled := "0"
+	if tc.Sampled {
+		sampled = "1"
+	}
+	h.Set("B3", tc.TraceID+"-"+tc.SpanID+"-"+sampled)
+}
+
+// parseTraceparent parses a version 00 traceparent header,
+// accepting the fields of future versions as the specification asks.
+func parseTraceparent(h http.Header) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(h.Get("Traceparent")), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		parts[0] == "00" && len(parts) != 4 {
+		return TraceContext{}, false
+	}
+	tc := TraceContext{TraceID: parts[1], SpanID: parts[2]}
+	flags, err := hex.DecodeString(parts[3])
+	if !validID(tc.TraceID, 32) || !validID(tc.SpanID, 16) || err != nil || len(flags) != 1 {
+		return TraceContext{}, false
+	}
+	tc.Sampled = flags[0]&1 == 1
+	tc.State = strings.Join(h.Values("Tracestate"), ",")
+	return tc, true
+}
+
+// parseB3 parses the single b3 header or the multiple X-B3 headers.
+// 64-bit trace IDs are left-padded to 128 bits.
+func parseB3(h http.Header) (TraceContext, bool) {
+	var traceID, spanID, sampled string
+	if b3 := h.Get("B3"); b3 != "" {
+		parts := strings.Split(b3, "-")
+		if len(parts) < 2 {
+			return TraceContext{}, false
+		}
+		traceID, spanID = parts[0], parts[1]
+		if len(parts) > 2 {
+			sampled = parts[2]
+		}
+	} else {
+		traceID, spanID = h.Get("X-B3-TraceId"), h.Get("X-B3-SpanId")
+		sampled = h.Get("X-B3-Sampled")
+		if h.Get("X-B3-Flags") == "1" {
+			sampled = "d"
+		}
+	}
+	traceID = strings.ToLower(traceID)
+	spanID = strings.ToLower(spanID)
+	if len(traceID) == 16 {
+		traceID = strings.Repeat("0", 16) + traceID
+	}
+	if !validID(traceID, 32) || !validID(spanID, 16) {
+		return TraceContext{}, false
+	}
+	// Absent sampling decisions default to sampled.
+	return TraceContext{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Sampled: sampled != "0" && sampled != "false",
+	}, true
+}
+
+// validID reports whether id is n lowercase hex digits, not all zero.
+func validID(id string, n int) bool {
+	if len(id) != n || strings.Trim(id, "0") == "" {
+		return false
+	}
+	for _, c := range id {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("alice: reading random bytes: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
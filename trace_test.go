@@ -0,0 +1,77 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func serveTrace(header http.Header) (TraceContext, bool) {
+	var tc TraceContext
+	var ok bool
+	h := New(PropagateTrace()).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		tc, ok = TraceFrom(ctx)
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header = header
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	return tc, ok
+}
+
+func TestPropagateTraceParsesTraceparent(t *testing.T) {
+	tc, ok := serveTrace(http.Header{
+		"Traceparent": {"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		"Tracestate":  {"congo=t61rcWkgMzE"},
+	})
+	assert.True(t, ok)
+	assert.Equal(t, tc.TraceID, "4bf92f3577b34da6a3ce929d0e0e4736")
+	assert.Equal(t, tc.ParentSpanID, "00f067aa0ba902b7")
+	assert.NotEqual(t, tc.SpanID, "00f067aa0ba902b7")
+	assert.True(t, tc.Sampled)
+	assert.Equal(t, tc.State, "congo=t61rcWkgMzE")
+}
+
+func TestPropagateTraceParsesB3(t *testing.T) {
+	tc, _ := serveTrace(http.Header{"B3": {"80f198ee56343ba8-e457b5a2e4d86bd1-0"}})
+	assert.Equal(t, tc.TraceID, "000000000000000080f198ee56343ba8")
+	assert.Equal(t, tc.ParentSpanID, "e457b5a2e4d86bd1")
+	assert.False(t, tc.Sampled)
+
+	tc, _ = serveTrace(http.Header{
+		"X-B3-Traceid": {"463ac35c9f6413ad48485a3953bb6124"},
+		"X-B3-Spanid":  {"a2fb4a1d1a96d312"},
+		"X-B3-Sampled": {"1"},
+	})
+	assert.Equal(t, tc.TraceID, "463ac35c9f6413ad48485a3953bb6124")
+	assert.True(t, tc.Sampled)
+}
+
+func TestPropagateTraceStartsNewTrace(t *testing.T) {
+	tc, ok := serveTrace(http.Header{"Traceparent": {"00-00000000000000000000000000000000-00f067aa0ba902b7-01"}})
+	assert.True(t, ok)
+	assert.Equal(t, len(tc.TraceID), 32)
+	assert.NotEqual(t, tc.TraceID, "00000000000000000000000000000000")
+	assert.Equal(t, tc.ParentSpanID, "")
+}
+
+func TestInjectTrace(t *testing.T) {
+	ctx := context.WithValue(context.Background(), traceKey{}, TraceContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+		Sampled: true,
+		State:   "congo=t61rcWkgMzE",
+	})
+	h := http.Header{}
+	InjectTraceContext(ctx, h)
+	InjectB3(ctx, h)
+	assert.Equal(t, h.Get("Traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.Equal(t, h.Get("Tracestate"), "congo=t61rcWkgMzE")
+	assert.Equal(t, h.Get("B3"), "4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-1")
+
+	h = http.Header{}
+	InjectTraceContext(context.Background(), h)
+	assert.Equal(t, len(h), 0)
+}
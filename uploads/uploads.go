@@ -0,0 +1,302 @@
+// Package uploads streams multipart file uploads to a storage backend
+// without buffering them in memory or temporary files.
+package uploads
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// Storage stores uploaded files.
+type Storage interface {
+	// Put stores the contents of r under a name derived from filename
+	// and returns where it was stored.
+	Put(ctx context.Context, filename, contentType string, r io.Reader) (location string, err error)
+	// Delete removes a stored file, rolling back failed uploads.
+	Delete(ctx context.Context, location string) error
+}
+
+// Disk stores files in a directory under random names
+// keeping the extension of the uploaded file.
+type Disk struct {
+	Dir string
+}
+
+// Put writes r to a new file in d.Dir and returns its path.
+func (d Disk) Put(ctx context.Context, filename, contentType string, r io.Reader) (string, error) {
+	f, err := os.CreateTemp(d.Dir, "upload-*"+filepath.Ext(filepath.Base(filename)))
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// Delete removes the file at location.
+func (d Disk) Delete(ctx context.Context, location string) error {
+	return os.Remove(location)
+}
+
+// ObjectPutter is the subset of an S3-compatible client used by S3.
+// Implement it on top of the SDK in use; size is -1 when unknown.
+type ObjectPutter interface {
+	PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error
+	DeleteObject(ctx context.Context, bucket, key string) error
+}
+
+// S3 stores files in a bucket of an S3-compatible object store
+// under Prefix and a random name.
+type S3 struct {
+	Client ObjectPutter
+	Bucket string
+	Prefix string
+}
+
+// Put uploads r and returns its key.
+func (s S3) Put(ctx context.Context, filename, contentType string, r io.Reader) (string, error) {
+	key := s.Prefix + randomName() + filepath.Ext(filepath.Base(filename))
+	if err := s.Client.PutObject(ctx, s.Bucket, key, r, -1, contentType); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Delete removes the object with key location.
+func (s S3) Delete(ctx context.Context, location string) error {
+	return s.Client.DeleteObject(ctx, s.Bucket, location)
+}
+
+func randomName() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// File is a stored upload.
+type File struct {
+	// Field is the form field the file was sent in.
+	Field       string
+	Filename    string
+	ContentType string
+	Size        int64
+	// Location is where Storage put the file.
+	Location string
+}
+
+// Progress reports how far an upload has come.
+type Progress struct {
+	Field, Filename string
+	// Bytes is the size of the current part read so far.
+	Bytes int64
+	// Read is the number of body bytes read so far,
+	// Total the body size or -1 if unknown.
+	Read, Total int64
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context in which uploads report their
+// progress to fn, e.g. to publish it for a polling client.
+// fn is called from the goroutine serving the request.
+func WithProgress(ctx context.Context, fn func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// Options configure Handler.
+type Options struct {
+	// Storage receives the files.
+	Storage Storage
+	// MaxPartSize limits each file; 0 means no limit.
+	MaxPartSize int64
+	// MaxParts limits the number of parts; it defaults to 100.
+	MaxParts int
+	// MaxValueSize limits each non-file field; it defaults to 1 MB.
+	MaxValueSize int64
+}
+
+type uploadKey struct{}
+
+type upload struct {
+	files  []File
+	values url.Values
+}
+
+// Handler returns a ContextHandler reading a multipart/form-data body
+// part by part, streaming files to opts.Storage as they arrive.
+// It then serves next with the stored files and the other fields
+// in the context (see FilesFrom and ValuesFrom).
+// If a part exceeds its limit or storing fails, the files stored so far
+// are deleted and the request fails with 413 or 500 respectively.
+func Handler(opts Options, next alice.ContextHandler) alice.ContextHandler {
+	maxParts := opts.MaxParts
+	if maxParts <= 0 {
+		maxParts = 100
+	}
+	maxValue := opts.MaxValueSize
+	if maxValue <= 0 {
+		maxValue = 1 << 20
+	}
+	return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mt != "multipart/form-data" {
+			w.Header().Set("Accept-Post", "multipart/form-data")
+			alice.WriteError(ctx, w, &alice.HTTPError{Status: http.StatusUnsupportedMediaType})
+			return
+		}
+
+		progress, _ := ctx.Value(progressKey{}).(func(Progress))
+		body := &countingReader{r: r.Body}
+		r.Body = struct {
+			io.Reader
+			io.Closer
+		}{body, r.Body}
+		mr, err := r.MultipartReader()
+		if err != nil {
+			alice.WriteError(ctx, w, &alice.HTTPError{Status: http.StatusBadRequest, Code: "malformed_multipart", Err: err})
+			return
+		}
+
+		up := &upload{values: url.Values{}}
+		fail := func(err *alice.HTTPError) {
+			for _, f := range up.files {
+				opts.Storage.Delete(ctx, f.Location)
+			}
+			alice.WriteError(ctx, w, err)
+		}
+		for parts := 0; ; parts++ {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				fail(&alice.HTTPError{Status: http.StatusBadRequest, Code: "malformed_multipart", Err: err})
+				return
+			}
+			if parts == maxParts {
+				fail(&alice.HTTPError{Status: http.StatusRequestEntityTooLarge, Detail: fmt.Sprintf("more than %d parts", maxParts)})
+				return
+			}
+
+			field := part.FormName()
+			if part.FileName() == "" {
+				v, err := io.ReadAll(io.LimitReader(part, maxValue+1))
+				if err != nil {
+					fail(&alice.HTTPError{Status: http.StatusBadRequest, Code: "malformed_multipart", Err: err})
+					return
+				}
+				if int64(len(v)) > maxValue {
+					fail(&alice.HTTPError{Status: http.StatusRequestEntityTooLarge, Detail: "field " + field + " is too large"})
+					return
+				}
+				up.values.Add(field, string(v))
+				continue
+			}
+
+			pr := &partReader{part: part, limit: opts.MaxPartSize}
+			if progress != nil {
+				pr.report = func(n int64) {
+					progress(Progress{
+						Field:    field,
+						Filename: part.FileName(),
+						Bytes:    n,
+						Read:     body.n,
+						Total:    r.ContentLength,
+					})
+				}
+			}
+			contentType := part.Header.Get("Content-Type")
+			location, err := opts.Storage.Put(ctx, part.FileName(), contentType, pr)
+			if errors.Is(err, errPartTooLarge) || pr.tooLarge {
+				if err == nil {
+					opts.Storage.Delete(ctx, location)
+				}
+				fail(&alice.HTTPError{Status: http.StatusRequestEntityTooLarge, Detail: "file " + part.FileName() + " is too large"})
+				return
+			}
+			if err != nil {
+				fail(&alice.HTTPError{Status: http.StatusInternalServerError, Err: err})
+				return
+			}
+			up.files = append(up.files, File{
+				Field:       field,
+				Filename:    part.FileName(),
+				ContentType: contentType,
+				Size:        pr.n,
+				Location:    location,
+			})
+		}
+		next.ServeHTTPContext(context.WithValue(ctx, uploadKey{}, up), w, r)
+	})
+}
+
+// FilesFrom returns the files stored by Handler.
+func FilesFrom(ctx context.Context) []File {
+	up, _ := ctx.Value(uploadKey{}).(*upload)
+	if up == nil {
+		return nil
+	}
+	return up.files
+}
+
+// ValuesFrom returns the non-file fields read by Handler.
+func ValuesFrom(ctx context.Context) url.Values {
+	up, _ := ctx.Value(uploadKey{}).(*upload)
+	if up == nil {
+		return nil
+	}
+	return up.values
+}
+
+var errPartTooLarge = errors.New("uploads: part too large")
+
+// partReader enforces the part size limit and reports progress.
+type partReader struct {
+	part     io.Reader
+	limit    int64
+	n        int64
+	tooLarge bool
+	report   func(int64)
+}
+
+func (p *partReader) Read(b []byte) (int, error) {
+	n, err := p.part.Read(b)
+	p.n += int64(n)
+	if p.limit > 0 && p.n > p.limit {
+		p.tooLarge = true
+		return 0, errPartTooLarge
+	}
+	if p.report != nil && n > 0 {
+		p.report(p.n)
+	}
+	return n, err
+}
+
+// countingReader counts the body bytes read.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,112 @@
+package uploads
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func multipartBody(files map[string]string) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "report")
+	for name, content := range files {
+		fw, _ := mw.CreateFormFile("file", name)
+		fw.Write([]byte(content))
+	}
+	mw.Close()
+	return &body, mw.FormDataContentType()
+}
+
+func TestHandlerStreamsToDisk(t *testing.T) {
+	dir := t.TempDir()
+	var files []File
+	var title string
+	var progress []Progress
+	h := Handler(Options{Storage: Disk{Dir: dir}}, alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		files = FilesFrom(ctx)
+		title = ValuesFrom(ctx).Get("title")
+	}))
+
+	body, contentType := multipartBody(map[string]string{"a.txt": "hello"})
+	r := httptest.NewRequest("POST", "/", body)
+	r.Header.Set("Content-Type", contentType)
+	ctx := WithProgress(context.Background(), func(p Progress) {
+		progress = append(progress, p)
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTPContext(ctx, w, r)
+
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, title, "report")
+	assert.Equal(t, len(files), 1)
+	assert.Equal(t, files[0].Filename, "a.txt")
+	assert.Equal(t, files[0].Size, int64(5))
+	content, _ := os.ReadFile(files[0].Location)
+	assert.Equal(t, string(content), "hello")
+	assert.Equal(t, progress[len(progress)-1].Bytes, int64(5))
+	assert.Equal(t, progress[len(progress)-1].Filename, "a.txt")
+}
+
+func TestHandlerRollsBackOversizedUploads(t *testing.T) {
+	dir := t.TempDir()
+	h := Handler(Options{Storage: Disk{Dir: dir}, MaxPartSize: 8}, alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler called")
+	}))
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "small.txt")
+	fw.Write([]byte("ok"))
+	fw, _ = mw.CreateFormFile("file", "large.txt")
+	fw.Write(bytes.Repeat([]byte("x"), 64))
+	mw.Close()
+
+	r := httptest.NewRequest("POST", "/", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	h.ServeHTTPContext(context.Background(), w, r)
+
+	assert.Equal(t, w.Code, http.StatusRequestEntityTooLarge)
+	entries, _ := os.ReadDir(dir)
+	assert.Equal(t, len(entries), 0)
+}
+
+func TestHandlerRequiresMultipart(t *testing.T) {
+	h := Handler(Options{Storage: Disk{Dir: t.TempDir()}}, nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTPContext(context.Background(), w, httptest.NewRequest("POST", "/", nil))
+	assert.Equal(t, w.Code, http.StatusUnsupportedMediaType)
+}
+
+type memoryBucket map[string][]byte
+
+func (m memoryBucket) PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error {
+	b, err := io.ReadAll(body)
+	m[bucket+"/"+key] = b
+	return err
+}
+
+func (m memoryBucket) DeleteObject(ctx context.Context, bucket, key string) error {
+	delete(m, bucket+"/"+key)
+	return nil
+}
+
+func TestS3Storage(t *testing.T) {
+	bucket := memoryBucket{}
+	s := S3{Client: bucket, Bucket: "uploads", Prefix: "incoming/"}
+
+	key, err := s.Put(context.Background(), "a.txt", "text/plain", bytes.NewReader([]byte("hello")))
+	assert.Nil(t, err)
+	assert.Equal(t, string(bucket["uploads/"+key]), "hello")
+	assert.Nil(t, s.Delete(context.Background(), key))
+	assert.Equal(t, len(bucket), 0)
+}
@@ -0,0 +1,297 @@
+package alice
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// MaxValidatedBodySize is the largest request body Validate decodes.
+const MaxValidatedBodySize = 1 << 20
+
+// A Schema validates decoded JSON documents, such as a compiled
+// JSON Schema (see package jsonschema). It returns the problems found
+// keyed by the location of the offending value, or nil.
+type Schema interface {
+	ValidateJSON(v any) map[string]string
+}
+
+type bodyKey struct{}
+
+// Validate returns a Constructor decoding and validating JSON request
+// bodies against schemaOrStruct, which is either a Schema or a struct
+// value whose type the body is decoded into:
+//
+//	type CreateUser struct {
+//	    Name  string `json:"name" validate:"required,max=64"`
+//	    Email string `json:"email" validate:"required,email"`
+//	    Role  string `json:"role" validate:"oneof=admin member"`
+//	}
+//
+//	chain := alice.New(alice.Validate(CreateUser{}))
+//	...
+//	user, _ := alice.BodyFrom[CreateUser](ctx)
+//
+// Struct fields are checked according to their validate tags
+// (see ValidateStruct). Bodies decoded against a Schema are stored
+// as the generic values of encoding/json, with numbers as json.Number.
+// The decoded value is stored in the context (see BodyFrom) and the
+// body remains readable by the handler. Malformed or invalid bodies
+// get a 400 Bad Request listing the field errors, bodies larger than
+// MaxValidatedBodySize a 413 Request Entity Too Large. Validate panics
+// on validate tags with unknown or malformed rules.
+func Validate(schemaOrStruct any) Constructor {
+	schema, isSchema := schemaOrStruct.(Schema)
+	typ := reflect.TypeOf(schemaOrStruct)
+	if !isSchema && (typ == nil || typ.Kind() != reflect.Struct) {
+		panic(fmt.Sprintf("alice: Validate needs a Schema or a struct, got %T", schemaOrStruct))
+	}
+	if !isSchema {
+		checkTags(typ, map[reflect.Type]bool{})
+	}
+	return func(next ContextHandler) ContextHandler {
+		return ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			var body []byte
+			if r.Body != nil {
+				var err error
+				body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, MaxValidatedBodySize))
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					WriteError(ctx, w, &HTTPError{Status: http.StatusRequestEntityTooLarge})
+					return
+				} else if err != nil {
+					WriteError(ctx, w, &HTTPError{Status: http.StatusBadRequest, Code: "unreadable_body", Err: err})
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			var v any
+			var fields map[string]string
+			if isSchema {
+				dec := json.NewDecoder(bytes.NewReader(body))
+				dec.UseNumber()
+				if err := dec.Decode(&v); err != nil {
+					writeMalformed(ctx, w, err)
+					return
+				}
+				fields = schema.ValidateJSON(v)
+			} else {
+				ptr := reflect.New(typ)
+				if err := json.Unmarshal(body, ptr.Interface()); err != nil {
+					writeMalformed(ctx, w, err)
+					return
+				}
+				v = ptr.Elem().Interface()
+				fields = ValidateStruct(v)
+			}
+			if len(fields) > 0 {
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusBadRequest,
+					Code:   "invalid_body",
+					Detail: "the request body is invalid",
+					Fields: fields,
+				})
+				return
+			}
+			next.ServeHTTPContext(context.WithValue(ctx, bodyKey{}, v), w, r)
+		})
+	}
+}
+
+func writeMalformed(ctx context.Context, w http.ResponseWriter, err error) {
+	WriteError(ctx, w, &HTTPError{
+		Status: http.StatusBadRequest,
+		Code:   "malformed_body",
+		Detail: "the request body is not valid JSON: " + err.Error(),
+		Err:    err,
+	})
+}
+
+// BodyFrom returns the request body decoded by Validate.
+func BodyFrom[T any](ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(bodyKey{}).(T)
+	return v, ok
+}
+
+// ValidateStruct checks the fields of a struct (or pointer to struct)
+// against their validate tags, descending into nested structs.
+// It returns the problems keyed by the JSON name of the field,
+// dotted for nested fields, or nil. Supported rules are:
+//
+//	required   the field is not its zero value
+//	min=n      strings, slices and maps have at least n elements,
+//	           numbers are at least n
+//	max=n      likewise at most n
+//	oneof=a b  the field is one of the space-separated values
+//	email      the field is an email address
+//
+// Rules other than required are skipped for zero values.
+func ValidateStruct(v any) map[string]string {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := make(map[string]string)
+	validateStruct(rv, "", fields)
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+func validateStruct(rv reflect.Value, prefix string, fields map[string]string) {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := jsonName(sf)
+		if name == "-" {
+			continue
+		}
+		fv := rv.Field(i)
+		if msg := checkRules(fv, sf.Tag.Get("validate")); msg != "" {
+			fields[prefix+name] = msg
+			continue
+		}
+		for fv.Kind() == reflect.Pointer && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct {
+			validateStruct(fv, prefix+name+".", fields)
+		}
+	}
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" {
+		return sf.Name
+	}
+	return name
+}
+
+// checkTags panics on the first unknown or malformed rule in the
+// validate tags of the struct type t and the structs nested in it.
+func checkTags(t reflect.Type, seen map[reflect.Type]bool) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() || jsonName(sf) == "-" {
+			continue
+		}
+		if tag := sf.Tag.Get("validate"); tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				name, arg, _ := strings.Cut(rule, "=")
+				switch name {
+				case "required", "oneof", "email":
+				case "min", "max":
+					if _, err := strconv.ParseFloat(arg, 64); err != nil {
+						panic(fmt.Sprintf("alice: invalid validate rule %q", rule))
+					}
+				default:
+					panic(fmt.Sprintf("alice: unknown validate rule %q", rule))
+				}
+			}
+		}
+		ft := sf.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			checkTags(ft, seen)
+		}
+	}
+}
+
+// checkRules returns the first rule of tag fv breaks, described, or "".
+func checkRules(fv reflect.Value, tag string) string {
+	if tag == "" {
+		return ""
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			if fv.IsZero() {
+				return "is required"
+			}
+			continue
+		}
+		if fv.IsZero() {
+			return ""
+		}
+		switch name {
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				panic(fmt.Sprintf("alice: invalid validate rule %q", rule))
+			}
+			size, ok := sizeOf(fv)
+			if !ok {
+				continue
+			}
+			if name == "min" && size < n {
+				return "must be at least " + arg
+			}
+			if name == "max" && size > n {
+				return "must be at most " + arg
+			}
+		case "oneof":
+			s := fmt.Sprint(reflect.Indirect(fv).Interface())
+			found := false
+			for _, allowed := range strings.Fields(arg) {
+				found = found || s == allowed
+			}
+			if !found {
+				return "must be one of " + strings.Join(strings.Fields(arg), ", ")
+			}
+		case "email":
+			s, _ := reflect.Indirect(fv).Interface().(string)
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				return "must be an email address"
+			}
+		default:
+			panic(fmt.Sprintf("alice: unknown validate rule %q", rule))
+		}
+	}
+	return ""
+}
+
+// sizeOf returns the length of strings and collections
+// and the value of numbers.
+func sizeOf(fv reflect.Value) (float64, bool) {
+	fv = reflect.Indirect(fv)
+	switch fv.Kind() {
+	case reflect.String:
+		return float64(len([]rune(fv.String()))), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(fv.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), true
+	}
+	return 0, false
+}
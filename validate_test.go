@@ -0,0 +1,113 @@
+package alice
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type createUser struct {
+	Name    string   `json:"name" validate:"required,max=5"`
+	Email   string   `json:"email" validate:"email"`
+	Role    string   `json:"role" validate:"oneof=admin member"`
+	Age     int      `json:"age" validate:"min=18"`
+	Tags    []string `json:"tags" validate:"max=2"`
+	Address *address `json:"address"`
+}
+
+func serveValidate(schemaOrStruct any, body string) (*httptest.ResponseRecorder, any, string) {
+	var decoded any
+	var raw []byte
+	h := New(Validate(schemaOrStruct)).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		decoded = ctx.Value(bodyKey{})
+		raw, _ = io.ReadAll(r.Body)
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+	return w, decoded, string(raw)
+}
+
+func TestValidateStoresDecodedStruct(t *testing.T) {
+	body := `{"name":"alice","email":"alice@example.com","role":"admin","age":30}`
+	w, _, raw := serveValidate(createUser{}, body)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, raw, body)
+
+	var user createUser
+	var ok bool
+	New(Validate(createUser{})).ThenWithContext(context.Background(), ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		user, ok = BodyFrom[createUser](ctx)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(body)))
+	assert.True(t, ok)
+	assert.Equal(t, user.Email, "alice@example.com")
+}
+
+func TestValidateReportsFieldErrors(t *testing.T) {
+	w, decoded, _ := serveValidate(createUser{}, `{"name":"alice smith","email":"nope","role":"root","age":12,"tags":["a","b","c"],"address":{}}`)
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+	assert.Nil(t, decoded)
+
+	var p struct {
+		Code   string            `json:"code"`
+		Fields map[string]string `json:"fields"`
+	}
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&p))
+	assert.Equal(t, p.Code, "invalid_body")
+	assert.Equal(t, p.Fields, map[string]string{
+		"name":         "must be at most 5",
+		"email":        "must be an email address",
+		"role":         "must be one of admin, member",
+		"age":          "must be at least 18",
+		"tags":         "must be at most 2",
+		"address.city": "is required",
+	})
+}
+
+func TestValidateRejectsMalformedJSON(t *testing.T) {
+	w, _, _ := serveValidate(createUser{}, `{"name":`)
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+	assert.Contains(t, w.Body.String(), "malformed_body")
+}
+
+type requireName struct{}
+
+func (requireName) ValidateJSON(v any) map[string]string {
+	if m, ok := v.(map[string]any); ok && m["name"] != nil {
+		return nil
+	}
+	return map[string]string{"name": "is required"}
+}
+
+func TestValidateWithSchema(t *testing.T) {
+	w, decoded, _ := serveValidate(requireName{}, `{"name":"alice","age":30}`)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, decoded, map[string]any{"name": "alice", "age": json.Number("30")})
+
+	w, _, _ = serveValidate(requireName{}, `{}`)
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
+
+func TestValidatePanicsOnInvalidPrototype(t *testing.T) {
+	assert.Panics(t, func() { Validate("nope") })
+
+	type unknownRule struct {
+		Name string `validate:"required,uppercase"`
+	}
+	assert.PanicsWithValue(t, `alice: unknown validate rule "uppercase"`, func() { Validate(unknownRule{}) })
+	type nestedInvalidRule struct {
+		Inner *struct {
+			Age int `validate:"min=ten"`
+		}
+	}
+	assert.PanicsWithValue(t, `alice: invalid validate rule "min=ten"`, func() { Validate(nestedInvalidRule{}) })
+}
@@ -0,0 +1,153 @@
+package alice
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// VersionOptions configure where Version looks for the API version.
+// Versions are normalized to the form "v2".
+type VersionOptions struct {
+	// Path enables a leading "/v2" path segment,
+	// which is stripped before the request continues.
+	Path bool
+	// Header, if set, names a header carrying the version, e.g. "Api-Version".
+	Header string
+	// Vendor, if set, enables vendor media types in the Accept header,
+	// e.g. "example" for "application/vnd.example.v2+json".
+	// A "version=2" media type parameter is recognized as well.
+	Vendor string
+	// Default is the version of requests not specifying one.
+	Default string
+	// Chains, if set, routes each version through its own chain.
+	// Requests for versions missing from it get 400 Bad Request.
+	Chains map[string]Chain
+}
+
+type versionKey struct{}
+
+// Version returns a Constructor extracting the API version of requests,
+// trying the path, then the header, then the Accept header,
+// and storing it in the context (see VersionFrom).
+//
+//	alice.New(alice.Version(alice.VersionOptions{
+//	    Path:    true,
+//	    Default: "v1",
+//	    Chains: map[string]alice.Chain{
+//	        "v1": alice.New(legacyAuth),
+//	        "v2": alice.New(oauth),
+//	    },
+//	}))
+func Version(opts VersionOptions) Constructor {
+	return func(next ContextHandler) ContextHandler {
+		var versioned map[string]ContextHandler
+		var branches []branch
+		if opts.Chains != nil {
+			versioned = make(map[string]ContextHandler, len(opts.Chains))
+			for v, c := range opts.Chains {
+				layers := c.layers(next)
+				versioned[normalizeVersion(v)] = layers[0]
+				branches = append(branches, branch{label: normalizeVersion(v), chain: c, layers: layers, continues: true})
+			}
+		}
+
+		serve := ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			v, r := extractVersion(r, opts)
+			if v != "" {
+				ctx = context.WithValue(ctx, versionKey{}, v)
+			}
+			if versioned == nil {
+				next.ServeHTTPContext(ctx, w, r)
+				return
+			}
+			h, ok := versioned[v]
+			if !ok {
+				WriteError(ctx, w, &HTTPError{
+					Status: http.StatusBadRequest,
+					Code:   "unsupported_version",
+					Detail: "unsupported API version " + v,
+				})
+				return
+			}
+			h.ServeHTTPContext(ctx, w, r)
+		})
+		return &branching{ContextHandler: serve, list: branches}
+	}
+}
+
+// extractVersion returns the version of r and r with any version
+// path segment stripped.
+func extractVersion(r *http.Request, opts VersionOptions) (string, *http.Request) {
+	if opts.Path {
+		seg, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if v := normalizeVersion(seg); v != "" && (seg[0] == 'v' || seg[0] == 'V') {
+			r2 := new(http.Request)
+			*r2 = *r
+			u := *r.URL
+			u.Path = "/" + rest
+			u.RawPath = ""
+			r2.URL = &u
+			return v, r2
+		}
+	}
+	if opts.Header != "" {
+		if v := normalizeVersion(r.Header.Get(opts.Header)); v != "" {
+			return v, r
+		}
+	}
+	if v := acceptVersion(r.Header.Get("Accept"), opts.Vendor); v != "" {
+		return v, r
+	}
+	return normalizeVersion(opts.Default), r
+}
+
+// acceptVersion finds a version in a vendor media type
+// or a version parameter of the Accept header.
+func acceptVersion(accept, vendor string) string {
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		if vendor != "" {
+			mt := strings.ToLower(strings.TrimSpace(params[0]))
+			prefix := "application/vnd." + strings.ToLower(vendor) + "."
+			if rest, ok := strings.CutPrefix(mt, prefix); ok {
+				seg, _, _ := strings.Cut(rest, "+")
+				if v := normalizeVersion(seg); v != "" {
+					return v
+				}
+			}
+		}
+		for _, p := range params[1:] {
+			k, val, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if strings.EqualFold(k, "version") {
+				if v := normalizeVersion(strings.Trim(val, `"`)); v != "" {
+					return v
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// normalizeVersion turns "2", "v2" and "V2" into "v2".
+// It returns "" for anything else.
+func normalizeVersion(s string) string {
+	s = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "v")
+	if s == "" {
+		return ""
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && c != '.' {
+			return ""
+		}
+	}
+	return "v" + s
+}
+
+// VersionFrom returns the API version found by Version,
+// or "" if there is none.
+func VersionFrom(ctx context.Context) string {
+	v, _ := ctx.Value(versionKey{}).(string)
+	return v
+}
@@ -0,0 +1,65 @@
+package alice
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+var writeVersion = ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(VersionFrom(ctx) + " " + r.URL.Path))
+})
+
+func TestVersionSources(t *testing.T) {
+	h := Version(VersionOptions{
+		Path:    true,
+		Header:  "Api-Version",
+		Vendor:  "example",
+		Default: "1",
+	})(writeVersion)
+
+	tests := []struct {
+		path, header, accept, want string
+	}{
+		{"/v2/users", "3", "", "v2 /users"},
+		{"/users", "3", "", "v3 /users"},
+		{"/users", "", "application/vnd.example.v4+json", "v4 /users"},
+		{"/users", "", "application/json; version=5", "v5 /users"},
+		{"/users", "", "application/json", "v1 /users"},
+		{"/version/users", "", "", "v1 /version/users"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", tt.path, nil)
+		r.Header.Set("Api-Version", tt.header)
+		r.Header.Set("Accept", tt.accept)
+		h.ServeHTTPContext(context.Background(), w, r)
+		assert.Equal(t, w.Body.String(), tt.want)
+	}
+}
+
+func TestVersionRoutesToChains(t *testing.T) {
+	h := Version(VersionOptions{
+		Path: true,
+		Chains: map[string]Chain{
+			"v1": New(tagMiddleware("legacy ")),
+			"v2": New(),
+		},
+	})(writeVersion)
+
+	for path, want := range map[string]string{
+		"/v1/users": "legacy v1 /users",
+		"/v2/users": "v2 /users",
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, w.Body.String(), want)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTPContext(context.Background(), w, httptest.NewRequest("GET", "/v3/users", nil))
+	assert.Equal(t, w.Code, http.StatusBadRequest)
+}
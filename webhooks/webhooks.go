@@ -0,0 +1,312 @@
+// Package webhooks receives webhook deliveries behind alice chains:
+// Verify buffers the body of deliveries and checks their signature,
+// as computed by GitHub, Stripe, Slack or any provider signing with
+// an HMAC, and Deduplicate acknowledges redeliveries of the events
+// already handled without handling them again.
+//
+//	store := alice.NewMemoryIdempotencyStore(24 * time.Hour)
+//	mux.Handle("POST /hooks/stripe", alice.New(
+//	    webhooks.Verify(webhooks.Stripe(secret)),
+//	    webhooks.Deduplicate(store, webhooks.JSONField("id")),
+//	).ThenWithContext(ctx, handler))
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"golang.org/x/net/context"
+)
+
+// DefaultMaxBodySize is the largest body Verify reads.
+const DefaultMaxBodySize = 1 << 20
+
+// DefaultTolerance is how far the timestamp of a delivery may be from
+// the current time, unless set otherwise.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrNoSignature is returned by Verifiers for deliveries without
+	// a signature.
+	ErrNoSignature = errors.New("webhooks: missing signature")
+	// ErrSignature is returned by Verifiers for deliveries whose
+	// signature does not match.
+	ErrSignature = errors.New("webhooks: invalid signature")
+	// ErrTimestamp is returned by Verifiers for deliveries whose
+	// timestamp is missing, malformed or out of tolerance.
+	ErrTimestamp = errors.New("webhooks: invalid timestamp")
+)
+
+// now is the current time, replaced by tests.
+var now = time.Now
+
+// A Verifier checks the signature of a delivery, given its body.
+type Verifier func(r *http.Request, body []byte) error
+
+// Options configure VerifyWith.
+type Options struct {
+	// MaxBodySize is the largest body read. Larger deliveries get
+	// 413 Request Entity Too Large. It defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+}
+
+type bodyKey struct{}
+
+// Verify returns a Constructor buffering the body of deliveries and
+// rejecting the ones v fails with 401 Unauthorized, with the code
+// "missing_signature", "invalid_signature" or "invalid_timestamp".
+// Handlers read the body from the request as usual, or from BodyFrom.
+func Verify(v Verifier) alice.Constructor {
+	return VerifyWith(v, Options{})
+}
+
+// VerifyWith is like Verify with options.
+func VerifyWith(v Verifier, opts Options) alice.Constructor {
+	limit := opts.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+	return func(next alice.ContextHandler) alice.ContextHandler {
+		return alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, limit))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				alice.WriteError(ctx, w, &alice.HTTPError{Status: http.StatusRequestEntityTooLarge, Err: err})
+				return
+			} else if err != nil {
+				alice.WriteError(ctx, w, &alice.HTTPError{Status: http.StatusBadRequest, Err: err})
+				return
+			}
+			if err := v(r, body); err != nil {
+				alice.WriteError(ctx, w, &alice.HTTPError{
+					Status: http.StatusUnauthorized,
+					Code:   errorCode(err),
+					Err:    err,
+				})
+				return
+			}
+
+			r2 := new(http.Request)
+			*r2 = *r
+			r2.Body = io.NopCloser(bytes.NewReader(body))
+			r2.ContentLength = int64(len(body))
+			next.ServeHTTPContext(context.WithValue(ctx, bodyKey{}, body), w, r2)
+		})
+	}
+}
+
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, ErrNoSignature):
+		return "missing_signature"
+	case errors.Is(err, ErrTimestamp):
+		return "invalid_timestamp"
+	}
+	return "invalid_signature"
+}
+
+// BodyFrom returns the body of the delivery verified by Verify,
+// or nil outside of it.
+func BodyFrom(ctx context.Context) []byte {
+	body, _ := ctx.Value(bodyKey{}).([]byte)
+	return body
+}
+
+// HMACOptions configure HMAC.
+type HMACOptions struct {
+	// Secret is the key shared with the provider.
+	Secret []byte
+	// Hash defaults to sha256.New.
+	Hash func() hash.Hash
+	// Header carries the signature, after Prefix if set,
+	// as in "X-Hub-Signature-256: sha256=<signature>".
+	Header string
+	Prefix string
+	// Base64 reads the signature as standard base64 instead of hex.
+	Base64 bool
+	// TimestampHeader, if set, carries the Unix time at which the
+	// delivery was signed, which must be within Tolerance of the current
+	// time so captured deliveries cannot be replayed. Tolerance defaults
+	// to DefaultTolerance.
+	TimestampHeader string
+	Tolerance       time.Duration
+	// Payload returns the signed payload given the timestamp and
+	// the body. It defaults to the body, preceded by the timestamp
+	// and a dot if TimestampHeader is set.
+	Payload func(timestamp string, body []byte) []byte
+}
+
+// HMAC returns a Verifier checking deliveries signed with an HMAC
+// of their payload.
+func HMAC(opts HMACOptions) Verifier {
+	if opts.Hash == nil {
+		opts.Hash = sha256.New
+	}
+	if opts.Payload == nil {
+		opts.Payload = func(timestamp string, body []byte) []byte {
+			if opts.TimestampHeader == "" {
+				return body
+			}
+			return append([]byte(timestamp+"."), body...)
+		}
+	}
+	return func(r *http.Request, body []byte) error {
+		sig, ok := strings.CutPrefix(r.Header.Get(opts.Header), opts.Prefix)
+		if !ok || sig == "" {
+			return ErrNoSignature
+		}
+		var timestamp string
+		if opts.TimestampHeader != "" {
+			timestamp = r.Header.Get(opts.TimestampHeader)
+			if err := checkTimestamp(timestamp, opts.Tolerance); err != nil {
+				return err
+			}
+		}
+		decode := hex.DecodeString
+		if opts.Base64 {
+			decode = base64.StdEncoding.DecodeString
+		}
+		mac, err := decode(sig)
+		if err != nil || !hmac.Equal(mac, sign(opts.Hash, opts.Secret, opts.Payload(timestamp, body))) {
+			return ErrSignature
+		}
+		return nil
+	}
+}
+
+// GitHub returns a Verifier checking the X-Hub-Signature-256 header
+// of GitHub deliveries. Their event ID is in the X-GitHub-Delivery header.
+func GitHub(secret string) Verifier {
+	return HMAC(HMACOptions{
+		Secret: []byte(secret),
+		Header: "X-Hub-Signature-256",
+		Prefix: "sha256=",
+	})
+}
+
+// Slack returns a Verifier checking the X-Slack-Signature header
+// of Slack requests, which must have been signed within DefaultTolerance.
+// The event ID of Events API deliveries is their "event_id" field.
+func Slack(secret string) Verifier {
+	return HMAC(HMACOptions{
+		Secret:          []byte(secret),
+		Header:          "X-Slack-Signature",
+		Prefix:          "v0=",
+		TimestampHeader: "X-Slack-Request-Timestamp",
+		Payload: func(timestamp string, body []byte) []byte {
+			return append([]byte("v0:"+timestamp+":"), body...)
+		},
+	})
+}
+
+// Stripe returns a Verifier checking the Stripe-Signature header
+// of Stripe deliveries, which must have been signed within
+// DefaultTolerance. Any of the v1 signatures of the header may match,
+// as while the secret is rolled. The event ID is the "id" field.
+func Stripe(secret string) Verifier {
+	return func(r *http.Request, body []byte) error {
+		var timestamp string
+		var sigs []string
+		for _, part := range strings.Split(r.Header.Get("Stripe-Signature"), ",") {
+			k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch k {
+			case "t":
+				timestamp = v
+			case "v1":
+				sigs = append(sigs, v)
+			}
+		}
+		if len(sigs) == 0 {
+			return ErrNoSignature
+		}
+		if err := checkTimestamp(timestamp, DefaultTolerance); err != nil {
+			return err
+		}
+		want := sign(sha256.New, []byte(secret), append([]byte(timestamp+"."), body...))
+		for _, sig := range sigs {
+			if mac, err := hex.DecodeString(sig); err == nil && hmac.Equal(mac, want) {
+				return nil
+			}
+		}
+		return ErrSignature
+	}
+}
+
+func sign(h func() hash.Hash, secret, payload []byte) []byte {
+	mac := hmac.New(h, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// checkTimestamp checks that the Unix time timestamp is within
+// tolerance of now, DefaultTolerance if zero.
+func checkTimestamp(timestamp string, tolerance time.Duration) error {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrTimestamp
+	}
+	if d := now().Sub(time.Unix(sec, 0)); d > tolerance || d < -tolerance {
+		return ErrTimestamp
+	}
+	return nil
+}
+
+// An EventID returns the ID of the event a delivery carries, given its
+// body, or "" if it has none.
+type EventID func(r *http.Request, body []byte) string
+
+// Header returns an EventID reading the header name,
+// such as X-GitHub-Delivery.
+func Header(name string) EventID {
+	return func(r *http.Request, body []byte) string {
+		return r.Header.Get(name)
+	}
+}
+
+// JSONField returns an EventID reading the top-level string field name
+// of JSON bodies, such as "id" for Stripe.
+func JSONField(name string) EventID {
+	return func(r *http.Request, body []byte) string {
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(body, &fields) != nil {
+			return ""
+		}
+		var id string
+		if json.Unmarshal(fields[name], &id) != nil {
+			return ""
+		}
+		return id
+	}
+}
+
+// Deduplicate returns a Constructor handling every event once, however
+// often the provider delivers it: the response to the first delivery of
+// an event is stored in store by event ID and replayed for redeliveries,
+// and redeliveries arriving while it is in flight get 409 Conflict, so
+// the provider retries them later (see alice.IdempotencyKeyed). Event IDs
+// are scoped to the request path. It goes after Verify, which buffers
+// the body given to id; deliveries without an event ID are passed through.
+func Deduplicate(store alice.IdempotencyStore, id EventID) alice.Constructor {
+	return alice.IdempotencyKeyed(store, func(ctx context.Context, r *http.Request) string {
+		event := id(r, BodyFrom(ctx))
+		if event == "" {
+			return ""
+		}
+		return "webhook " + r.URL.Path + " " + event
+	})
+}
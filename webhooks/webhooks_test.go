@@ -0,0 +1,154 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SimiPro/alice"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func hexMAC(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// echo writes the body it reads from the request and from BodyFrom.
+var echo = alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Write(body)
+	w.Write(BodyFrom(ctx))
+})
+
+func serve(cons alice.Constructor, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	alice.New(cons).ThenWithContext(context.Background(), echo).ServeHTTP(w, r)
+	return w
+}
+
+func errorCodeOf(w *httptest.ResponseRecorder) string {
+	var body struct{ Code string }
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return body.Code
+}
+
+func TestVerifyGitHub(t *testing.T) {
+	const payload = `{"action":"opened"}`
+	verify := Verify(GitHub("s3cret"))
+
+	r := httptest.NewRequest("POST", "/hooks", strings.NewReader(payload))
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hexMAC("s3cret", payload))
+	w := serve(verify, r)
+	assert.Equal(t, w.Code, http.StatusOK)
+	assert.Equal(t, w.Body.String(), payload+payload)
+
+	r = httptest.NewRequest("POST", "/hooks", strings.NewReader(payload))
+	r.Header.Set("X-Hub-Signature-256", "sha256="+hexMAC("other", payload))
+	w = serve(verify, r)
+	assert.Equal(t, w.Code, http.StatusUnauthorized)
+	assert.Equal(t, errorCodeOf(w), "invalid_signature")
+
+	w = serve(verify, httptest.NewRequest("POST", "/hooks", strings.NewReader(payload)))
+	assert.Equal(t, w.Code, http.StatusUnauthorized)
+	assert.Equal(t, errorCodeOf(w), "missing_signature")
+}
+
+func TestVerifyTimestampTolerance(t *testing.T) {
+	defer func(orig func() time.Time) { now = orig }(now)
+	current := time.Unix(1700000000, 0)
+	now = func() time.Time { return current }
+
+	const payload = `{"type":"event_callback"}`
+	verify := Verify(Slack("s3cret"))
+	request := func(signedAt time.Time) *http.Request {
+		ts := strconv.FormatInt(signedAt.Unix(), 10)
+		r := httptest.NewRequest("POST", "/slack", strings.NewReader(payload))
+		r.Header.Set("X-Slack-Request-Timestamp", ts)
+		r.Header.Set("X-Slack-Signature", "v0="+hexMAC("s3cret", "v0:"+ts+":"+payload))
+		return r
+	}
+
+	assert.Equal(t, serve(verify, request(current.Add(-time.Minute))).Code, http.StatusOK)
+	w := serve(verify, request(current.Add(-10*time.Minute)))
+	assert.Equal(t, w.Code, http.StatusUnauthorized)
+	assert.Equal(t, errorCodeOf(w), "invalid_timestamp")
+	assert.Equal(t, serve(verify, request(current.Add(10*time.Minute))).Code, http.StatusUnauthorized)
+}
+
+func TestVerifyStripe(t *testing.T) {
+	const payload = `{"id":"evt_1"}`
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	for header, status := range map[string]int{
+		"t=" + ts + ",v1=" + hexMAC("whsec", ts+"."+payload):                                          http.StatusOK,
+		"t=" + ts + ",v1=" + hexMAC("old", ts+"."+payload) + ",v1=" + hexMAC("whsec", ts+"."+payload): http.StatusOK,
+		"t=" + ts + ",v1=" + hexMAC("old", ts+"."+payload):                                            http.StatusUnauthorized,
+		"t=1,v1=" + hexMAC("whsec", "1."+payload):                                                     http.StatusUnauthorized,
+		"t=" + ts: http.StatusUnauthorized,
+	} {
+		r := httptest.NewRequest("POST", "/stripe", strings.NewReader(payload))
+		r.Header.Set("Stripe-Signature", header)
+		assert.Equal(t, serve(Verify(Stripe("whsec")), r).Code, status, header)
+	}
+}
+
+func TestVerifyHMACBase64(t *testing.T) {
+	const payload = "hello"
+	mac := hmac.New(sha256.New, []byte("key"))
+	mac.Write([]byte(payload))
+	r := httptest.NewRequest("POST", "/", strings.NewReader(payload))
+	r.Header.Set("X-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	w := serve(Verify(HMAC(HMACOptions{Secret: []byte("key"), Header: "X-Signature", Base64: true})), r)
+	assert.Equal(t, w.Code, http.StatusOK)
+}
+
+func TestVerifyLimitsBody(t *testing.T) {
+	verify := VerifyWith(func(r *http.Request, body []byte) error { return nil }, Options{MaxBodySize: 4})
+	assert.Equal(t, serve(verify, httptest.NewRequest("POST", "/", strings.NewReader("1234"))).Code, http.StatusOK)
+	assert.Equal(t, serve(verify, httptest.NewRequest("POST", "/", strings.NewReader("12345"))).Code, http.StatusRequestEntityTooLarge)
+}
+
+func TestDeduplicate(t *testing.T) {
+	store := alice.NewMemoryIdempotencyStore(time.Hour)
+	handled := 0
+	h := alice.New(
+		Verify(func(r *http.Request, body []byte) error { return nil }),
+		Deduplicate(store, JSONField("id")),
+	).ThenWithContext(context.Background(), alice.ContextHandlerFunc(func(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+		handled++
+		w.Write([]byte("handled " + strconv.Itoa(handled)))
+	}))
+	deliver := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/hooks", strings.NewReader(body)))
+		return w
+	}
+
+	assert.Equal(t, deliver(`{"id":"evt_1"}`).Body.String(), "handled 1")
+	redelivery := deliver(`{"id":"evt_1","attempt":2}`)
+	assert.Equal(t, redelivery.Code, http.StatusOK)
+	assert.Equal(t, redelivery.Body.String(), "handled 1")
+	assert.Equal(t, deliver(`{"id":"evt_2"}`).Body.String(), "handled 2")
+	assert.Equal(t, deliver(`{}`).Body.String(), "handled 3")
+	assert.Equal(t, deliver(`{}`).Body.String(), "handled 4")
+}
+
+func TestEventIDs(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", nil)
+	r.Header.Set("X-GitHub-Delivery", "72d3162e")
+	assert.Equal(t, Header("X-GitHub-Delivery")(r, nil), "72d3162e")
+	assert.Equal(t, JSONField("event_id")(r, []byte(`{"event_id":"Ev01","id":3}`)), "Ev01")
+	assert.Equal(t, JSONField("id")(r, []byte(`{"event_id":"Ev01","id":3}`)), "")
+	assert.Equal(t, JSONField("id")(r, []byte(`not json`)), "")
+}
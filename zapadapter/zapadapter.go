@@ -0,0 +1,28 @@
+// Package zapadapter adapts a zap logger to alice.Logger.
+package zapadapter
+
+import (
+	"github.com/SimiPro/alice"
+	"go.uber.org/zap"
+)
+
+type logger struct {
+	s *zap.SugaredLogger
+}
+
+// New returns an alice.Logger writing to l.
+func New(l *zap.Logger) alice.Logger {
+	return logger{l.Sugar()}
+}
+
+func (l logger) Debug(msg string, keysAndValues ...any) {
+	l.s.Debugw(msg, keysAndValues...)
+}
+
+func (l logger) Info(msg string, keysAndValues ...any) {
+	l.s.Infow(msg, keysAndValues...)
+}
+
+func (l logger) Error(msg string, keysAndValues ...any) {
+	l.s.Errorw(msg, keysAndValues...)
+}
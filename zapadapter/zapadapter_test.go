@@ -0,0 +1,20 @@
+package zapadapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNew(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	New(zap.New(core)).Error("failed", "status", 500)
+
+	entries := logs.All()
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Message, "failed")
+	assert.Equal(t, entries[0].Level, zap.ErrorLevel)
+	assert.Equal(t, entries[0].ContextMap()["status"], int64(500))
+}
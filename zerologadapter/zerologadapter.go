@@ -0,0 +1,28 @@
+// Package zerologadapter adapts a zerolog logger to alice.Logger.
+package zerologadapter
+
+import (
+	"github.com/SimiPro/alice"
+	"github.com/rs/zerolog"
+)
+
+type logger struct {
+	l zerolog.Logger
+}
+
+// New returns an alice.Logger writing to l.
+func New(l zerolog.Logger) alice.Logger {
+	return logger{l}
+}
+
+func (l logger) Debug(msg string, keysAndValues ...any) {
+	l.l.Debug().Fields(keysAndValues).Msg(msg)
+}
+
+func (l logger) Info(msg string, keysAndValues ...any) {
+	l.l.Info().Fields(keysAndValues).Msg(msg)
+}
+
+func (l logger) Error(msg string, keysAndValues ...any) {
+	l.l.Error().Fields(keysAndValues).Msg(msg)
+}
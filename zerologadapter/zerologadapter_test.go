@@ -0,0 +1,16 @@
+package zerologadapter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	New(zerolog.New(&buf)).Error("failed", "status", 500)
+
+	assert.Equal(t, buf.String(), `{"level":"error","status":500,"message":"failed"}`+"\n")
+}